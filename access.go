@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+// inviteAlphabet leaves out letters that are easily mistaken for digits, and digits
+// altogether, so that a code can never be read as a game ID
+const inviteAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// inviteCodeLength is how many characters an invite code has
+const inviteCodeLength = 6
+
+// RoomAccess controls who may join a room. Public rooms are listed on the home screen and
+// anyone can join them; private rooms are hidden, and can only be joined or watched with
+// their invite code or password.
+type RoomAccess struct {
+	Private    bool
+	InviteCode string
+	// PasswordHash is empty for private rooms without a password
+	PasswordHash string
+	// Opponent is the only registered name allowed to take the second seat, if set. They can
+	// join by the room's ID without its code or password.
+	Opponent string
+}
+
+// parseRoomAccess takes the options that make a room private out of the options given to
+// mk, returning them along with the options left for the game itself:
+// "private", "password <password>" and "for <name>"
+func parseRoomAccess(options string) (RoomAccess, string, error) {
+	access := RoomAccess{}
+	remaining := []string{}
+
+	fields := strings.Fields(options)
+	for i := 0; i < len(fields); i++ {
+		switch strings.ToLower(fields[i]) {
+		case "private":
+			access.Private = true
+		case "password":
+			if i+1 == len(fields) {
+				return access, "", errors.New("The syntax for a password is password <password>")
+			}
+			i++
+			access.Private = true
+			access.PasswordHash = hashCredential(fields[i])
+		case "for":
+			if i+1 == len(fields) {
+				return access, "", errors.New("The syntax for an invited opponent is for <name>")
+			}
+			i++
+			access.Private = true
+			access.Opponent = fields[i]
+		default:
+			remaining = append(remaining, fields[i])
+		}
+	}
+
+	if access.Private {
+		code, err := newInviteCode()
+		if err != nil {
+			return access, "", err
+		}
+		access.InviteCode = code
+	}
+
+	return access, strings.Join(remaining, " "), nil
+}
+
+// newInviteCode generates a short random code for a private room
+func newInviteCode() (string, error) {
+	// bytes past the last whole multiple of the alphabet's length are thrown away, so that
+	// every letter is as likely as the others
+	limit := 256 - 256%len(inviteAlphabet)
+
+	code := []byte{}
+	b := make([]byte, inviteCodeLength)
+	for len(code) < inviteCodeLength {
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
+
+		for _, value := range b {
+			if int(value) < limit && len(code) < inviteCodeLength {
+				code = append(code, inviteAlphabet[int(value)%len(inviteAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// unlocks checks a secret against the room's invite code and password, without leaking how
+// much of it matched
+func (access RoomAccess) unlocks(secret string) bool {
+	if secret == "" {
+		return false
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(secret))
+	if subtle.ConstantTimeCompare([]byte(code), []byte(access.InviteCode)) == 1 {
+		return true
+	}
+	return access.PasswordHash != "" && subtle.ConstantTimeCompare([]byte(hashCredential(secret)), []byte(access.PasswordHash)) == 1
+}
+
+// admitsPlayer checks whether a player called name, who gave secret, may take the second seat
+func (access RoomAccess) admitsPlayer(name string, secret string) error {
+	if !access.Private {
+		return nil
+	}
+
+	if access.Opponent != "" {
+		if !strings.EqualFold(name, access.Opponent) {
+			return errors.New("That room is reserved for " + access.Opponent)
+		}
+		return nil
+	}
+
+	if !access.unlocks(secret) {
+		return errors.New("That room is private: join it with its invite code or password")
+	}
+	return nil
+}
+
+// admitsSpectator checks whether someone who gave secret may watch the room
+func (access RoomAccess) admitsSpectator(secret string) error {
+	if access.Private && !access.unlocks(secret) {
+		return errors.New("That game is private: watch it with its invite code or password")
+	}
+	return nil
+}
+
+// gameByInviteCode finds the room with the given invite code, if any
+func (server *Server) gameByInviteCode(code string) *GameRoom {
+	server.M.Lock()
+	defer server.M.Unlock()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return nil
+	}
+	for _, game := range server.games {
+		if game.Access.Private && subtle.ConstantTimeCompare([]byte(code), []byte(game.Access.InviteCode)) == 1 {
+			return game
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseRoomAccess(t *testing.T) {
+	access, options, err := parseRoomAccess("9x9 password Hunter2 5m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options != "9x9 5m" || !access.Private || len(access.InviteCode) != inviteCodeLength {
+		t.Errorf("Expected a private room with 9x9 5m left over, got %v and '%s'", access, options)
+	}
+	if !access.unlocks("Hunter2") || access.unlocks("hunter2") || !access.unlocks(access.InviteCode) {
+		t.Error("Expected the password, case and all, and the invite code to unlock the room")
+	}
+
+	access, options, _ = parseRoomAccess("renju")
+	if access.Private || access.InviteCode != "" || options != "renju" {
+		t.Errorf("Expected a public room, got %v", access)
+	}
+
+	for _, invalid := range []string{"password", "9x9 for"} {
+		if _, _, err := parseRoomAccess(invalid); err == nil {
+			t.Errorf("Expected '%s' to be refused", invalid)
+		}
+	}
+}
+
+func TestPrivateRoom(t *testing.T) {
+	server := NewServer()
+
+	responses := server.handleCreate(Request{UserID: "mock_user1", Action: CREATE, Data: "private 9x9"}, nil)
+	created := responses[0].response
+	if !created.Success || created.InviteCode == "" || created.Settings.Size != 9 {
+		t.Fatalf("Expected a private 9x9 room with an invite code, got %v", created)
+	}
+	game := server.games[created.GameID]
+
+	home := server.handleSendToHome(nil)[0].response
+	if len(home.Home) != 0 {
+		t.Errorf("Expected the private room to be hidden, got %v", home.Home)
+	}
+
+	responses = server.handleJoin(Request{GameID: game.ID, UserID: "mock_user2", Action: JOIN}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected joining without the invite code to fail")
+	}
+
+	if server.gameByInviteCode("ABCDEFG") != nil || server.gameByInviteCode(created.InviteCode) != game {
+		t.Fatal("Expected to find the room by its invite code only")
+	}
+
+	responses = server.handleJoin(Request{GameID: game.ID, UserID: "mock_user2", Action: JOIN, Data: created.InviteCode}, nil, game)
+	if !responses[0].response.Success {
+		t.Fatalf("Expected the invite code to let the player in, got %s", responses[0].response.Data)
+	}
+
+	home = server.handleSendToHome(nil)[0].response
+	if len(home.Live) != 0 {
+		t.Errorf("Expected the private game to be hidden from the games in progress, got %v", home.Live)
+	}
+
+	game.Turn = 1
+	responses = server.handleWatch(Request{GameID: game.ID, UserID: "mock_user3", Action: WATCH}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected watching without the invite code to fail")
+	}
+
+	spectator := &SocketClient{}
+	responses = server.handleExport(Request{GameID: game.ID, UserID: "mock_user3", Action: EXPORT, Data: TEXT}, spectator, game)
+	if responses[0].response.Success {
+		t.Error("Expected exporting without the invite code to fail")
+	}
+
+	server.handleWatch(Request{GameID: game.ID, UserID: "mock_user3", Action: WATCH, Data: created.InviteCode}, spectator, game)
+	responses = server.handleExport(Request{GameID: game.ID, UserID: "mock_user3", Action: EXPORT, Data: TEXT}, spectator, game)
+	if !responses[0].response.Success {
+		t.Errorf("Expected a spectator who gave the invite code to export the game, got %s", responses[0].response.Data)
+	}
+
+	responses = server.handleExport(Request{GameID: game.ID, UserID: "mock_user2", Action: EXPORT, Data: TEXT}, nil, game)
+	if !responses[0].response.Success {
+		t.Errorf("Expected a player to export the game, got %s", responses[0].response.Data)
+	}
+}
+
+func TestReservedRoom(t *testing.T) {
+	server := NewServer()
+	server.names.Register("mock_user2", "bob", "")
+	server.names.Register("mock_user3", "carol", "")
+
+	responses := server.handleCreate(Request{UserID: "mock_user1", Action: CREATE, Data: "for Bob"}, nil)
+	game := server.games[responses[0].response.GameID]
+
+	responses = server.handleJoin(Request{GameID: game.ID, UserID: "mock_user3", Action: JOIN, Data: game.Access.InviteCode}, nil, game)
+	if responses[0].response.Success || responses[0].response.Data != "That room is reserved for Bob" {
+		t.Errorf("Expected the seat to be saved for bob, got %s", responses[0].response.Data)
+	}
+
+	responses = server.handleJoin(Request{GameID: game.ID, UserID: "mock_user2", Action: JOIN}, nil, game)
+	if !responses[0].response.Success {
+		t.Errorf("Expected bob to join by the room's id, got %s", responses[0].response.Data)
+	}
+}
+
+func TestFileStoreRestoresRoomAccess(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses := server.handleCreate(Request{UserID: "mock_user1", Action: CREATE, Data: "password secret"}, nil)
+	gameID := responses[0].response.GameID
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := restored.games[gameID]
+	if game == nil || !game.Access.Private || !game.Access.unlocks("secret") {
+		t.Fatalf("Expected the room to stay private after a restart, got %v", game)
+	}
+}
+
+func TestParseRoomTarget(t *testing.T) {
+	gameID, secret, err := parseRoomTarget("3 hunter2")
+	if err != nil || gameID != 3 || secret != "hunter2" {
+		t.Errorf("Expected game 3 with a password, got %d %s %v", gameID, secret, err)
+	}
+
+	gameID, secret, err = parseRoomTarget("k7qx3m")
+	if err != nil || gameID != -1 || secret != "k7qx3m" {
+		t.Errorf("Expected an invite code, got %d %s %v", gameID, secret, err)
+	}
+
+	if _, _, err := parseRoomTarget("room"); err == nil {
+		t.Error("Expected a word that isn't an invite code to be refused")
+	}
+
+	// a code of nothing but digits would be taken for a game ID
+	for i := 0; i < 1000; i++ {
+		code, err := newInviteCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gameID, secret, err := parseRoomTarget(code)
+		if err != nil || gameID != -1 || secret != code {
+			t.Fatalf("Expected %s to be read as an invite code, got %d %s %v", code, gameID, secret, err)
+		}
+	}
+}
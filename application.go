@@ -2,11 +2,32 @@ package main
 
 import (
 	"flag"
+	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 )
 
-func parseEnv() (string, string, bool) {
+// options are how the app was started, from its flags and environment
+type options struct {
+	port       string
+	host       string
+	clientMode bool
+	localMode  bool
+	replayPath string
+	render     string
+	cursor     bool
+	name       string
+}
+
+func parseEnv() options {
 	clientMode := flag.Bool("play", false, "activate client mode")
+	localMode := flag.Bool("local", false, "play two players at this terminal, without a server")
+	replayPath := flag.String("replay", "", "step through a game saved as psq, sgf or txt")
+	cursor := flag.Bool("cursor", false, "move a cursor over the board with the arrow keys or hjkl, and play with enter")
+	render := flag.String("render", "", "draw the board with ansi, unicode or ascii, or auto to suit the terminal")
+	name := flag.String("name", os.Getenv("GOMOKU_NAME"), "the name other players see, registered with the server")
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "5000"
@@ -18,17 +39,93 @@ func parseEnv() (string, string, bool) {
 	}
 
 	flag.Parse()
-	return port, host, *clientMode
+	return options{
+		port:       port,
+		host:       host,
+		clientMode: *clientMode,
+		localMode:  *localMode,
+		replayPath: *replayPath,
+		render:     *render,
+		cursor:     *cursor,
+		name:       *name,
+	}
 }
 
 func main() {
-	port, host, clientMode := parseEnv()
+	opts := parseEnv()
 
-	if clientMode == true {
+	var renderer *Renderer
+	if opts.replayPath != "" || opts.localMode || opts.clientMode {
+		var err error
+		renderer, err = SelectRenderer(opts.render, os.Getenv)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if opts.cursor && (opts.localMode || opts.clientMode) {
+		restore, err := enableKeyInput()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer restore()
+
+		// put the terminal back if the client is interrupted
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-interrupted
+			restore()
+			os.Exit(1)
+		}()
+	}
+
+	if opts.replayPath != "" {
+		replay, err := NewReplay(opts.replayPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		replay.renderer = renderer
+		replay.Run(os.Stdin)
+	} else if opts.localMode {
+		client := NewClient("GoGomoku")
+		client.renderer = renderer
+		if opts.cursor {
+			client.UseCursor()
+		}
+		client.RunLocal()
+	} else if opts.clientMode == true {
 		client := NewClient("GoGomoku")
-		client.Run(host, port)
+		client.renderer = renderer
+		if opts.cursor {
+			client.UseCursor()
+		}
+		client.sessionPath = defaultSessionPath()
+		client.identityPath = defaultIdentityPath()
+		client.name = opts.name
+		client.Run(opts.host, opts.port)
 	} else {
 		server := NewServer()
-		server.Listen(port)
+
+		dataDir := os.Getenv("DATA_DIR")
+		if dataDir != "" {
+			store, err := NewFileStore(dataDir)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = server.UseStore(store)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			ratings, err := NewFileRatingStore(filepath.Join(dataDir, "ratings.json"))
+			if err != nil {
+				log.Fatal(err)
+			}
+			server.UseRatingStore(ratings)
+		}
+
+		server.Listen(opts.port)
 	}
 }
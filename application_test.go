@@ -3,9 +3,14 @@ package main
 import (
 	"bytes"
 	"errors"
+	"net"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"go_gomoku/gomoku"
 )
 
 type incrementalReader struct {
@@ -44,9 +49,22 @@ func waitForHandledRequest(client *Client, action string) (Request, error) {
 	return request, err
 }
 
+// listen starts the server and waits until it accepts connections
+func listen(server *Server, port string) {
+	go server.Listen(port)
+	for {
+		conn, err := net.Dial("tcp", "localhost:"+port)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 func TestGoGomokuConnectSuccess(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	client := NewClient("Test")
@@ -63,7 +81,7 @@ func TestGoGomokuConnectSuccess(t *testing.T) {
 	}
 
 	// verify sent home
-	request, err := waitForHandledRequest(&client, HOME)
+	request, err := waitForHandledRequest(client, HOME)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -80,9 +98,17 @@ type PlayerBundle struct {
 }
 
 func setupClient(t *testing.T) (PlayerBundle, error) {
+	return setupClientMode(t, false)
+}
+
+// setupClientMode connects a client, reading key presses in cursor mode if cursor is set
+func setupClientMode(t *testing.T, cursor bool) (PlayerBundle, error) {
 	var err error
 	client := NewClient("Test")
 	client.disablePrint = true
+	if cursor {
+		client.UseCursor()
+	}
 	newSocketClient := client.Connect("localhost", "3003")
 	connected := make(chan bool)
 	reader := incrementalReader{make(chan string)}
@@ -95,29 +121,38 @@ func setupClient(t *testing.T) (PlayerBundle, error) {
 	}
 
 	go func() {
-		client.listenForInput(reader)
+		client.readInput(reader)
 	}()
 
 	player := PlayerBundle{
-		&client,
+		client,
 		newSocketClient,
 		&reader,
 	}
 
 	if err == nil {
 		// verify sent home
-		_, homeError := waitForHandledRequest(&client, HOME)
+		_, homeError := waitForHandledRequest(client, HOME)
 		if homeError != nil {
 			err = homeError
 		}
 	}
 
+	if err == nil {
+		// the server only takes requests from clients that have signed in
+		client.register()
+		_, registerError := waitForHandledRequest(client, REGISTER)
+		if registerError != nil {
+			err = registerError
+		}
+	}
+
 	return player, err
 }
 
 func TestGoGomokuCreateGameSuccess(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1Bundle, err := setupClient(t)
@@ -140,7 +175,7 @@ func TestGoGomokuCreateGameSuccess(t *testing.T) {
 
 func TestGoGomokuHomeFromGameSuccess(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1Bundle, err := setupClient(t)
@@ -170,7 +205,7 @@ func TestGoGomokuHomeFromGameSuccess(t *testing.T) {
 
 func TestGoGomokuHomeFromGameUnconfirmed(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1Bundle, err := setupClient(t)
@@ -196,7 +231,7 @@ func TestGoGomokuHomeFromGameUnconfirmed(t *testing.T) {
 
 func TestGoGomokuHomeFromGameRefused(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1Bundle, err := setupClient(t)
@@ -223,7 +258,7 @@ func TestGoGomokuHomeFromGameRefused(t *testing.T) {
 
 func TestGoGomokuHomeRefresh(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1Bundle, err := setupClient(t)
@@ -326,7 +361,7 @@ func setupGame(t *testing.T) (PlayerBundle, PlayerBundle, error) {
 
 func TestGoGomokuJoinGameSuccess(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -352,9 +387,9 @@ func TestGoGomokuJoinGameSuccess(t *testing.T) {
 
 func boardsAreEqual(game *GameRoom, player1 PlayerBundle, player2 PlayerBundle) bool {
 	for _, color := range []string{"white","black"} {
-		boardSpaces := game.Board.listSpaces(color)
-		player1Spaces := player1.client.board.listSpaces(color)
-		player2Spaces := player2.client.board.listSpaces(color)
+		boardSpaces := game.Board.ListSpaces(color)
+		player1Spaces := player1.client.board.ListSpaces(color)
+		player2Spaces := player2.client.board.ListSpaces(color)
 		for i, space := range(boardSpaces) {
 			if player1Spaces[i] != space {
 				return false
@@ -405,7 +440,7 @@ func playMoveAndValidateBoardStates(game *GameRoom, move string, movingPlayer Pl
 
 func TestGoGomokuFirstMoveSuccess(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -421,8 +456,8 @@ func TestGoGomokuFirstMoveSuccess(t *testing.T) {
 		t.Error(err)
 	}
 
-	boardSpacesWhite := game.Board.listSpaces("white")
-	boardSpacesBlack := game.Board.listSpaces("black")
+	boardSpacesWhite := game.Board.ListSpaces("white")
+	boardSpacesBlack := game.Board.ListSpaces("black")
 	expectedWhiteSpaces := []string{"1 3"}
 	expectedBlackSpaces := []string{"1 1", "1 2"}
 	for i, space := range boardSpacesWhite {
@@ -443,7 +478,7 @@ func TestGoGomokuFirstMoveSuccess(t *testing.T) {
 
 func TestGoGomokuSecondMovePass(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -479,8 +514,8 @@ func TestGoGomokuSecondMovePass(t *testing.T) {
 		t.Errorf("Expected player 2's opponentColor to be white, got %s", player2.client.opponentColor)
 	}
 
-	boardSpacesWhite := game.Board.listSpaces("white")
-	boardSpacesBlack := game.Board.listSpaces("black")
+	boardSpacesWhite := game.Board.ListSpaces("white")
+	boardSpacesBlack := game.Board.ListSpaces("black")
 	expectedWhiteSpaces := []string{"1 3"}
 	expectedBlackSpaces := []string{"1 1", "1 2"}
 	for i, space := range boardSpacesWhite {
@@ -501,7 +536,7 @@ func TestGoGomokuSecondMovePass(t *testing.T) {
 
 func TestGoGomokuSecondMoveSuccess(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -538,8 +573,8 @@ func TestGoGomokuSecondMoveSuccess(t *testing.T) {
 		t.Errorf("Expected player 2's opponentColor to be black, got %s", player2.client.opponentColor)
 	}
 
-	boardSpacesWhite := game.Board.listSpaces("white")
-	boardSpacesBlack := game.Board.listSpaces("black")
+	boardSpacesWhite := game.Board.ListSpaces("white")
+	boardSpacesBlack := game.Board.ListSpaces("black")
 	expectedWhiteSpaces := []string{"1 3", "1 4"}
 	expectedBlackSpaces := []string{"1 1", "1 2"}
 	for i, space := range boardSpacesWhite {
@@ -560,7 +595,7 @@ func TestGoGomokuSecondMoveSuccess(t *testing.T) {
 
 func TestGoGomokuFurtherMoveSuccessAfterPass(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -592,8 +627,8 @@ func TestGoGomokuFurtherMoveSuccessAfterPass(t *testing.T) {
 		t.Error(err)
 	}
 
-	boardSpacesWhite := game.Board.listSpaces("white")
-	boardSpacesBlack := game.Board.listSpaces("black")
+	boardSpacesWhite := game.Board.ListSpaces("white")
+	boardSpacesBlack := game.Board.ListSpaces("black")
 	expectedWhiteSpaces := []string{"1 3", "1 4"}
 	expectedBlackSpaces := []string{"1 1", "1 2", "1 5"}
 	for i, space := range boardSpacesWhite {
@@ -614,7 +649,7 @@ func TestGoGomokuFurtherMoveSuccessAfterPass(t *testing.T) {
 
 func TestGoGomokuBlackWin(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -661,8 +696,8 @@ func TestGoGomokuBlackWin(t *testing.T) {
 		t.Error(err)
 	}
 
-	boardSpacesWhite := game.Board.listSpaces("white")
-	boardSpacesBlack := game.Board.listSpaces("black")
+	boardSpacesWhite := game.Board.ListSpaces("white")
+	boardSpacesBlack := game.Board.ListSpaces("black")
 	expectedWhiteSpaces := []string{"8 1", "8 2", "8 3", "8 4"}
 	expectedBlackSpaces := []string{"1 1", "1 2", "1 3", "1 4", "1 5"}
 	for i, space := range boardSpacesWhite {
@@ -685,7 +720,7 @@ func TestGoGomokuBlackWin(t *testing.T) {
 
 func TestGoGomokuWhiteWin(t *testing.T) {
 	server := NewServer()
-	go server.Listen("3003")
+	listen(&server, "3003")
 	defer server.Stop()
 
 	player1, player2, err := setupGame(t)
@@ -737,8 +772,8 @@ func TestGoGomokuWhiteWin(t *testing.T) {
 		t.Error(err)
 	}
 
-	boardSpacesWhite := game.Board.listSpaces("white")
-	boardSpacesBlack := game.Board.listSpaces("black")
+	boardSpacesWhite := game.Board.ListSpaces("white")
+	boardSpacesBlack := game.Board.ListSpaces("black")
 	expectedWhiteSpaces := []string{"8 1", "8 2", "8 3", "8 4", "8 5"}
 	expectedBlackSpaces := []string{"1 1", "1 2", "1 3", "1 4", "1 6"}
 	for i, space := range boardSpacesWhite {
@@ -757,3 +792,857 @@ func TestGoGomokuWhiteWin(t *testing.T) {
 		t.Fatal("Expected game to be over")
 	}
 }
+
+func TestGoGomokuBotGame(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player, err := setupClient(t)
+	defer player.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player.reader.input <- "mk bot easy\n"
+	_, err = waitForHandledRequest(player.client, OTHERJOINED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if player.client.yourTurn {
+		player.reader.input <- "mv 1 1, 1 2, 1 3\n"
+		_, err = waitForHandledRequest(player.client, MOVE)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the bot moves on its own
+	_, err = waitForHandledRequest(player.client, MOVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !player.client.yourTurn {
+		t.Error("Expected it to be the player's turn after the bot moved")
+	}
+
+	game := server.games[player.client.GameID]
+	if len(game.Players) != 2 {
+		t.Errorf("Expected bot game to have 2 players, got %d", len(game.Players))
+	}
+}
+
+func TestGoGomokuResumeAfterDisconnect(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := server.games[player1.client.GameID]
+	err = playMoveAndValidateBoardStates(game, "mv 1 1, 1 2, 1 3\n", player1, player2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "mg are you still there?\n"
+	_, err = waitForHandledRequest(player2.client, MESSAGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// player 2's connection drops, and they come back with their saved session
+	player2.socketClient.Socket.Close()
+
+	client := NewClient("Test")
+	client.disablePrint = true
+	client.userID = player2.client.userID
+	client.session = player2.client.session
+	socketClient := client.Connect("localhost", "3003")
+	defer socketClient.Socket.Close()
+
+	connected := make(chan bool)
+	go socketClient.Receive(client.handler, &connected)
+	_, err = waitForHandledRequest(client, HOME)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.resumeGame()
+	_, err = waitForHandledRequest(client, RESUME)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = waitForHandledRequest(player1.client, OTHERRESUMED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.GameID != player1.client.GameID {
+		t.Errorf("Expected to resume game #%d, got #%d", player1.client.GameID, client.GameID)
+	}
+
+	if !client.yourTurn || client.turn != 2 {
+		t.Errorf("Expected it to be the resumed player's turn on turn 2, got turn %d (yourTurn: %t)", client.turn, client.yourTurn)
+	}
+
+	if len(client.board.ListSpaces("black")) != 2 || len(client.board.ListSpaces("white")) != 1 {
+		t.Errorf("Expected the board to be resumed, got %v", client.board.Spaces())
+	}
+
+	if len(client.messages) < 1 || client.messages[0].Author != "Opponent" || client.messages[0].Content != "are you still there?" {
+		t.Errorf("Expected the chat to be resumed, got %v", client.messages)
+	}
+
+	if game.Players[client.userID].SocketClient.isClosed() {
+		t.Error("Expected the player to be bound to their new connection")
+	}
+}
+
+func TestGoGomokuResumeWrongToken(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2.client.session.Token = "not the token"
+	player2.client.resumeGame()
+	request, err := waitForHandledRequest(player2.client, RESUME)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Success {
+		t.Error("Expected resume with the wrong token to fail")
+	}
+
+	if player2.client.session.Token != "" {
+		t.Error("Expected the client to forget its session after a failed resume")
+	}
+}
+
+func TestGoGomokuImpersonation(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a third client that knows player 1's ID can't resign for them
+	other, err := setupClient(t)
+	defer other.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other.client.sendToServer(Request{GameID: player1.client.GameID, UserID: player1.client.userID, Action: RESIGN})
+	request, err := waitForHandledRequest(other.client, RESIGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Success || server.games[player1.client.GameID].IsOver {
+		t.Error("Expected a resignation for another player to be refused")
+	}
+
+	// nor sign in as them without their credential
+	client := NewClient("Test")
+	client.disablePrint = true
+	client.userID = player1.client.userID
+	socketClient := client.Connect("localhost", "3003")
+	defer socketClient.Socket.Close()
+
+	connected := make(chan bool)
+	go socketClient.Receive(client.handler, &connected)
+	<-connected
+
+	client.register()
+	request, err = waitForHandledRequest(client, REGISTER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Success {
+		t.Error("Expected signing in as another player to fail")
+	}
+
+	client.sendToServer(Request{GameID: player1.client.GameID, UserID: player1.client.userID, Action: RESIGN})
+	request, err = waitForHandledRequest(client, RESIGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Success || server.games[player1.client.GameID].IsOver {
+		t.Error("Expected a client that hasn't signed in to be refused")
+	}
+}
+
+func TestGoGomokuWatchGame(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spectator, err := setupClient(t)
+	defer spectator.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the game in progress is listed on the home screen
+	spectator.reader.input <- "hm\n"
+	request, err := waitForHandledRequest(spectator.client, HOME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Live) != 1 || request.Live[0].ID != player1.client.GameID {
+		t.Fatalf("Expected game #%d to be listed as in progress, got %v", player1.client.GameID, request.Live)
+	}
+
+	spectator.reader.input <- "wt " + strconv.Itoa(player1.client.GameID) + "\n"
+	_, err = waitForHandledRequest(spectator.client, WATCH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spectator.client.watching || spectator.client.GameID != player1.client.GameID {
+		t.Fatalf("Expected to be watching game #%d", player1.client.GameID)
+	}
+
+	game := server.games[player1.client.GameID]
+	err = playMoveAndValidateBoardStates(game, "mv 1 1, 1 2, 1 3\n", player1, player2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = waitForHandledRequest(spectator.client, MOVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spectator.client.board.ListSpaces("black")) != 2 || spectator.client.yourTurn {
+		t.Errorf("Expected spectator to see the move without getting a turn")
+	}
+
+	// spectators can't move
+	spectator.reader.input <- "mv 5 5\n"
+	_, err = waitForHandledRequest(player2.client, MOVE)
+	if err == nil {
+		t.Error("Expected spectator's move not to be sent")
+	}
+
+	spectator.reader.input <- "hm\n"
+	_, err = waitForHandledRequest(spectator.client, HOME)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game.M.Lock()
+	defer game.M.Unlock()
+	if len(game.Spectators) != 0 {
+		t.Errorf("Expected spectator to stop watching, got %d spectators", len(game.Spectators))
+	}
+}
+
+func TestGoGomokuWatchOpenGame(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player, err := setupClient(t)
+	defer player.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player.reader.input <- "mk\n"
+	_, err = waitForHandledRequest(player.client, CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spectator, err := setupClient(t)
+	defer spectator.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spectator.reader.input <- "wt " + strconv.Itoa(player.client.GameID) + "\n"
+	request, err := waitForHandledRequest(spectator.client, WATCH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Success || spectator.client.watching {
+		t.Error("Expected watching a game that hasn't started to fail")
+	}
+}
+
+func TestGoGomokuTimeout(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, err := setupClient(t)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2, err := setupClient(t)
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "mk 500ms\n"
+	_, err = waitForHandledRequest(player1.client, CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2.reader.input <- "jn " + strconv.Itoa(player1.client.GameID) + "\n"
+	request, err := waitForHandledRequest(player2.client, JOIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(request.Clocks) != 2 {
+		t.Errorf("Expected clocks for both players, got %v", request.Clocks)
+	}
+
+	// nobody moves, so the first player's flag falls
+	request, err = waitForHandledRequest(player2.client, TIMEOUT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := server.games[player1.client.GameID]
+	if request.UserID != game.FirstPlayerID {
+		t.Errorf("Expected first player to run out of time, got %s", request.UserID)
+	}
+
+	if !player2.client.gameOver || !game.IsOver {
+		t.Error("Expected game to be over")
+	}
+}
+
+func TestGoGomokuResign(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "rs\n"
+	player1.reader.input <- "y\n"
+	_, err = waitForHandledRequest(player2.client, RESIGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !player2.client.gameOver {
+		t.Error("Expected game to be over")
+	}
+
+	if description := player2.client.describeResult(); description != "You won by resignation." {
+		t.Errorf("Expected 'You won by resignation.', got '%s'", description)
+	}
+}
+
+func TestGoGomokuDrawAgreed(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "dr\n"
+	_, err = waitForHandledRequest(player1.client, DRAW)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = waitForHandledRequest(player2.client, DRAW)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2.reader.input <- "dr\n"
+	request, err := waitForHandledRequest(player1.client, DRAW)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !request.GameOver || request.Result.Kind != gomoku.DRAWN {
+		t.Errorf("Expected the game to be drawn, got %v", request.Result)
+	}
+
+	if description := player1.client.describeResult(); description != "It's a draw." {
+		t.Errorf("Expected 'It's a draw.', got '%s'", description)
+	}
+}
+
+func TestGoGomokuRematch(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, player2, err := setupGame(t)
+	defer player1.socketClient.Socket.Close()
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	game := server.games[player1.client.GameID]
+	firstPlayerID := game.FirstPlayerID
+
+	player1.reader.input <- "rs\n"
+	player1.reader.input <- "y\n"
+	for _, player := range []PlayerBundle{player1, player2} {
+		_, err = waitForHandledRequest(player.client, RESIGN)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	player1.reader.input <- "re\n"
+	for _, player := range []PlayerBundle{player1, player2} {
+		_, err = waitForHandledRequest(player.client, REMATCH)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	player2.reader.input <- "re\n"
+	for _, player := range []PlayerBundle{player1, player2} {
+		_, err = waitForHandledRequest(player.client, REMATCH)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if player1.client.gameOver || player2.client.gameOver {
+		t.Error("Expected the rematch to have started")
+	}
+
+	if player1.client.yourTurn || !player2.client.yourTurn {
+		t.Error("Expected the other player to go first in the rematch")
+	}
+
+	if game.FirstPlayerID == firstPlayerID {
+		t.Error("Expected the first player to alternate")
+	}
+
+	if score := player2.client.describeScore(game.Score); score != "You 1 - 0 Opponent" {
+		t.Errorf("Expected 'You 1 - 0 Opponent', got '%s'", score)
+	}
+}
+
+func TestGoGomokuCursorMoves(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, err := setupClientMode(t, true)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2, err := setupClientMode(t, true)
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// commands are typed after :
+	player1.reader.input <- ":"
+	player1.reader.input <- "mk\r"
+	_, err = waitForHandledRequest(player1.client, CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2.reader.input <- ":jn " + strconv.Itoa(player1.client.GameID) + "\r"
+	_, err = waitForHandledRequest(player2.client, JOIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = waitForHandledRequest(player1.client, OTHERJOINED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := player1, player2
+	if player2.client.yourTurn {
+		first, second = player2, player1
+	}
+
+	// select two black stones and a white one, with an arrow key split across reads
+	for _, keys := range []string{" ", "\x1b", "[C", " ", "jj", " ", "\r"} {
+		first.reader.input <- keys
+	}
+	game := server.games[player1.client.GameID]
+	_, err = waitForHandledRequest(first.client, MOVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = waitForHandledRequest(second.client, MOVE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{"8 8": "black", "8 9": "black", "10 9": "white"}
+	for space, color := range expected {
+		coord, _ := gomoku.ParseCoord(space)
+		if game.Board.IsTakenBy(coord) != color {
+			t.Errorf("Expected %s on %s, got %s", color, space, game.Board.IsTakenBy(coord))
+		}
+	}
+	if !boardsAreEqual(game, first, second) {
+		t.Error("After move, boards are not equal")
+	}
+
+	// chat is still available
+	second.reader.input <- "m"
+	second.reader.input <- "good luck\r"
+	request, err := waitForHandledRequest(first.client, MESSAGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Data != "good luck" {
+		t.Errorf("Expected the message 'good luck', got '%s'", request.Data)
+	}
+}
+
+func TestGoGomokuNames(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, err := setupClient(t)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2, err := setupClient(t)
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "nm alice\n"
+	request, err := waitForHandledRequest(player1.client, REGISTER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !request.Success || player1.client.identity.Name != "alice" || player1.client.identity.Credential == "" {
+		t.Fatalf("Expected alice to be registered, got %v", player1.client.identity)
+	}
+
+	player2.reader.input <- "nm Alice\n"
+	request, err = waitForHandledRequest(player2.client, REGISTER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Success {
+		t.Error("Expected the name Alice to be taken")
+	}
+
+	player2.reader.input <- "nm bob\n"
+	_, err = waitForHandledRequest(player2.client, REGISTER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "mk\n"
+	_, err = waitForHandledRequest(player1.client, CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2.reader.input <- "hm\n"
+	request, err = waitForHandledRequest(player2.client, HOME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Home) != 1 || request.Home[0].Name != "alice" {
+		t.Errorf("Expected alice's game on the home screen, got %v", request.Home)
+	}
+
+	player2.reader.input <- "jn " + strconv.Itoa(player1.client.GameID) + "\n"
+	_, err = waitForHandledRequest(player1.client, OTHERJOINED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	told := false
+	for _, message := range player1.client.messages {
+		told = told || message.Content == "bob joined. Let the game begin!"
+	}
+	if !told {
+		t.Errorf("Expected to be told that bob joined, got %v", player1.client.messages)
+	}
+
+	player2.reader.input <- "mg good luck\n"
+	_, err = waitForHandledRequest(player1.client, MESSAGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := player1.client.messages[len(player1.client.messages)-1]
+	if last.Author != "bob" || last.Content != "good luck" {
+		t.Errorf("Expected bob's message, got %v", last)
+	}
+}
+
+func TestGoGomokuIdentityKept(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	path := filepath.Join(t.TempDir(), "identity.json")
+
+	connect := func(name string) (*Client, *SocketClient) {
+		client := NewClient("Test")
+		client.disablePrint = true
+		client.identityPath = path
+		client.name = name
+		socketClient := client.Connect("localhost", "3003")
+
+		connected := make(chan bool)
+		go socketClient.Receive(client.handler, &connected)
+		<-connected
+
+		client.register()
+		request, err := waitForHandledRequest(client, REGISTER)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !request.Success {
+			t.Fatalf("Expected to register as carol, got: %s", request.Data)
+		}
+		return client, socketClient
+	}
+
+	first, socketClient := connect("carol")
+	socketClient.Socket.Close()
+
+	// the next run signs in to the saved name without being told it
+	second, socketClient := connect("")
+	defer socketClient.Socket.Close()
+
+	if second.userID != first.userID || second.identity.Name != "carol" {
+		t.Errorf("Expected to come back as carol (%s), got %s (%s)", first.userID, second.identity.Name, second.userID)
+	}
+}
+
+func TestGoGomokuQueue(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, err := setupClient(t)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2, err := setupClient(t)
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "queue 9x9\n"
+	request, err := waitForHandledRequest(player1.client, QUEUE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !request.Success || player1.client.GameID != -1 {
+		t.Fatalf("Expected to wait in the queue, got %s", request.Data)
+	}
+
+	player2.reader.input <- "qu 9x9\n"
+	_, err = waitForHandledRequest(player2.client, JOIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = waitForHandledRequest(player1.client, OTHERJOINED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if player1.client.GameID == -1 || player1.client.GameID != player2.client.GameID {
+		t.Errorf("Expected both players in the same game, got %d and %d", player1.client.GameID, player2.client.GameID)
+	}
+	if player1.client.board.Size != 9 {
+		t.Errorf("Expected a 9x9 board, got %d", player1.client.board.Size)
+	}
+}
+
+func TestGoGomokuTournament(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, err := setupClient(t)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2, err := setupClient(t)
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "tn mk roundrobin 9x9\n"
+	request, err := waitForHandledRequest(player1.client, TOURNAMENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !request.Success {
+		t.Fatalf("Expected the tournament to be created, got %s", request.Data)
+	}
+
+	player1.reader.input <- "tn jn 0\n"
+	_, err = waitForHandledRequest(player1.client, TOURNAMENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	player2.reader.input <- "tn jn 0\n"
+	_, err = waitForHandledRequest(player2.client, TOURNAMENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2.reader.input <- "tn\n"
+	request, err = waitForHandledRequest(player2.client, TOURNAMENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Tournaments) != 1 || request.Tournaments[0].Players != 2 {
+		t.Fatalf("Expected one tournament with two players, got %v", request.Tournaments)
+	}
+
+	player1.reader.input <- "tn go 0\n"
+	request, err = waitForHandledRequest(player2.client, TOURNAMENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Round != 1 {
+		t.Errorf("Expected the first round to be announced, got %v", request)
+	}
+	_, err = waitForHandledRequest(player2.client, JOIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = waitForHandledRequest(player1.client, OTHERJOINED)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if player1.client.GameID == -1 || player1.client.GameID != player2.client.GameID {
+		t.Errorf("Expected both players in the same game, got %d and %d", player1.client.GameID, player2.client.GameID)
+	}
+	if player1.client.messages[0].Content != "Round 1 of tournament #0: you play "+guestName(player2.client.userID) {
+		t.Errorf("Expected the round to be announced above the board, got %v", player1.client.messages)
+	}
+
+	player2.reader.input <- "tn st 0\n"
+	request, err = waitForHandledRequest(player2.client, TOURNAMENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Standings) != 2 || !strings.Contains(request.Data, "round 1 of 1") {
+		t.Errorf("Expected standings for the first of one round, got %s %v", request.Data, request.Standings)
+	}
+}
+
+func TestGoGomokuPrivateRoom(t *testing.T) {
+	server := NewServer()
+	listen(&server, "3003")
+	defer server.Stop()
+
+	player1, err := setupClient(t)
+	defer player1.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player2, err := setupClient(t)
+	defer player2.socketClient.Socket.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	player1.reader.input <- "mk private\n"
+	request, err := waitForHandledRequest(player1.client, CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.InviteCode == "" {
+		t.Fatal("Expected an invite code for the private room")
+	}
+
+	player2.reader.input <- "hm\n"
+	request, err = waitForHandledRequest(player2.client, HOME)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Home) != 0 {
+		t.Errorf("Expected the private room to be hidden, got %v", request.Home)
+	}
+
+	player2.reader.input <- "jn " + strconv.Itoa(player1.client.GameID) + "\n"
+	request, err = waitForHandledRequest(player2.client, JOIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Success {
+		t.Fatal("Expected joining the private room by its id to fail")
+	}
+
+	code := player1.client.messages[len(player1.client.messages)-1].Content
+	code = code[strings.Index(code, "'jn ")+4 : len(code)-1]
+	player2.reader.input <- "jn " + strings.ToLower(code) + "\n"
+	_, err = waitForHandledRequest(player2.client, JOIN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if player2.client.GameID != player1.client.GameID {
+		t.Errorf("Expected to join game %d with the invite code, got %d", player1.client.GameID, player2.client.GameID)
+	}
+}
@@ -1,271 +1,145 @@
 package main
 
 import (
-	"fmt"
-	"sort"
-	"strconv"
-	"strings"
+	"go_gomoku/gomoku"
 )
 
-var (
-	axes                  = [4][2]int{[2]int{-1, -1}, [2]int{-1, 0}, [2]int{-1, 1}, [2]int{0, -1}}
+// how a stone is highlighted, after its color in what stoneAt returns
+const (
+	lastMoveMark = " last"
+	winningMark  = " winning"
 )
 
+// what stoneAt returns for the cursor, and for free spaces selected for the next move
 const (
-	topLeft               = "\u250F"
-	bottomLeft            = "\u2517"
-	topRight              = "\u2513"
-	bottomRight           = "\u251B"
-	horizontal            = "\u2500"
-	horizontal2           = "\u2500\u2500"
-	horizontal3           = "\u2500\u2500\u2500"
-	space                 = " "
-	space2                = "  "
-	space3                = "   "
-	vertical              = "\u2503"
-	bottomIntersection    = "\u253B"
-	rightIntersection     = "\u252B"
-	topIntersection       = "\u2533"
-	leftIntersection      = "\u2523"
-	fullIntersection      = "\u254B"
-	black                 = "\u25C9"
-	white                 = "\u25EF"
-	horizontalAfterPiece  = space
-	horizontal2AfterPiece = space + horizontal
-	horizontal3AfterPiece = space + horizontal2
+	cursorMark   = "cursor"
+	selectedMark = "selected"
 )
 
-// Board contains the state of the game board
-type Board struct {
-	Spaces map[string]map[string]bool
+// BoardView is a board as shown in the terminal, which a Renderer draws
+type BoardView struct {
+	gomoku.Board
+	// lastMove is the stones placed on the last turn, which are marked
+	lastMove []gomoku.Coord
+	// winningLine is the line that won the game, which is marked instead of the last move
+	winningLine []gomoku.Coord
+	// cursor is where the player is pointing in cursor mode, or nil
+	cursor *gomoku.Coord
+	// selected is the spaces chosen in cursor mode for a move of several stones
+	selected []gomoku.Coord
 }
 
-// BoardInterface defines methods a Board should implement
-type BoardInterface interface {
-	checkForWin(Coord, string) bool
-	checkOwnership(int, string, Coord) (bool, Request)
-	printBoard()
-	checkAlongAxis(string, [2]int, Coord, int) int
-	getAxisLabel(int, int) string
-	getColorCode(string) string
-	getColumnChar(int, int) string
-	getCoord(int, int) Coord
-	getRowChar(int, int, string, string) string
-	intersectionOrSpace(string, string, string, bool) string
-	isTakenBy(Coord) string
+// BoardViewInterface defines methods a BoardView should implement
+type BoardViewInterface interface {
+	getCoord(int, int) gomoku.Coord
+	lastColumn() int
+	lastRow() int
+	centreCursor()
+	moveCursor(int, int)
+	toggleSelected(gomoku.Coord)
+	setPosition(map[string]map[string]bool, []gomoku.Coord, []gomoku.Coord)
+	stoneAt(gomoku.Coord) string
 }
 
-// assert that Board implements Interface
-var _ BoardInterface = (*Board)(nil)
+// assert that BoardView implements Interface
+var _ BoardViewInterface = (*BoardView)(nil)
 
-// New creates an empty board
-func NewBoard() Board {
-	spaces := make(map[string]map[string]bool)
-	spaces["white"] = make(map[string]bool)
-	spaces["black"] = make(map[string]bool)
-	return Board{
-		Spaces: spaces,
-	}
+// NewBoardView creates an empty size x size board to draw
+func NewBoardView(size int, winLength int) BoardView {
+	return BoardView{Board: gomoku.NewSizedBoard(size, winLength)}
 }
 
-func (board *Board) listSpaces(color string) []string {
-	spaces := []string{}
-	for space, _ := range board.Spaces[color] {
-		spaces = append(spaces, space)
-	}
-	sort.Strings(spaces)
-	return spaces
+// setPosition replaces the stones with those sent by the server, and marks the last move
+// and the winning line
+func (board *BoardView) setPosition(spaces map[string]map[string]bool, lastMove []gomoku.Coord, winningLine []gomoku.Coord) {
+	board.Board = gomoku.NewBoardFromSpaces(board.Size, board.WinLength, spaces)
+	board.lastMove = lastMove
+	board.winningLine = winningLine
+	board.selected = nil
 }
 
-func (board *Board) printBoard() {
-	prevOccupied := FREE
-	occupied := FREE
-
-	for y := 0; y <= 29; y++ {
-		row := ""
-		for x := 1; x <= 27; x++ {
-			label := board.getAxisLabel(x, y)
-			row += label
-
-			if y == 0 && label != "" {
-				continue
-			}
-
-			coord := board.getCoord(x, y)
-
-			occupied = board.isTakenBy(coord)
+// stoneAt returns the color of the stone on a space followed by how it is marked, or FREE.
+// The cursor is drawn over whatever is beneath it.
+func (board *BoardView) stoneAt(coord gomoku.Coord) string {
+	if board.cursor != nil && *board.cursor == coord {
+		return cursorMark
+	}
 
-			if y%2 == 1 {
-				row += board.getRowChar(x, y, occupied, prevOccupied)
-			} else {
-				row += board.getColumnChar(x, y)
+	color := board.IsTakenBy(coord)
+	if color == gomoku.FREE {
+		for _, space := range board.selected {
+			if space == coord {
+				return selectedMark
 			}
-			prevOccupied = occupied
 		}
-
-		fmt.Println(row)
+		return color
 	}
-}
-
-func (board *Board) checkAlongAxis(color string, axis [2]int, move Coord, num int) int {
-	next := Coord{
-		X: move.X + axis[0],
-		Y: move.Y + axis[1],
-	}
-
-	for c := range board.Spaces[color] {
-		coordinates := strings.Split(c, " ")
-		x, _ := strconv.Atoi(coordinates[0])
-		y, _ := strconv.Atoi(coordinates[1])
 
-		if x == next.X && y == next.Y {
-			return board.checkAlongAxis(color, axis, next, num+1)
+	for _, stone := range board.winningLine {
+		if stone == coord {
+			return color + winningMark
 		}
 	}
 
-	return num
-}
-
-func (board *Board) checkForWin(move Coord, color string) bool {
-	// check within color from move coordinates
-	for _, axis := range axes {
-		len := board.checkAlongAxis(color, axis, move, 1)
-		complement := [2]int{axis[0] * -1, axis[1] * -1}
-		len = board.checkAlongAxis(color, complement, move, len)
-
-		if len == 5 {
-			return true
+	for _, stone := range board.lastMove {
+		if stone == coord {
+			return color + lastMoveMark
 		}
 	}
 
-	return false
+	return color
 }
 
-func (board *Board) isTakenBy(move Coord) string {
-	spotStr := move.String()
-
-	for color := range board.Spaces {
-		if board.Spaces[color][spotStr] {
-			return color
-		}
-	}
-
-	return FREE
+// centreCursor shows the cursor in the middle of the board
+func (board *BoardView) centreCursor() {
+	board.cursor = &gomoku.Coord{X: board.Size/2 + 1, Y: board.Size/2 + 1}
 }
 
-func (board *Board) getColorCode(color string) string {
-	if color == "white" {
-		return white
+// moveCursor moves the cursor by dx rows and dy columns, stopping at the edge of the board
+func (board *BoardView) moveCursor(dx int, dy int) {
+	if board.cursor == nil {
+		return
 	}
-	return black
-}
 
-func (board *Board) intersectionOrSpace(horizontal string, intersection string, occupied string, spaceFirst bool) string {
-	space := intersection
-	if occupied != FREE {
-		space = board.getColorCode(occupied)
+	moved := gomoku.Coord{X: board.cursor.X + dx, Y: board.cursor.Y + dy}
+	if board.IsOnBoard(moved) {
+		board.cursor = &moved
 	}
-
-	if spaceFirst {
-		return space + horizontal
-	}
-
-	return horizontal + space
 }
 
-func (board *Board) checkOwnership(gameID int, userID string, move Coord) (bool, Request) {
-	if board.isTakenBy(move) != FREE {
-		errorResponse := Request{
-			GameID:  gameID,
-			UserID:  userID,
-			Action:  MOVE,
-			Data:    "That spot is already taken!",
-			Success: false,
-		}
-
-		return false, errorResponse
+// toggleSelected adds a free space to the selection, or takes it out again
+func (board *BoardView) toggleSelected(coord gomoku.Coord) {
+	if board.IsTakenBy(coord) != gomoku.FREE {
+		return
 	}
 
-	return true, Request{}
-}
-
-func (board *Board) getRowChar(x int, y int, occupied string, prevOccupied string) string {
-	HORIZONTALS := [3]string{horizontal, horizontal2, horizontal3}
-
-	if prevOccupied != FREE {
-		HORIZONTALS[0] = horizontalAfterPiece
-		HORIZONTALS[1] = horizontal2AfterPiece
-		HORIZONTALS[2] = horizontal3AfterPiece
-	}
-
-	if y == 1 {
-		switch x {
-		case 1:
-			return board.intersectionOrSpace(HORIZONTALS[1], topLeft, occupied, true)
-		case 27:
-			return board.intersectionOrSpace(HORIZONTALS[2], topRight, occupied, false)
-		default:
-			if x%2 == 0 {
-				return board.intersectionOrSpace(HORIZONTALS[0], topIntersection, occupied, false)
-			}
-
-			return HORIZONTALS[1]
+	for i, space := range board.selected {
+		if space == coord {
+			board.selected = append(board.selected[:i:i], board.selected[i+1:]...)
+			return
 		}
 	}
-
-	if y == 29 {
-		switch x {
-		case 1:
-			return board.intersectionOrSpace(HORIZONTALS[1], bottomLeft, occupied, true)
-		case 27:
-			return board.intersectionOrSpace(HORIZONTALS[2], bottomRight, occupied, false)
-		default:
-			if x%2 == 0 {
-				return board.intersectionOrSpace(HORIZONTALS[0], bottomIntersection, occupied, false)
-			}
-
-			return HORIZONTALS[1]
-		}
-	}
-
-	switch x {
-	case 1:
-		return board.intersectionOrSpace(HORIZONTALS[1], leftIntersection, occupied, true)
-	case 27:
-		return board.intersectionOrSpace(HORIZONTALS[2], rightIntersection, occupied, false)
-	default:
-		if x%2 == 0 {
-			return board.intersectionOrSpace(HORIZONTALS[0], fullIntersection, occupied, false)
-		}
-
-		return HORIZONTALS[1]
-	}
+	board.selected = append(board.selected, coord)
 }
 
-func (board *Board) getColumnChar(x int, y int) string {
-	if x == 1 {
-		return vertical + space2
-	}
-
-	if x == 27 {
-		return space3 + vertical
-	}
-
-	if x%2 == 0 {
-		return space + vertical
-	}
+// lastColumn is the rightmost character column used to draw the grid
+func (board *BoardView) lastColumn() int {
+	return 2*board.Size - 3
+}
 
-	return space2
+// lastRow is the bottom character row used to draw the grid
+func (board *BoardView) lastRow() int {
+	return 2*board.Size - 1
 }
 
-func (board *Board) getCoord(x int, y int) Coord {
-	coord := Coord{
+func (board *BoardView) getCoord(x int, y int) gomoku.Coord {
+	coord := gomoku.Coord{
 		X: 0,
 		Y: 0,
 	}
 
-	if x == 27 {
-		coord.Y = 15
+	if x == board.lastColumn() {
+		coord.Y = board.Size
 	}
 
 	if x == 1 {
@@ -276,8 +150,8 @@ func (board *Board) getCoord(x int, y int) Coord {
 		coord.X = 1
 	}
 
-	if y == 29 {
-		coord.X = 15
+	if y == board.lastRow() {
+		coord.X = board.Size
 	}
 
 	if coord.X != 0 && coord.Y != 0 {
@@ -304,50 +178,3 @@ func (board *Board) getCoord(x int, y int) Coord {
 
 	return coord
 }
-
-func (board *Board) getAxisLabel(x int, y int) string {
-	// x axis
-	ret := ""
-	if y == 0 {
-		if x == 1 {
-			ret += space3
-		}
-
-		if x%2 == 1 {
-			char := strconv.Itoa(((x - 1) / 2) + 1)
-
-			ret += char + space
-
-			if x == 27 {
-				ret += " 15"
-				return ret
-			}
-		} else {
-			if x >= 20 {
-				ret += space
-				return ret
-			}
-
-			ret += space2
-		}
-		return ret
-	}
-
-	// y axis
-	if x == 1 {
-		if y%2 == 1 {
-			char := strconv.Itoa(((y - 1) / 2) + 1)
-
-			if y >= 19 {
-				ret += char + space
-			} else {
-				ret += char + space2
-			}
-		} else {
-			ret += space3
-		}
-		return ret
-	}
-
-	return ret
-}
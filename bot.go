@@ -0,0 +1,542 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// bot difficulty levels
+const (
+	EASY   = "easy"
+	MEDIUM = "medium"
+	HARD   = "hard"
+)
+
+const (
+	botEmpty int8 = 0
+	botBlack int8 = 1
+	botWhite int8 = 2
+
+	botWinScore = 1 << 30
+	// botWeightCap keeps a whole board of windows well below botWinScore, so long
+	// connect-n lines can't overflow or pass for a win
+	botWeightCap = botWinScore >> 13
+)
+
+var botColorNames = [3]string{"", "black", "white"}
+
+// isBotLevel checks whether name is a known difficulty level
+func isBotLevel(name string) bool {
+	switch name {
+	case EASY, MEDIUM, HARD:
+		return true
+	}
+	return false
+}
+
+// Bot is a computer player that fills a seat in a GameRoom
+type Bot struct {
+	Level      string
+	depth      int
+	width      int
+	noise      int
+	timeBudget time.Duration
+	random     *rand.Rand
+}
+
+// NewBot creates a bot that searches deeper and longer at higher levels
+func NewBot(level string) *Bot {
+	bot := Bot{
+		Level:  level,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	switch level {
+	case EASY:
+		bot.depth = 1
+		bot.width = 8
+		bot.noise = 40
+		bot.timeBudget = 200 * time.Millisecond
+	case HARD:
+		bot.depth = 5
+		bot.width = 12
+		bot.timeBudget = 3 * time.Second
+	default:
+		bot.depth = 3
+		bot.width = 10
+		bot.timeBudget = time.Second
+	}
+
+	return &bot
+}
+
+// botTurn is a snapshot of everything the bot needs to pick a move, so that the
+// search can run without holding the room's lock
+type botTurn struct {
+	bot       *Bot
+	grid      *botGrid
+	board     gomoku.Board
+	rules     gomoku.RuleSet
+	color     string
+	placement bool
+	choosing  bool
+}
+
+// prepareTurn snapshots the room for the bot playing as userID
+func (bot *Bot) prepareTurn(game *GameRoom, userID string) botTurn {
+	board := game.Board.Copy()
+	turn := botTurn{
+		bot:   bot,
+		grid:  newBotGrid(&board, game.Rules),
+		board: board,
+		rules: game.Rules,
+		color: game.Colors[userID],
+	}
+
+	if opening, ok := game.Opening.(*gomoku.SwapOpening); ok {
+		turn.placement = opening.Stage() == gomoku.SwapPlaceThree
+		turn.choosing = opening.Stage() == gomoku.SwapChooseColor
+	}
+
+	return turn
+}
+
+// choose returns the text the bot sends as its MOVE
+func (turn botTurn) choose() string {
+	if turn.placement {
+		return turn.placeFirstThree()
+	}
+
+	if turn.choosing {
+		// see how good white's best reply is; if it's bad for white, take black instead
+		move, score := turn.search(botWhite)
+		if score < 0 {
+			return "pass"
+		}
+		return move.String()
+	}
+
+	color := botBlack
+	if turn.color == "white" {
+		color = botWhite
+	}
+
+	move, _ := turn.search(color)
+	return move.String()
+}
+
+// placeFirstThree picks a reasonably balanced Swap opening near the centre
+func (turn botTurn) placeFirstThree() string {
+	center := turn.grid.size/2 + 1
+	offset := turn.bot.random.Intn(3) - 1
+
+	// the stones span three rows, which must all be on small boards
+	y := center + offset
+	if y+2 > turn.grid.size {
+		y = turn.grid.size - 2
+	}
+
+	black1 := gomoku.Coord{X: center, Y: y}
+	black2 := gomoku.Coord{X: center + 1, Y: y + 2}
+	white := gomoku.Coord{X: center + 1, Y: y + 1}
+	return black1.String() + ", " + black2.String() + ", " + white.String()
+}
+
+// fallback returns a move to send if the server rejects the one chosen: the first free
+// spots during the opening, or else taking black, or else the search's best move
+func (turn botTurn) fallback() string {
+	if turn.placement {
+		spots := []string{}
+		for x := 1; x <= turn.grid.size && len(spots) < 3; x++ {
+			for y := 1; y <= turn.grid.size && len(spots) < 3; y++ {
+				if turn.grid.at(x, y) == botEmpty {
+					spots = append(spots, gomoku.Coord{X: x, Y: y}.String())
+				}
+			}
+		}
+		return strings.Join(spots, ", ")
+	}
+
+	if turn.choosing {
+		return "pass"
+	}
+
+	color := botBlack
+	if turn.color == "white" {
+		color = botWhite
+	}
+	move, _ := turn.search(color)
+	return move.String()
+}
+
+// search runs an iterative-deepening alpha-beta search and returns the best move
+// with its score from color's point of view
+func (turn botTurn) search(color int8) (gomoku.Coord, int) {
+	search := botSearch{
+		grid:     turn.grid,
+		width:    turn.bot.width,
+		deadline: time.Now().Add(turn.bot.timeBudget),
+	}
+
+	candidates := turn.grid.candidates(color, 0)
+	allowed := []botMove{}
+	for _, candidate := range candidates {
+		colorName := "black"
+		if color == botWhite {
+			colorName = "white"
+		}
+		if turn.rules.ForbiddenReason(&turn.board, candidate.coord, colorName) != "" {
+			continue
+		}
+		if turn.bot.noise > 0 {
+			candidate.score += turn.bot.random.Intn(turn.bot.noise)
+		}
+		allowed = append(allowed, candidate)
+	}
+
+	if len(allowed) == 0 {
+		return turn.grid.firstEmpty(), 0
+	}
+
+	sort.SliceStable(allowed, func(i, j int) bool { return allowed[i].score > allowed[j].score })
+
+	// always take a win
+	if allowed[0].score > botWinScore {
+		return allowed[0].coord, botWinScore
+	}
+
+	if len(allowed) > turn.bot.width {
+		allowed = allowed[:turn.bot.width]
+	}
+
+	bestMove := allowed[0].coord
+	bestScore := -botWinScore * 2
+	for depth := 1; depth <= turn.bot.depth; depth++ {
+		depthMove, depthScore, complete := search.root(color, depth, allowed)
+		if !complete {
+			break
+		}
+		bestMove, bestScore = depthMove, depthScore
+		if depthScore >= botWinScore {
+			break
+		}
+	}
+
+	return bestMove, bestScore
+}
+
+// botMove is a candidate move with its ordering score
+type botMove struct {
+	coord gomoku.Coord
+	score int
+}
+
+// botSearch holds the state of a single negamax search
+type botSearch struct {
+	grid     *botGrid
+	width    int
+	deadline time.Time
+	aborted  bool
+}
+
+func (search *botSearch) root(color int8, depth int, moves []botMove) (gomoku.Coord, int, bool) {
+	alpha := -botWinScore * 2
+	beta := botWinScore * 2
+	best := moves[0].coord
+
+	for _, move := range moves {
+		score := search.score(move.coord, color, depth, alpha, beta)
+		if search.aborted {
+			return best, alpha, false
+		}
+		if score > alpha {
+			alpha = score
+			best = move.coord
+		}
+	}
+
+	return best, alpha, true
+}
+
+// score plays move for color and scores the result from color's point of view
+func (search *botSearch) score(move gomoku.Coord, color int8, depth int, alpha int, beta int) int {
+	search.grid.set(move, color)
+	defer search.grid.set(move, botEmpty)
+
+	if search.grid.isWin(move, color) {
+		// prefer quicker wins
+		return botWinScore + depth
+	}
+
+	return -search.negamax(otherBotColor(color), depth-1, -beta, -alpha)
+}
+
+func (search *botSearch) negamax(color int8, depth int, alpha int, beta int) int {
+	if time.Now().After(search.deadline) {
+		search.aborted = true
+		return 0
+	}
+
+	if depth == 0 {
+		return search.grid.evaluate(color)
+	}
+
+	moves := search.grid.candidates(color, search.width)
+	if len(moves) == 0 {
+		return 0
+	}
+
+	for _, move := range moves {
+		score := search.score(move.coord, color, depth, alpha, beta)
+		if search.aborted {
+			return 0
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return alpha
+}
+
+func otherBotColor(color int8) int8 {
+	if color == botBlack {
+		return botWhite
+	}
+	return botBlack
+}
+
+// botGrid is a compact copy of the board for searching
+type botGrid struct {
+	size      int
+	winLength int
+	cells     []int8
+	weights   []int
+	board     gomoku.Board
+	rules     gomoku.RuleSet
+}
+
+func newBotGrid(board *gomoku.Board, rules gomoku.RuleSet) *botGrid {
+	grid := botGrid{
+		size:      board.Size,
+		winLength: board.WinLength,
+		cells:     make([]int8, board.Size*board.Size),
+		weights:   make([]int, board.WinLength+1),
+		board:     gomoku.NewSizedBoard(board.Size, board.WinLength),
+		rules:     rules,
+	}
+
+	for i := 1; i < board.WinLength; i++ {
+		grid.weights[i] = 1
+		for j := 1; j < i; j++ {
+			grid.weights[i] *= 12
+			if grid.weights[i] >= botWeightCap {
+				grid.weights[i] = botWeightCap
+				break
+			}
+		}
+	}
+	grid.weights[board.WinLength] = botWinScore
+
+	values := map[string]int8{"black": botBlack, "white": botWhite}
+	for x := 1; x <= board.Size; x++ {
+		for y := 1; y <= board.Size; y++ {
+			coord := gomoku.Coord{X: x, Y: y}
+			if value, ok := values[board.IsTakenBy(coord)]; ok {
+				grid.set(coord, value)
+			}
+		}
+	}
+
+	return &grid
+}
+
+func (grid *botGrid) at(x int, y int) int8 {
+	if x < 1 || x > grid.size || y < 1 || y > grid.size {
+		return -1
+	}
+	return grid.cells[(x-1)*grid.size+y-1]
+}
+
+func (grid *botGrid) set(coord gomoku.Coord, value int8) {
+	grid.cells[(coord.X-1)*grid.size+coord.Y-1] = value
+	if value == botEmpty {
+		grid.board.Remove(coord)
+	} else {
+		grid.board.Place(coord, botColorNames[value])
+	}
+}
+
+func (grid *botGrid) firstEmpty() gomoku.Coord {
+	for x := 1; x <= grid.size; x++ {
+		for y := 1; y <= grid.size; y++ {
+			if grid.at(x, y) == botEmpty {
+				return gomoku.Coord{X: x, Y: y}
+			}
+		}
+	}
+	return gomoku.Coord{}
+}
+
+// isWin checks whether the stone at move completes a line that wins under the rules
+func (grid *botGrid) isWin(move gomoku.Coord, color int8) bool {
+	return grid.rules.WinningLine(&grid.board, move, botColorNames[color]) != nil
+}
+
+// completes checks whether color playing at move would win, leaving the grid unchanged
+func (grid *botGrid) completes(move gomoku.Coord, color int8) bool {
+	grid.set(move, color)
+	defer grid.set(move, botEmpty)
+	return grid.isWin(move, color)
+}
+
+// evaluate scores every window of winLength cells from the point of view of color, the
+// side to move
+func (grid *botGrid) evaluate(color int8) int {
+	score := 0
+	ownFours := 0
+	opponentFours := 0
+	for x := 1; x <= grid.size; x++ {
+		for y := 1; y <= grid.size; y++ {
+			for _, axis := range gomoku.Axes {
+				endX := x + axis[0]*(grid.winLength-1)
+				endY := y + axis[1]*(grid.winLength-1)
+				if grid.at(endX, endY) < 0 {
+					continue
+				}
+
+				own, opponent := grid.countWindow(x, y, axis, color)
+				if opponent == 0 {
+					score += grid.weights[own]
+					if own == grid.winLength-1 {
+						ownFours++
+					}
+				} else if own == 0 {
+					score -= grid.weights[opponent]
+					if opponent == grid.winLength-1 {
+						opponentFours++
+					}
+				}
+			}
+		}
+	}
+
+	// the side to move completes any four, and can only block one of the opponent's
+	if ownFours > 0 {
+		return botWinScore / 2
+	}
+	if opponentFours > 1 {
+		return -botWinScore / 2
+	}
+	return score
+}
+
+func (grid *botGrid) countWindow(x int, y int, axis [2]int, color int8) (int, int) {
+	own := 0
+	opponent := 0
+	for i := 0; i < grid.winLength; i++ {
+		switch grid.at(x+axis[0]*i, y+axis[1]*i) {
+		case botEmpty:
+		case color:
+			own++
+		default:
+			opponent++
+		}
+	}
+	return own, opponent
+}
+
+// candidates lists empty points near existing stones, best first. Winning moves and
+// moves that block an immediate loss score at least botWinScore; when either exists
+// only those moves are returned. A width of 0 returns every candidate.
+func (grid *botGrid) candidates(color int8, width int) []botMove {
+	moves := []botMove{}
+	wins := []botMove{}
+	blocks := []botMove{}
+	empty := true
+
+	for x := 1; x <= grid.size; x++ {
+		for y := 1; y <= grid.size; y++ {
+			if grid.at(x, y) != botEmpty {
+				empty = false
+				continue
+			}
+			if !grid.hasNeighbour(x, y) {
+				continue
+			}
+
+			attack, defense := grid.scoreCell(x, y, color)
+			move := botMove{coord: gomoku.Coord{X: x, Y: y}, score: attack + defense}
+			if attack >= botWinScore && grid.completes(move.coord, color) {
+				move.score = botWinScore + 1
+				wins = append(wins, move)
+			} else if defense >= botWinScore && grid.completes(move.coord, otherBotColor(color)) {
+				move.score = botWinScore
+				blocks = append(blocks, move)
+			}
+			moves = append(moves, move)
+		}
+	}
+
+	if empty {
+		center := grid.size/2 + 1
+		return []botMove{botMove{coord: gomoku.Coord{X: center, Y: center}}}
+	}
+
+	if len(wins) > 0 {
+		return wins
+	}
+	if len(blocks) > 0 {
+		return blocks
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].score > moves[j].score })
+	if width > 0 && len(moves) > width {
+		moves = moves[:width]
+	}
+	return moves
+}
+
+func (grid *botGrid) hasNeighbour(x int, y int) bool {
+	for dx := -2; dx <= 2; dx++ {
+		for dy := -2; dy <= 2; dy++ {
+			if value := grid.at(x+dx, y+dy); value == botBlack || value == botWhite {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scoreCell rates how much playing at x, y builds color's lines (attack) and breaks
+// the opponent's (defense)
+func (grid *botGrid) scoreCell(x int, y int, color int8) (int, int) {
+	attack := 0
+	defense := 0
+	for _, axis := range gomoku.Axes {
+		for offset := 0; offset < grid.winLength; offset++ {
+			startX := x - axis[0]*offset
+			startY := y - axis[1]*offset
+			endX := startX + axis[0]*(grid.winLength-1)
+			endY := startY + axis[1]*(grid.winLength-1)
+			if grid.at(startX, startY) < 0 || grid.at(endX, endY) < 0 {
+				continue
+			}
+
+			own, opponent := grid.countWindow(startX, startY, axis, color)
+			if opponent == 0 {
+				attack += grid.weights[own+1]
+			} else if own == 0 {
+				defense += grid.weights[opponent+1]
+			}
+		}
+	}
+	return attack, defense
+}
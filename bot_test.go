@@ -0,0 +1,154 @@
+package main
+
+import (
+	"testing"
+
+	"go_gomoku/gomoku"
+)
+
+// placeStones puts stones on the board without playing them as turns
+func placeStones(game *GameRoom, black []string, white []string) {
+	for _, space := range black {
+		coord, _ := gomoku.ParseCoord(space)
+		game.Board.Place(coord, "black")
+	}
+	for _, space := range white {
+		coord, _ := gomoku.ParseCoord(space)
+		game.Board.Place(coord, "white")
+	}
+}
+
+func TestBotTakesWin(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+	game.AssignColors("mock_user2")
+	game.Opening.SetStage(gomoku.SwapDone)
+	placeStones(game, []string{"8 4", "8 5", "8 6", "8 7"}, []string{"9 4", "9 5", "9 6", "1 1"})
+	data := game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2").choose()
+
+	if data != "8 3" && data != "8 8" {
+		t.Errorf("Expected bot to complete its five, got %s", data)
+	}
+}
+
+func TestBotBlocksFour(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+	game.AssignColors("mock_user2")
+	game.Opening.SetStage(gomoku.SwapDone)
+	placeStones(game, []string{"2 2", "3 3", "12 12"}, []string{"8 4", "8 5", "8 6", "8 7"})
+	game.Board.Place(gomoku.Coord{X: 8, Y: 3}, "black")
+	data := game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2").choose()
+
+	if data != "8 8" {
+		t.Errorf("Expected bot to block the four on 8 8, got %s", data)
+	}
+}
+
+func TestBotBlocksOpenThree(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+	game.AssignColors("mock_user2")
+	game.Opening.SetStage(gomoku.SwapDone)
+	placeStones(game, []string{"2 2", "3 14"}, []string{"8 5", "8 6", "8 7"})
+	data := game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2").choose()
+
+	blocks := map[string]bool{"8 3": true, "8 4": true, "8 8": true, "8 9": true}
+	if !blocks[data] {
+		t.Errorf("Expected bot to block the open three, got %s", data)
+	}
+}
+
+func TestBotSwapOpening(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+	game.FirstPlayerID = "mock_user2"
+
+	data := game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2").choose()
+	_, err := game.Opening.Play(game.Game, "mock_user2", data)
+	if err != nil {
+		t.Fatalf("Expected bot's opening '%s' to be valid, got error: %s", data, err)
+	}
+
+	game.Turn = 2
+	game.FirstPlayerID = "mock_user1"
+	data = game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2").choose()
+	_, err = game.Opening.Play(game.Game, "mock_user2", data)
+	if err != nil {
+		t.Fatalf("Expected bot's color choice '%s' to be valid, got error: %s", data, err)
+	}
+
+	if game.PlayerColors() == nil {
+		t.Error("Expected colors to be chosen after the bot's turn")
+	}
+}
+
+func TestBotAvoidsRenjuForbidden(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+	game.AssignColors("mock_user2")
+	game.Opening.SetStage(gomoku.SwapDone)
+	placeStones(game, []string{"7 8", "7 9", "8 7", "9 7"}, []string{"1 1", "1 3", "15 15", "15 13"})
+	game.Rules = gomoku.RenjuRules{}
+	game.Players["mock_user2"].Bot = NewBot(EASY)
+
+	for i := 0; i < 5; i++ {
+		data := game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2").choose()
+		if data == "7 7" {
+			t.Fatal("Expected bot not to play a double three under renju rules")
+		}
+	}
+}
+
+func TestBotSwapOpeningSmallBoard(t *testing.T) {
+	for _, size := range []int{5, 6} {
+		settings := DefaultGameSettings()
+		settings.Size = size
+		settings.WinLength = 4
+		settings.Bot = MEDIUM
+
+		for i := 0; i < 10; i++ {
+			game := newTestGame(settings)
+			game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+			game.FirstPlayerID = "mock_user2"
+
+			turn := game.Players["mock_user2"].Bot.prepareTurn(game, "mock_user2")
+			for _, data := range []string{turn.choose(), turn.fallback()} {
+				_, err := game.ParseMoves(data, 3)
+				if err != nil {
+					t.Fatalf("Expected bot's opening '%s' to be valid on a %dx%d board, got error: %s", data, size, size, err)
+				}
+			}
+		}
+	}
+}
+
+func TestBotWeightsLongLines(t *testing.T) {
+	board := gomoku.NewSizedBoard(gomoku.MaxBoardSize, gomoku.MaxBoardSize)
+	grid := newBotGrid(&board, gomoku.FreestyleRules{})
+
+	for i := 2; i < len(grid.weights)-1; i++ {
+		if grid.weights[i] < grid.weights[i-1] || grid.weights[i] > botWeightCap {
+			t.Fatalf("Expected weights to grow up to the cap, got %d after %d", grid.weights[i], grid.weights[i-1])
+		}
+	}
+}
+
+func TestBotCaroBlockedLine(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	game.Players["mock_user2"].Bot = NewBot(MEDIUM)
+	game.AssignColors("mock_user2")
+	game.Opening.SetStage(gomoku.SwapDone)
+	placeStones(game, []string{"8 4", "8 5", "8 6", "8 7"}, []string{"8 3", "8 9", "1 1"})
+	move := gomoku.Coord{X: 8, Y: 8}
+
+	game.Rules = gomoku.CaroRules{}
+	if newBotGrid(&game.Board, game.Rules).completes(move, botBlack) {
+		t.Error("Expected a five blocked at both ends not to win under caro rules")
+	}
+
+	game.Rules = gomoku.FreestyleRules{}
+	if !newBotGrid(&game.Board, game.Rules).completes(move, botBlack) {
+		t.Error("Expected a five to win under freestyle rules")
+	}
+}
@@ -2,19 +2,25 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"go_gomoku/gomoku"
 )
 
 // Client runs the CLI for players
 type Client struct {
+	// M is held while a response or a command is handled, since they arrive on different goroutines
+	M             	sync.Mutex
 	disablePrint  	bool
 	handledRequests chan Request
 	messages      	[]Message
@@ -28,28 +34,76 @@ type Client struct {
 	connection    	net.Conn
 	yourTurn      	bool
 	turn          	int
-	board         	Board
+	board         	BoardView
+	renderer      	*Renderer
+	settings      	GameSettings
+	// watching is set when the client is spectating rather than playing
+	watching      	bool
+	colors        	map[string]string
+	clocks        	map[string]gomoku.PlayerClock
+	result        	gomoku.Result
+	// sessionPath is where the session is saved, or empty to not save it
+	sessionPath   	string
+	session       	Session
+	// exportPath is where the game asked for with ex will be saved
+	exportPath    	string
+	// imported is the file shown on the board by im, if any
+	imported      	string
+	// local plays hot-seat games without a server, when set
+	local         	*LocalGame
+	// goingHome and resigning wait for the player to confirm with y
+	goingHome     	bool
+	resigning     	bool
+	// cursorMode moves a cursor over the board with the keyboard, instead of typing moves
+	cursorMode    	bool
+	// typing is set while a command is typed in cursor mode, and typed holds it so far
+	typing        	bool
+	typed         	string
+	// name is the name the player asked for, which the server has to accept
+	name          	string
+	// identityPath is where identities are saved, or empty to not save them
+	identityPath  	string
+	identity      	Identity
+	// names maps the players in the game who have registered names to those names
+	names         	map[string]string
 }
 
 // Interface defines methods a Client should implement
 type ClientInterface interface {
 	Run(string, string)
 	handler([]byte)
-	createGame()
+	createGame(string)
+	exportGame(string)
+	importGame(string)
 	listenForInput(io.Reader)
+	listenForKeys(io.Reader)
+	handleCommand(string)
+	handleKey(string)
+	printPrompt()
+	readInput(io.Reader)
+	UseCursor()
 	addMessage(string, string)
 	backToHome()
 	clearScreen()
-	getTurnOneInstructions() string
-	getTurnTwoInstructions() string
 	handleCreateRequest(Request)
+	handleDrawRequest(Request)
+	handleExportRequest(Request)
+	handleRequest(Request)
 	handleHomeRequest(Request)
 	handleJoinRequest(Request)
 	handleMessageRequest(Request)
 	handleMoveRequest(Request)
 	handleOtherJoinedRequest(Request)
+	handleOtherResumedRequest(Request)
+	handleRematchRequest(Request)
+	handleResignRequest(Request)
+	handleResumeRequest(Request)
+	handleTakebackRequest(Request)
+	handleRegisterRequest(Request)
+	handleWatchRequest(Request)
 	joinGame(string)
 	makeMove(string)
+	offerDraw()
 	printBoard()
 	printBoardAndMessages()
 	printError(err error)
@@ -57,18 +111,27 @@ type ClientInterface interface {
 	printMessages()
 	printString(message string)
 	printTurn()
+	requestRematch()
+	resign()
+	resumeGame()
+	RunLocal()
 	sendMessage(string)
 	sendToServer(Request)
+	takeBack(string)
+	register()
+	setName(string)
+	watchGame(string)
 }
 
 // assert that Board implements Interface
 var _ ClientInterface = (*Client)(nil)
 
 // New creates a client instance
-func NewClient(serverName string) Client {
-	client := Client{
+func NewClient(serverName string) *Client {
+	client := &Client{
 		serverName: serverName,
 		handledRequests: make(chan Request),
+		renderer: defaultRenderer(),
 	}
 	client.reset()
 	return client
@@ -76,24 +139,42 @@ func NewClient(serverName string) Client {
 
 func (client *Client) reset() {
 	client.GameID = -1
+	client.watching = false
 	client.gameOver = false
 	client.opponentID = ""
 	client.yourColor = ""
 	client.opponentColor = ""
 	client.messages = []Message{}
 	client.turn = 0
-	client.board = NewBoard()
+	client.colors = nil
+	client.names = nil
+	client.clocks = nil
+	client.result = gomoku.Result{}
+	client.imported = ""
+	client.useSettings(DefaultGameSettings())
+}
+
+// useSettings resets the board to match a room's settings
+func (client *Client) useSettings(settings GameSettings) {
+	if settings.Size == 0 {
+		settings = DefaultGameSettings()
+	}
+	client.settings = settings
+	client.board = NewBoardView(settings.Size, settings.WinLength)
+	if client.cursorMode {
+		client.board.centreCursor()
+	}
 }
 
 func (client *Client) clearScreen() {
 	if !client.disablePrint {
-		clearScreen()
+		client.renderer.Clear(os.Stdout)
 	}
 }
 
 func (client *Client) printBoard() {
 	if !client.disablePrint {
-		client.board.printBoard()
+		client.renderer.Board(os.Stdout, &client.board)
 	}
 }
 
@@ -112,22 +193,106 @@ func (client *Client) printError(err error) {
 func (client *Client) printTurn() {
 	var turnStr string
 
-	if client.turn == 0 {
+	if client.imported != "" {
+		turnStr = "Imported from " + client.imported
+	} else if client.turn == 0 {
 		if client.gameOver {
 			turnStr = "Game over!"
+			if client.result.Kind != "" {
+				turnStr += " " + client.describeResult()
+			}
 		} else {
 			turnStr = "Waiting for player to join..."
 		}
 	} else {
 		turnStr = "Turn #" + strconv.Itoa(client.turn)
-		if client.yourTurn {
+		if client.local != nil {
+			turnStr += ": " + client.playerName(client.local.game.PlayerToMove())
+		} else if client.watching {
+			turnStr += " (watching)"
+		} else if client.yourTurn {
 			turnStr += ": You"
 		} else {
 			turnStr += ": Opponent"
 		}
 	}
 
-	client.printString(turnStr)
+	client.printString(turnStr + client.clockLine())
+}
+
+// describeResult explains how the game ended
+func (client *Client) describeResult() string {
+	if client.result.Kind == gomoku.DRAWN {
+		return "It's a draw."
+	}
+
+	winner := client.playerName(client.result.WinnerID)
+	switch client.result.Kind {
+	case gomoku.RESIGNED:
+		return winner + " won by resignation."
+	case gomoku.TIMEDOUT:
+		return winner + " won on time."
+	case gomoku.FORFEITED:
+		return winner + " won by forfeit."
+	}
+	return winner + " won!"
+}
+
+// playerName names a player from this client's point of view
+func (client *Client) playerName(userID string) string {
+	if client.local != nil {
+		if color := client.colors[userID]; color != "" {
+			return userID + " (" + color + ")"
+		}
+		return userID
+	}
+	if client.watching {
+		if name := client.names[userID]; name != "" {
+			return colorName(client.colors[userID]) + " (" + name + ")"
+		}
+		return colorName(client.colors[userID])
+	}
+	if userID == client.opponentID {
+		return client.opponentName(userID)
+	}
+	return "You"
+}
+
+// opponentName is the name the opponent registered, or just Opponent
+func (client *Client) opponentName(userID string) string {
+	if name := client.names[userID]; name != "" {
+		return name
+	}
+	return "Opponent"
+}
+
+// clockLine shows the time both players have left, as of the last update from the server
+func (client *Client) clockLine() string {
+	control := client.settings.TimeControl
+	if control.Kind == "" || len(client.clocks) == 0 {
+		return ""
+	}
+
+	clocks := []string{}
+	if client.watching {
+		for _, color := range []string{"black", "white"} {
+			for id, playerColor := range client.colors {
+				if playerColor == color {
+					clocks = append(clocks, colorName(playerColor)+" "+gomoku.FormatClock(client.clocks[id], control))
+				}
+			}
+		}
+	} else {
+		clocks = append(clocks, "You "+gomoku.FormatClock(client.clocks[client.userID], control))
+		if clock, ok := client.clocks[client.opponentID]; ok {
+			clocks = append(clocks, "Opponent "+gomoku.FormatClock(clock, control))
+		}
+	}
+
+	if len(clocks) == 0 {
+		return ""
+	}
+	return " ----- " + strings.Join(clocks, " | ")
 }
 
 func (client *Client) printMessages() {
@@ -145,15 +310,45 @@ func (client *Client) printMessages() {
 func (client *Client) printHomeScreen(request Request) {
 	client.clearScreen()
 	client.printString("WELCOME TO GOMOKU!")
-	client.printString("Type 'mk' to make a new game")
+	if client.identity.Name != "" && client.local == nil {
+		client.printString("You're playing as " + client.identity.Name)
+	}
+	if client.cursorMode {
+		client.printString("In cursor mode, type ':' before each command (ex: ':mk')")
+	}
+	if client.local != nil {
+		client.printString("Two players take turns at this terminal, starting with Player 1.")
+		client.printString("Type 'mk' to start a game, optionally with a board size, win length and rule set and opening (ex: 'mk 19x19 connect-6 freestyle swap2')")
+		client.printString("Type 'im' followed by a .psq, .sgf or .txt file to look at a saved game")
+		return
+	}
+	client.printString("Type 'mk' to make a new game, optionally with a board size, win length and rule set and opening (ex: 'mk 19x19 connect-6 freestyle swap2')")
+	client.printString("Type 'mk bot' followed by easy, medium or hard to play against the computer (ex: 'mk bot hard')")
+	client.printString("Add a time control for a timed game: sudden death, Fischer increment or byo-yomi (ex: 'mk 5m', 'mk 5m+3s' or 'mk 10m/30sx3')")
+	client.printString("Add 'rated' for a game that counts towards your rating, once you've chosen a name (ex: 'mk rated 5m')")
+	client.printString("Type 'lb' to see the best rated players and your own rating")
+	client.printString("Type 'queue' to be matched with a player of a similar rating, optionally with the options for mk (ex: 'queue rated 5m+3s'), and 'queue cancel' to stop looking")
+	client.printString("Type 'tn' to list tournaments, 'tn mk roundrobin' or 'tn mk swiss' followed by the options for mk to host one, 'tn jn' followed by a tournament id to play in one, 'tn go' followed by its id to start the one you made, and 'tn st' followed by its id for the standings")
 	client.printString("Type 'hm' to refresh")
-	client.printString("Type 'jn' followed by a game id to join a game")
+	client.printString("Type 'nm' followed by a name to choose the name other players see")
+	client.printString("Add 'private' for a room that isn't listed here, 'password' followed by a password to protect it, or 'for' followed by a name to save the seat for one player (ex: 'mk private', 'mk password hunter2', 'mk for alice')")
+	client.printString("Type 'jn' followed by a game id to join a game, by an invite code to join a private room, or by a game id and its password")
+	client.printString("Type 'wt' followed by a game id to watch a game in progress")
+	client.printString("Type 'im' followed by a .psq, .sgf or .txt file to look at a saved game")
 	client.printString("_________")
 	if len(request.Home) == 0 {
 		client.printString("(no open games)")
 	} else {
 		for _, game := range request.Home {
-			client.printString("Game ID: " + strconv.Itoa(game.ID) + " ----- User: " + game.UserID)
+			client.printString("Game ID: " + strconv.Itoa(game.ID) + " ----- User: " + game.Name + " ----- " + game.Settings.String())
+		}
+	}
+	client.printString("_________")
+	if len(request.Live) == 0 {
+		client.printString("(no games in progress)")
+	} else {
+		for _, game := range request.Live {
+			client.printString("Game ID: " + strconv.Itoa(game.ID) + " ----- Users: " + game.Name + " vs " + game.OpponentName + " ----- " + game.Settings.String())
 		}
 	}
 }
@@ -163,9 +358,17 @@ func (client *Client) printBoardAndMessages() {
 	client.printTurn()
 	client.printBoard()
 	client.printMessages()
+	client.printPrompt()
 }
 
 func (client *Client) sendToServer(request Request) {
+	if client.local != nil {
+		for _, response := range client.local.handleRequest(request) {
+			client.handleRequest(response)
+		}
+		return
+	}
+
 	data, err := gobToBytes(request)
 
 	if err != nil {
@@ -173,7 +376,10 @@ func (client *Client) sendToServer(request Request) {
 		return
 	}
 
-	client.connection.Write(data) // TODO: get error, handle
+	err = writeFrame(client.connection, data)
+	if err != nil {
+		client.printError(err)
+	}
 }
 
 func (client *Client) addMessage(content string, author string) {
@@ -186,7 +392,7 @@ func (client *Client) addMessage(content string, author string) {
 	client.printBoardAndMessages()
 }
 
-func (client *Client) createGame() {
+func (client *Client) createGame(options string) {
 	if client.GameID != -1 {
 		client.printString("You're already in a game!")
 		return
@@ -195,20 +401,40 @@ func (client *Client) createGame() {
 	request := Request{
 		UserID: client.userID,
 		Action: CREATE,
+		Data:   options,
 	}
 
 	client.sendToServer(request)
 }
 
+// parseRoomTarget reads the room given to jn or wt: a game id, optionally followed by the
+// room's password, or an invite code, which is sent with a game id of -1
+func parseRoomTarget(text string) (int, string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return -1, "", errors.New("No game given")
+	}
+
+	gameID, err := strconv.Atoi(fields[0])
+	if err == nil {
+		return gameID, strings.Join(fields[1:], " "), nil
+	}
+
+	if len(fields) > 1 || len(fields[0]) != inviteCodeLength {
+		return -1, "", errors.New("Not a game id or an invite code: " + text)
+	}
+	return -1, fields[0], nil
+}
+
 func (client *Client) joinGame(gameIDStr string) {
 	if client.GameID != -1 {
 		client.addMessage("You're already in a game!!", client.serverName)
 		return
 	}
 
-	gameID, err := strconv.Atoi(gameIDStr)
+	gameID, secret, err := parseRoomTarget(gameIDStr)
 	if err != nil {
-		client.addMessage("Please enter a valid integer as the game id to join!", client.serverName)
+		client.addMessage("Please enter a valid integer as the game id to join, or an invite code!", client.serverName)
 		return
 	}
 
@@ -216,6 +442,7 @@ func (client *Client) joinGame(gameIDStr string) {
 		GameID: gameID,
 		UserID: client.userID,
 		Action: JOIN,
+		Data:   secret,
 	}
 
 	client.sendToServer(request)
@@ -225,6 +452,8 @@ func (client *Client) joinGame(gameIDStr string) {
 func (client *Client) makeMove(text string) {
 	if client.GameID == -1 {
 		client.addMessage("You're not in a game yet!", client.serverName)
+	} else if client.watching {
+		client.addMessage("You're only watching this game!", client.serverName)
 	} else if client.turn == 0 {
 		client.addMessage("The game hasn't started yet!", client.serverName)
 	} else {
@@ -239,9 +468,127 @@ func (client *Client) makeMove(text string) {
 	}
 }
 
+// gameAction sends an action for the game being played, checking that it has started
+func (client *Client) gameAction(action string, data string) {
+	if client.GameID == -1 {
+		client.addMessage("You're not in a game yet!", client.serverName)
+	} else if client.watching {
+		client.addMessage("You're only watching this game!", client.serverName)
+	} else if client.gameOver {
+		client.addMessage("The game is over!", client.serverName)
+	} else if client.turn == 0 {
+		client.addMessage("The game hasn't started yet!", client.serverName)
+	} else {
+		request := Request{
+			GameID: client.GameID,
+			UserID: client.userID,
+			Action: action,
+			Data:   data,
+		}
+
+		client.sendToServer(request)
+	}
+}
+
+func (client *Client) requestRematch() {
+	if client.GameID == -1 {
+		client.addMessage("You're not in a game yet!", client.serverName)
+	} else if client.watching {
+		client.addMessage("You're only watching this game!", client.serverName)
+	} else if !client.gameOver {
+		client.addMessage("The game isn't over yet!", client.serverName)
+	} else {
+		request := Request{
+			GameID: client.GameID,
+			UserID: client.userID,
+			Action: REMATCH,
+		}
+
+		client.sendToServer(request)
+	}
+}
+
+// takeBack asks to take back a move, or answers the opponent's request with "" or "no"
+func (client *Client) takeBack(answer string) {
+	client.gameAction(TAKEBACK, answer)
+}
+
+func (client *Client) resign() {
+	client.gameAction(RESIGN, "")
+}
+
+func (client *Client) offerDraw() {
+	client.gameAction(DRAW, "")
+}
+
+// exportGame asks the server for the current game in a notation, to be saved to a file.
+// The options are an optional format and file name, ex: "sgf mygame.sgf".
+func (client *Client) exportGame(options string) {
+	if client.GameID == -1 {
+		client.addMessage("You're not in a game yet!", client.serverName)
+		return
+	}
+
+	format := SGF
+	fields := strings.Fields(options)
+	if len(fields) > 0 {
+		format = strings.ToLower(fields[0])
+	}
+	if !isNotation(format) || len(fields) > 2 {
+		client.addMessage("The syntax for exporting is ex [psq|sgf|txt] [<file>]", client.serverName)
+		return
+	}
+
+	client.exportPath = "game-" + strconv.Itoa(client.GameID) + "." + format
+	if len(fields) == 2 {
+		client.exportPath = fields[1]
+	}
+
+	request := Request{
+		GameID: client.GameID,
+		UserID: client.userID,
+		Action: EXPORT,
+		Data:   format,
+	}
+
+	client.sendToServer(request)
+}
+
+// importGame shows the game saved in a psq, sgf or txt file on the board
+func (client *Client) importGame(path string) {
+	if client.GameID != -1 {
+		client.addMessage("You're already in a game!!", client.serverName)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		client.addMessage("Could not read "+path+": "+err.Error(), client.serverName)
+		return
+	}
+
+	record, err := parseRecord(string(data))
+	if err != nil {
+		client.addMessage("Could not import "+path+": "+err.Error(), client.serverName)
+		return
+	}
+
+	client.reset()
+	client.imported = path
+	client.settings.Size = record.Size
+	client.board = BoardView{Board: record.board(), lastMove: record.lastMove()}
+
+	client.addMessage("Imported "+strconv.Itoa(len(record.Moves))+" moves. Type hm to go back to the home screen.", client.serverName)
+	if record.Result != "" {
+		client.addMessage("Result: "+record.Result, client.serverName)
+	}
+}
+
 func (client *Client) sendMessage(text string) {
 	if client.GameID == -1 {
 		client.addMessage("You're not in a game yet!", client.serverName)
+	} else if client.watching {
+		client.addMessage("You're only watching this game!", client.serverName)
 	} else if client.turn == 0 {
 		client.addMessage("The game hasn't started yet!", client.serverName)
 	} else {
@@ -257,12 +604,26 @@ func (client *Client) sendMessage(text string) {
 	}
 }
 
-func (client *Client) getTurnOneInstructions() string {
-	return "You go first! Begin by placing two black pieces and then one white. Ex: 'mv 8 8, 8 7, 6 6'"
-}
+func (client *Client) watchGame(gameIDStr string) {
+	if client.GameID != -1 {
+		client.addMessage("You're already in a game!!", client.serverName)
+		return
+	}
+
+	gameID, secret, err := parseRoomTarget(gameIDStr)
+	if err != nil {
+		client.addMessage("Please enter a valid integer as the game id to watch, or an invite code!", client.serverName)
+		return
+	}
 
-func (client *Client) getTurnTwoInstructions() string {
-	return "If you want to play white, play a move as normal. Otherwise, type 'mv pass'."
+	request := Request{
+		GameID: gameID,
+		UserID: client.userID,
+		Action: WATCH,
+		Data:   secret,
+	}
+
+	client.sendToServer(request)
 }
 
 func (client *Client) handleCreateRequest(request Request) {
@@ -270,8 +631,15 @@ func (client *Client) handleCreateRequest(request Request) {
 		client.gameOver = false
 		client.GameID = request.GameID
 		client.yourTurn = true
+		client.useSettings(request.Settings)
+		client.rememberSession(request.Token)
 		gameIDStr := strconv.Itoa(request.GameID)
 		client.addMessage("Created game #"+gameIDStr, client.serverName)
+		if request.InviteCode != "" {
+			client.addMessage("This room is private. Invite your opponent to join with 'jn "+request.InviteCode+"'", client.serverName)
+		}
+	} else if request.Data != "" {
+		client.addMessage("Error! Could not create game: "+request.Data, client.serverName)
 	} else {
 		client.addMessage("Error! Could not create game.", client.serverName)
 	}
@@ -284,10 +652,16 @@ func (client *Client) handleJoinRequest(request Request) {
 		client.opponentID = request.UserID
 		client.turn = request.Turn
 		client.yourTurn = request.YourTurn
+		client.useSettings(request.Settings)
+		client.rememberSession(request.Token)
 		gameIDStr := strconv.Itoa(request.GameID)
 		client.addMessage("Joined game #"+gameIDStr, client.serverName)
-		if client.yourTurn {
-			client.addMessage(client.getTurnOneInstructions(), client.serverName)
+		client.addMessage("This game is played on "+client.settings.String(), client.serverName)
+		if rules := gomoku.RuleSetByName(client.settings.RuleSet); rules != nil {
+			client.addMessage(rules.Description(client.settings.WinLength), client.serverName)
+		}
+		if client.yourTurn && request.Instructions != "" {
+			client.addMessage(request.Instructions, client.serverName)
 		}
 	} else {
 		client.printString(request.Data)
@@ -299,16 +673,86 @@ func (client *Client) handleOtherJoinedRequest(request Request) {
 		client.turn = request.Turn
 		client.opponentID = request.UserID
 		client.yourTurn = request.YourTurn
-		client.addMessage("Let the game begin!", client.serverName)
-		if client.yourTurn {
-			client.addMessage(client.getTurnOneInstructions(), client.serverName)
+		client.addMessage(client.opponentName(request.UserID)+" joined. Let the game begin!", client.serverName)
+		if client.yourTurn && request.Instructions != "" {
+			client.addMessage(request.Instructions, client.serverName)
 		}
 	}
 }
 
+func (client *Client) handleOtherResumedRequest(request Request) {
+	if request.Success {
+		client.addMessage("Your opponent reconnected!", client.serverName)
+	}
+}
+
+func (client *Client) handleResumeRequest(request Request) {
+	if !request.Success {
+		client.forgetSession()
+		client.printString("Could not resume game #" + strconv.Itoa(request.GameID) + ": " + request.Data)
+		return
+	}
+
+	client.GameID = request.GameID
+	client.opponentID = request.UserID
+	client.turn = request.Turn
+	client.yourTurn = request.YourTurn
+	client.gameOver = request.GameOver
+	client.useSettings(request.Settings)
+	if request.Board != nil {
+		client.board.setPosition(request.Board, request.LastMove, request.WinningLine)
+	}
+
+	if color, ok := request.Colors[client.userID]; ok {
+		client.yourColor = color
+		client.opponentColor = gomoku.OpponentColor(color)
+	}
+
+	client.messages = []Message{}
+	for _, message := range request.Messages {
+		author := client.opponentName(message.Author)
+		if message.Author == client.userID {
+			author = "You"
+		}
+		client.messages = append(client.messages, Message{Content: message.Content, Author: author})
+	}
+
+	client.addMessage("Resumed game #"+strconv.Itoa(request.GameID), client.serverName)
+	if client.gameOver {
+		client.addMessage(client.gameOverHint(), client.serverName)
+	}
+	if client.yourTurn && request.Instructions != "" {
+		client.addMessage(request.Instructions, client.serverName)
+	}
+}
+
+func (client *Client) handleWatchRequest(request Request) {
+	if !request.Success {
+		client.printString(request.Data)
+		return
+	}
+
+	client.GameID = request.GameID
+	client.watching = true
+	client.turn = request.Turn
+	client.yourTurn = false
+	client.gameOver = request.GameOver
+	client.useSettings(request.Settings)
+	if request.Board != nil {
+		client.board.setPosition(request.Board, request.LastMove, request.WinningLine)
+	}
+
+	client.addMessage("Watching game #"+strconv.Itoa(request.GameID)+", played on "+client.settings.String(), client.serverName)
+	if client.gameOver {
+		client.addMessage(client.gameOverHint(), client.serverName)
+	}
+}
+
 func (client *Client) handleMessageRequest(request Request) {
 	if request.Success {
-		client.addMessage(request.Data, "Opponent")
+		client.addMessage(request.Data, client.opponentName(request.UserID))
+	} else if request.Data != "" {
+		client.addMessage(request.Data, client.serverName)
 	} else {
 		client.addMessage("Error! Could not parse message from opponent.", client.serverName)
 	}
@@ -319,10 +763,81 @@ func (client *Client) handleHomeRequest(request Request) {
 	client.printHomeScreen(request)
 }
 
+// handleRegisterRequest saves the name the server accepted, along with its credential
+func (client *Client) handleRegisterRequest(request Request) {
+	if !request.Success {
+		if client.name == "" && client.identity.Name == "" {
+			client.notice("Could not sign in: " + request.Data)
+			return
+		}
+		client.name = ""
+		client.notice("Could not choose that name: " + request.Data)
+		return
+	}
+
+	client.name = request.Data
+	client.identity = Identity{UserID: client.userID, Name: request.Data, Credential: request.Token}
+	client.saveIdentity()
+	if request.Data != "" {
+		client.notice("You're playing as " + request.Data)
+	}
+}
+
+// handleLeaderboardRequest shows the best rated players, followed by the player's own rating
+func (client *Client) handleLeaderboardRequest(request Request) {
+	if !request.Success {
+		client.notice(request.Data)
+		return
+	}
+
+	client.notice("LEADERBOARD")
+	if len(request.Leaderboard) == 0 {
+		client.notice("(no rated games yet)")
+	}
+	for _, entry := range request.Leaderboard {
+		client.notice(entry.String())
+	}
+
+	if request.YourRating.Rank == 0 {
+		client.notice("You haven't played a rated game yet")
+		return
+	}
+	client.notice("You: " + request.YourRating.String())
+}
+
+// handleTournamentRequest shows tournaments and standings, and clears the screen for the game
+// when a round starts
+func (client *Client) handleTournamentRequest(request Request) {
+	if request.Round > 0 {
+		// the round's game replaces whatever was on screen, and the notice stays above its board
+		client.reset()
+		client.messages = append(client.messages, Message{Content: request.Data, Author: client.serverName})
+		client.printString(request.Data)
+		return
+	}
+
+	client.notice(request.Data)
+	for _, tournament := range request.Tournaments {
+		client.notice("Tournament #" + strconv.Itoa(tournament.ID) + " ----- " + tournament.Format + " ----- " + strconv.Itoa(tournament.Players) + " players ----- " + tournament.Status + " ----- " + tournament.Settings.String())
+	}
+	for _, standing := range request.Standings {
+		client.notice(standing.String())
+	}
+}
+
+// notice shows a message from the client below the board in a game, or on the home screen
+func (client *Client) notice(message string) {
+	if client.GameID != -1 {
+		client.addMessage(message, client.serverName)
+		return
+	}
+	client.printString(message)
+}
+
 func (client *Client) handleMoveRequest(request Request) {
 	if request.Success {
-		if client.turn == 2 {
-			client.yourColor = request.Colors[client.userID]
+		if color, ok := request.Colors[client.userID]; ok {
+			client.yourColor = color
 			if client.yourColor == "white" {
 				client.opponentColor = "black"
 			} else {
@@ -331,32 +846,198 @@ func (client *Client) handleMoveRequest(request Request) {
 		}
 
 		client.turn = request.Turn
-		client.board.Spaces = request.Board
+		client.board.setPosition(request.Board, request.LastMove, request.WinningLine)
 
-		player := "You"
-		if request.UserID == client.opponentID {
-			player = "Opponent"
-		}
+		player := client.playerName(request.UserID)
 
 		client.gameOver = request.GameOver
+		client.result = request.Result
 		client.yourTurn = request.YourTurn
 
 		client.addMessage(request.Data, player)
 		if client.gameOver {
-			client.addMessage("Type hm to go back to the main screen!", client.serverName)
+			client.addMessage(client.gameOverHint(), client.serverName)
 		}
 
-		if client.yourTurn && client.turn == 2 {
-			client.addMessage(client.getTurnTwoInstructions(), client.serverName)
+		if client.yourTurn && request.Instructions != "" {
+			client.addMessage(request.Instructions, client.serverName)
 		}
 	} else {
 		client.addMessage(request.Data, client.serverName)
 	}
 }
 
+// colorName names a player by their color, for spectators
+func colorName(color string) string {
+	switch color {
+	case "black":
+		return "Black"
+	case "white":
+		return "White"
+	}
+	return "Player"
+}
+
+func (client *Client) handleResignRequest(request Request) {
+	if !request.Success {
+		client.addMessage(request.Data, client.serverName)
+		return
+	}
+
+	client.endGame(request)
+}
+
+func (client *Client) handleRematchRequest(request Request) {
+	if !request.Success {
+		client.addMessage(request.Data, client.serverName)
+		return
+	}
+
+	// the game is still over until both players have asked for a rematch
+	if request.GameOver {
+		client.addMessage(request.Data, client.playerName(request.UserID))
+		if !client.watching && request.UserID == client.opponentID {
+			client.addMessage("Type re to accept.", client.serverName)
+		}
+		return
+	}
+
+	client.gameOver = false
+	client.result = gomoku.Result{}
+	client.yourColor = ""
+	client.opponentColor = ""
+	client.colors = nil
+	client.turn = request.Turn
+	client.yourTurn = request.YourTurn
+	client.useSettings(request.Settings)
+
+	rematch := "Rematch!"
+	if !client.watching {
+		rematch += " Score: " + client.describeScore(request.Score)
+	}
+	client.addMessage(rematch, client.serverName)
+
+	if client.yourTurn && request.Instructions != "" {
+		client.addMessage(request.Instructions, client.serverName)
+	}
+}
+
+// describeScore shows the running score of a room, e.g. "You 2 - 1 Opponent, 1 draw"
+func (client *Client) describeScore(score MatchScore) string {
+	described := client.playerName(client.userID) + " " + strconv.Itoa(score.Wins[client.userID]) + " - " + strconv.Itoa(score.Wins[client.opponentID]) + " " + client.playerName(client.opponentID)
+	if score.Draws == 1 {
+		described += ", 1 draw"
+	} else if score.Draws > 1 {
+		described += ", " + strconv.Itoa(score.Draws) + " draws"
+	}
+	return described
+}
+
+// gameOverHint tells the player what they can do once the game is over
+func (client *Client) gameOverHint() string {
+	if client.watching {
+		return "Type hm to go back to the main screen!"
+	}
+	return "Type re to ask for a rematch, or hm to go back to the main screen!"
+}
+
+func (client *Client) handleTakebackRequest(request Request) {
+	if !request.Success {
+		client.addMessage(request.Data, client.serverName)
+		return
+	}
+
+	// requests and refusals don't change the board
+	if request.Board == nil {
+		client.addMessage(request.Data, client.playerName(request.UserID))
+		if !client.watching && request.UserID == client.opponentID && request.Data == "asked to take back their last move" {
+			client.addMessage("Type tb to accept, or tb no to decline.", client.serverName)
+		}
+		return
+	}
+
+	client.board.setPosition(request.Board, request.LastMove, request.WinningLine)
+	client.turn = request.Turn
+	client.yourTurn = request.YourTurn
+	client.colors = request.Colors
+	client.yourColor = ""
+	client.opponentColor = ""
+	if color, ok := request.Colors[client.userID]; ok {
+		client.yourColor = color
+		client.opponentColor = gomoku.OpponentColor(color)
+	}
+
+	client.addMessage(request.Data, client.playerName(request.UserID))
+	if client.yourTurn && request.Instructions != "" {
+		client.addMessage(request.Instructions, client.serverName)
+	}
+}
+
+// endGame shows a resignation or accepted draw that ended the game
+func (client *Client) endGame(request Request) {
+	client.turn = request.Turn
+	client.gameOver = true
+	client.result = request.Result
+	client.addMessage(request.Data, client.playerName(request.UserID))
+	client.addMessage(client.gameOverHint(), client.serverName)
+}
+
+func (client *Client) handleDrawRequest(request Request) {
+	if !request.Success {
+		client.addMessage(request.Data, client.serverName)
+		return
+	}
+
+	if request.GameOver {
+		client.endGame(request)
+		return
+	}
+
+	client.addMessage(request.Data, client.playerName(request.UserID))
+	if !client.watching && request.UserID == client.opponentID {
+		client.addMessage("Type dr to accept, or keep playing to decline.", client.serverName)
+	}
+}
+
+func (client *Client) handleExportRequest(request Request) {
+	if !request.Success {
+		client.addMessage(request.Data, client.serverName)
+		return
+	}
+
+	err := os.WriteFile(client.exportPath, []byte(request.Data), 0644)
+	if err != nil {
+		client.addMessage("Could not save the game: "+err.Error(), client.serverName)
+		return
+	}
+	client.addMessage("Saved the game to "+client.exportPath, client.serverName)
+}
+
 // handler handles requests
 func (client *Client) handler(message []byte) {
-	request := decodeGob(message)
+	request, err := decodeGob(message)
+	if err != nil {
+		client.printError(err)
+		return
+	}
+
+	client.M.Lock()
+	client.handleRequest(request)
+	client.M.Unlock()
+	go func() {client.handledRequests <- request}()
+}
+
+// handleRequest updates the client with a response from the server, or from the local game
+func (client *Client) handleRequest(request Request) {
+	if request.Colors != nil {
+		client.colors = request.Colors
+	}
+	if request.Clocks != nil {
+		client.clocks = request.Clocks
+	}
+	if request.Names != nil {
+		client.names = request.Names
+	}
 
 	switch action := request.Action; action {
 	case CREATE:
@@ -369,86 +1050,328 @@ func (client *Client) handler(message []byte) {
 		client.handleMessageRequest(request)
 	case HOME:
 		client.handleHomeRequest(request)
-	case MOVE:
+	case MOVE, TIMEOUT, FORFEIT:
 		client.handleMoveRequest(request)
+	case RESUME:
+		client.handleResumeRequest(request)
+	case OTHERRESUMED:
+		client.handleOtherResumedRequest(request)
+	case WATCH:
+		client.handleWatchRequest(request)
+	case RESIGN:
+		client.handleResignRequest(request)
+	case DRAW:
+		client.handleDrawRequest(request)
+	case REMATCH:
+		client.handleRematchRequest(request)
+	case TAKEBACK:
+		client.handleTakebackRequest(request)
+	case EXPORT:
+		client.handleExportRequest(request)
+	case REGISTER:
+		client.handleRegisterRequest(request)
+	case LEADERBOARD:
+		client.handleLeaderboardRequest(request)
+	case QUEUE:
+		client.notice(request.Data)
+	case TOURNAMENT:
+		client.handleTournamentRequest(request)
 	}
-	go func() {client.handledRequests <- request}()
+}
+
+// rememberSession saves what is needed to resume the current game
+func (client *Client) rememberSession(token string) {
+	client.session.UserID = client.userID
+	client.session.GameID = client.GameID
+	client.session.Token = token
+
+	if client.sessionPath == "" {
+		return
+	}
+
+	err := saveSession(client.sessionPath, client.session)
+	if err != nil {
+		client.printError(err)
+	}
+}
+
+// forgetSession stops the client from resuming its last game
+func (client *Client) forgetSession() {
+	client.session.Token = ""
+
+	if client.sessionPath == "" {
+		return
+	}
+
+	err := clearSession(client.sessionPath)
+	if err != nil {
+		client.printError(err)
+	}
+}
+
+// register signs in to the name the player asked for, or to the name they registered
+// before. Guests sign in without a name. Either way the server won't take requests from the
+// connection until it has.
+func (client *Client) register() {
+	name := client.name
+	if name == "" {
+		name = client.identity.Name
+	}
+
+	request := Request{
+		UserID: client.userID,
+		Action: REGISTER,
+		Data:   name,
+		Token:  client.identity.Credential,
+	}
+
+	client.sendToServer(request)
+}
+
+// queueForGame asks the server to find an opponent who wants a game with the same options,
+// or to stop looking if options is "cancel"
+func (client *Client) queueForGame(options string) {
+	if client.GameID != -1 {
+		client.addMessage("You're already in a game!", client.serverName)
+		return
+	}
+
+	request := Request{
+		UserID: client.userID,
+		Action: QUEUE,
+		Data:   options,
+	}
+
+	client.sendToServer(request)
+}
+
+// sendTournamentCommand passes a tn command on to the server
+func (client *Client) sendTournamentCommand(command string) {
+	request := Request{
+		UserID: client.userID,
+		Action: TOURNAMENT,
+		Data:   command,
+	}
+
+	client.sendToServer(request)
+}
+
+// showLeaderboard asks the server for the best rated players
+func (client *Client) showLeaderboard() {
+	request := Request{
+		UserID: client.userID,
+		Action: LEADERBOARD,
+	}
+
+	client.sendToServer(request)
+}
+
+// setName asks the server for a new name
+func (client *Client) setName(name string) {
+	client.name = name
+	client.register()
+}
+
+// saveIdentity remembers who the client is on this server, for the next time it connects
+func (client *Client) saveIdentity() {
+	if client.identityPath == "" {
+		return
+	}
+
+	err := saveIdentity(client.identityPath, identityKey(client.session.Host, client.session.Port), client.identity)
+	if err != nil {
+		client.printError(err)
+	}
+}
+
+// resumeGame asks the server to send the game the client was playing, if any
+func (client *Client) resumeGame() {
+	if client.session.Token == "" {
+		return
+	}
+
+	request := Request{
+		GameID: client.session.GameID,
+		UserID: client.userID,
+		Action: RESUME,
+		Token:  client.session.Token,
+	}
+
+	client.sendToServer(request)
 }
 
 func (client *Client) backToHome() {
+	client.forgetSession()
+
 	request := Request{
 		Action: HOME,
 	}
 
+	// say which game is being left, so that the server stops sending its moves to
+	// spectators and stops offering rematches to players
+	if client.GameID != -1 {
+		request.GameID = client.GameID
+	}
+
 	client.sendToServer(request)
 }
 
 func (client *Client) listenForInput(readstream io.Reader) {
-	goingHome := false
 	scanner := bufio.NewScanner(readstream)
 	for scanner.Scan() {
-		text := scanner.Text()
+		client.M.Lock()
+		client.handleCommand(scanner.Text())
+		client.M.Unlock()
 
-		if text == "y" && goingHome {
-			goingHome = false
-			client.backToHome()
-			continue
+		if scanner.Err() != nil {
+			fmt.Fprintf(os.Stderr, "Error reading console input!")
 		}
+	}
+}
 
-		// reset confirmation if user gives a different command
-		goingHome = false
+// handleCommand runs a single line typed by the player
+func (client *Client) handleCommand(text string) {
+	if text == "y" && client.goingHome {
+		client.goingHome = false
+		client.backToHome()
+		return
+	}
 
-		if len(text) < 2 {
-			continue
-		}
+	if text == "y" && client.resigning {
+		client.resigning = false
+		client.resign()
+		return
+	}
 
-		switch action := text[:2]; action {
-		case "hp":
-			client.addMessage("Type mk to make a game; jn <game_id> to join a game; mv <x> <y> to make a move; mg <message> to send a message; hp for help", client.serverName)
-		case "mk":
-			client.createGame()
-		case "jn":
-			if len(text) < 4 {
-				client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
-				continue
-			}
-			client.joinGame(text[3:])
-		case "mg":
-			if len(text) < 4 {
-				client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
-				continue
-			}
-			client.sendMessage(text[3:])
-		case "mv":
-			if len(text) < 4 {
-				client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
-				continue
-			}
-			client.makeMove(text[3:])
-		case "hm":
-			if client.gameOver || client.GameID == -1 {
-				client.backToHome()
-				continue
-			}
-			goingHome = true
-			client.addMessage("Are you sure you want to leave the game? Type y if you DEFINITELY want to go back to the home screen.", client.serverName)
-		default:
-			client.addMessage("Unrecognized command! Type 'hp' for help!", client.serverName)
-		}
+	// reset confirmation if user gives a different command
+	client.goingHome = false
+	client.resigning = false
 
-		if scanner.Err() != nil {
-			fmt.Fprintf(os.Stderr, "Error reading console input!")
+	if len(text) < 2 {
+		return
+	}
+
+	// joining, watching, chatting, names, ratings, the queue and tournaments need other people on a server
+	if client.local != nil && (text[:2] == "jn" || text[:2] == "wt" || text[:2] == "mg" || text[:2] == "nm" || text[:2] == "lb" || text[:2] == "qu" || text[:2] == "tn") {
+		client.addMessage("You need a server to do that!", client.serverName)
+		return
+	}
+
+	switch action := text[:2]; action {
+	case "hp":
+		client.addMessage("Type mk [<n>x<n>] [connect-<n>] [freestyle|standard|caro|renju] [swap|swap2|pro|longpro] [bot easy|medium|hard] [5m|5m+3s|10m/30sx3] [rated] to make a game; queue [<options>] to be matched with an opponent, or queue cancel to stop; tn [ls|mk <format> [<options>]|jn <id>|go <id>|st <id>] for tournaments; mk private|password <password>|for <name> to make a private room; jn <game_id> [<password>] or jn <invite_code> to join a game; wt <game_id> [<password>] or wt <invite_code> to watch a game; mv <x> <y> to make a move; rs to resign; dr to offer or accept a draw; tb to take back your last move; re to ask for a rematch; ex [psq|sgf|txt] [<file>] to save the game; im <file> to show a saved game; nm <name> to choose your name; lb to see the leaderboard; mg <message> to send a message; hp for help", client.serverName)
+	case "mk":
+		client.createGame(strings.TrimSpace(text[2:]))
+	case "jn":
+		if len(text) < 4 {
+			client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
+			return
+		}
+		client.joinGame(text[3:])
+	case "wt":
+		if len(text) < 4 {
+			client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
+			return
+		}
+		client.watchGame(text[3:])
+	case "mg":
+		if len(text) < 4 {
+			client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
+			return
+		}
+		client.sendMessage(text[3:])
+	case "mv":
+		if len(text) < 4 {
+			client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
+			return
+		}
+		client.makeMove(text[3:])
+	case "rs":
+		if client.gameOver || client.GameID == -1 || client.watching || client.turn == 0 {
+			client.resign()
+			return
+		}
+		client.resigning = true
+		client.addMessage("Are you sure you want to resign? Type y to resign.", client.serverName)
+	case "dr":
+		client.offerDraw()
+	case "re":
+		client.requestRematch()
+	case "tb":
+		client.takeBack(strings.TrimSpace(text[2:]))
+	case "ex":
+		client.exportGame(text[2:])
+	case "im":
+		if len(text) < 4 {
+			client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
+			return
 		}
+		client.importGame(strings.TrimSpace(text[3:]))
+	case "nm":
+		if len(text) < 4 {
+			client.addMessage("Invalid value! Type 'hp' for help!", client.serverName)
+			return
+		}
+		client.setName(strings.TrimSpace(text[3:]))
+	case "lb":
+		client.showLeaderboard()
+	case "tn":
+		client.sendTournamentCommand(strings.TrimSpace(text[2:]))
+	case "qu":
+		// both qu and queue, followed by the options for mk
+		fields := strings.Fields(text)
+		client.queueForGame(strings.Join(fields[1:], " "))
+	case "hm":
+		if client.gameOver || client.GameID == -1 || client.watching {
+			client.backToHome()
+			return
+		}
+		client.goingHome = true
+		client.addMessage("Are you sure you want to leave the game? Type y if you DEFINITELY want to go back to the home screen.", client.serverName)
+	default:
+		client.addMessage("Unrecognized command! Type 'hp' for help!", client.serverName)
 	}
 }
 
 func (client *Client) Connect(host string, port string) *SocketClient {
+	client.session.Host = host
+	client.session.Port = port
+
+	// a saved identity for this server keeps the same user, along with their name
+	if client.userID == "" && client.identityPath != "" {
+		identities, err := loadIdentities(client.identityPath)
+		if err != nil {
+			client.printError(err)
+		} else if identity, ok := identities[identityKey(host, port)]; ok {
+			client.identity = identity
+			client.userID = identity.UserID
+		}
+	}
+
+	// a saved session for this server lets the client come back as the same user
+	if client.userID == client.identity.UserID && client.sessionPath != "" {
+		session, err := loadSession(client.sessionPath)
+		if err != nil {
+			client.printError(err)
+		} else if session.Host == host && session.Port == port && session.Token != "" && (client.userID == "" || session.UserID == client.userID) {
+			client.session = session
+			client.userID = session.UserID
+		}
+	}
+
 	// create addresses
-	uuid, err := uuid.NewUUID()
-	if err != nil {
-		log.Fatal(err)
+	if client.userID == "" {
+		uuid, err := uuid.NewUUID()
+		if err != nil {
+			log.Fatal(err)
+		}
+		client.userID = uuid.String()
+	}
+
+	if client.identity.UserID == "" {
+		client.identity.UserID = client.userID
+		client.saveIdentity()
 	}
-	client.userID = uuid.String()
 
 	client.printString("Connecting to host on port " + port + "...")
 	conn, err := net.Dial("tcp", host+":"+port)
@@ -461,12 +1384,60 @@ func (client *Client) Connect(host string, port string) *SocketClient {
 	return socketClient
 }
 
+// reconnect dials the server again after the connection drops, waiting longer between attempts
+func (client *Client) reconnect(host string, port string) *SocketClient {
+	for delay := time.Second; ; delay *= 2 {
+		conn, err := net.Dial("tcp", host+":"+port)
+		if err == nil {
+			client.M.Lock()
+			client.connection = conn
+			client.M.Unlock()
+			return &SocketClient{Socket: conn}
+		}
+
+		if delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+		time.Sleep(delay)
+	}
+}
+
+// receive handles requests from the server, reconnecting and resuming the game whenever
+// the connection drops
+func (client *Client) receive(socketClient *SocketClient, host string, port string, connected *chan bool) {
+	for {
+		socketClient.Receive(client.handler, connected)
+
+		client.printString("Lost connection to the server! Reconnecting...")
+		socketClient = client.reconnect(host, port)
+
+		// resume once the home screen has arrived, so that it doesn't replace the game
+		reconnected := make(chan bool)
+		connected = &reconnected
+		go func() {
+			<-reconnected
+			client.M.Lock()
+			client.register()
+			client.resumeGame()
+			client.M.Unlock()
+		}()
+	}
+}
+
+// RunLocal plays hot-seat games at this terminal, without a server
+func (client *Client) RunLocal() {
+	client.local = &LocalGame{}
+	client.userID = localPlayer1
+	client.sendToServer(Request{Action: HOME})
+	client.readInput(os.Stdin)
+}
+
 // Run begins the CLI and connects to the server
 func (client *Client) Run(host string, port string) {
 	socketClient := client.Connect(host, port)
 	connected := make(chan bool)
 
-	go socketClient.Receive(client.handler, &connected)
+	go client.receive(socketClient, host, port, &connected)
 
 	select {
 	case <-connected:
@@ -475,5 +1446,22 @@ func (client *Client) Run(host string, port string) {
 		os.Exit(1)
 	}
 
-	client.listenForInput(os.Stdin)
+	client.register()
+	client.resumeGame()
+	client.readInput(os.Stdin)
+}
+
+// UseCursor switches to moving a cursor over the board with the keyboard
+func (client *Client) UseCursor() {
+	client.cursorMode = true
+	client.board.centreCursor()
+}
+
+// readInput follows the player's commands, as key presses in cursor mode or otherwise as lines
+func (client *Client) readInput(readstream io.Reader) {
+	if client.cursorMode {
+		client.listenForKeys(readstream)
+		return
+	}
+	client.listenForInput(readstream)
 }
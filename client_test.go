@@ -3,6 +3,8 @@ package main
 import (
 	"strconv"
 	"testing"
+
+	"go_gomoku/gomoku"
 )
 
 func TestClientHandleMessage(t *testing.T) {
@@ -152,3 +154,42 @@ func TestClientHandleCreateError(t *testing.T) {
 		t.Errorf("Expected message content to be %s, got: %s", expectedMessageContent, newClient.messages[0].Content)
 	}
 }
+
+func TestClientHandleMoveHighlights(t *testing.T) {
+	newClient := NewClient("GoGomoku")
+	newClient.disablePrint = true
+	newClient.userID = "mock_user1"
+
+	request := Request{
+		Success:  true,
+		GameID:   3,
+		UserID:   "mock_user2",
+		Action:   MOVE,
+		Turn:     4,
+		YourTurn: true,
+		Board:    map[string]map[string]bool{"black": {"8 8": true}, "white": {"9 9": true}},
+		LastMove: []gomoku.Coord{gomoku.Coord{X: 9, Y: 9}},
+	}
+	newClient.handleRequest(request)
+
+	if newClient.board.stoneAt(gomoku.Coord{X: 9, Y: 9}) != "white"+lastMoveMark || newClient.board.stoneAt(gomoku.Coord{X: 8, Y: 8}) != "black" {
+		t.Error("Expected only the last move to be marked")
+	}
+
+	request.UserID = "mock_user1"
+	request.Board["black"] = map[string]bool{"8 8": true, "8 9": true, "8 10": true}
+	request.LastMove = []gomoku.Coord{gomoku.Coord{X: 8, Y: 10}}
+	request.WinningLine = []gomoku.Coord{gomoku.Coord{X: 8, Y: 8}, gomoku.Coord{X: 8, Y: 9}, gomoku.Coord{X: 8, Y: 10}}
+	request.GameOver = true
+	newClient.handleRequest(request)
+
+	for _, coord := range request.WinningLine {
+		if newClient.board.stoneAt(coord) != "black"+winningMark {
+			t.Errorf("Expected %s to be marked as part of the winning line", coord)
+		}
+	}
+
+	if newClient.board.stoneAt(gomoku.Coord{X: 9, Y: 9}) != "white" {
+		t.Error("Expected the earlier move not to be marked")
+	}
+}
@@ -2,12 +2,26 @@ package main
 
 // constants
 const (
-	HOME        = "HOME"
-	FREE        = "FREE"
-	MOVE        = "MOVE"
-	JOIN        = "JOIN"
-	OTHERJOINED = "OTHERJOINED"
-	CREATE      = "CREATE"
-	MESSAGE     = "MESSAGE"
-	SUCCESS     = "SUCCESS"
+	HOME         = "HOME"
+	FREE         = "FREE"
+	MOVE         = "MOVE"
+	JOIN         = "JOIN"
+	OTHERJOINED  = "OTHERJOINED"
+	CREATE       = "CREATE"
+	MESSAGE      = "MESSAGE"
+	SUCCESS      = "SUCCESS"
+	RESUME       = "RESUME"
+	OTHERRESUMED = "OTHERRESUMED"
+	WATCH        = "WATCH"
+	TIMEOUT      = "TIMEOUT"
+	RESIGN       = "RESIGN"
+	DRAW         = "DRAW"
+	REMATCH      = "REMATCH"
+	TAKEBACK     = "TAKEBACK"
+	EXPORT       = "EXPORT"
+	REGISTER     = "REGISTER"
+	LEADERBOARD  = "LEADERBOARD"
+	QUEUE        = "QUEUE"
+	TOURNAMENT   = "TOURNAMENT"
+	FORFEIT      = "FORFEIT"
 )
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"go_gomoku/gomoku"
+)
+
+// keys read in cursor mode that aren't a single character
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keyBackspace = "backspace"
+	keyEscape    = "escape"
+)
+
+// ctrlD ends input in cursor mode, since the terminal no longer turns it into end of file
+const ctrlD = 4
+
+// enableKeyInput switches the terminal to pass on each key as it's pressed, without echoing
+// it, and returns a function that switches it back
+func enableKeyInput() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, errors.New("Cursor mode needs a terminal that supports stty: " + err.Error())
+	}
+
+	_, err = stty("-icanon", "-echo", "min", "1")
+	if err != nil {
+		return nil, errors.New("Couldn't switch the terminal to cursor mode: " + err.Error())
+	}
+
+	return func() {
+		stty(strings.TrimSpace(saved))
+	}, nil
+}
+
+// stty runs stty against the terminal the client was started from
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	return string(output), err
+}
+
+// readKey reads a single key press, turning the escape sequences for the arrow keys into
+// keyUp, keyDown, keyLeft and keyRight
+func readKey(reader *bufio.Reader) (string, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch b {
+	case ctrlD:
+		return "", io.EOF
+	case '\r', '\n':
+		return keyEnter, nil
+	case '\b', 127:
+		return keyBackspace, nil
+	case 27:
+		next, err := reader.ReadByte()
+		if err != nil {
+			return keyEscape, nil
+		}
+		if next != '[' && next != 'O' {
+			reader.UnreadByte()
+			return keyEscape, nil
+		}
+
+		final, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch final {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			return keyRight, nil
+		case 'D':
+			return keyLeft, nil
+		}
+		return keyEscape, nil
+	}
+
+	if b >= utf8.RuneSelf {
+		reader.UnreadByte()
+		r, _, err := reader.ReadRune()
+		return string(r), err
+	}
+
+	return string(b), nil
+}
+
+// listenForKeys follows key presses in cursor mode until the input ends
+func (client *Client) listenForKeys(readstream io.Reader) {
+	reader := bufio.NewReader(readstream)
+	for {
+		key, err := readKey(reader)
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "Error reading console input!")
+			}
+			return
+		}
+
+		client.M.Lock()
+		client.handleKey(key)
+		client.M.Unlock()
+	}
+}
+
+// handleKey moves the cursor, selects and plays stones, or adds to the command being typed.
+// Typing : starts a command, and during a game m starts a message to the opponent.
+func (client *Client) handleKey(key string) {
+	if client.typing {
+		client.typeKey(key)
+		return
+	}
+
+	switch key {
+	case keyUp, "k":
+		client.moveCursor(-1, 0)
+	case keyDown, "j":
+		client.moveCursor(1, 0)
+	case keyLeft, "h":
+		client.moveCursor(0, -1)
+	case keyRight, "l":
+		client.moveCursor(0, 1)
+	case " ":
+		if client.board.cursor != nil && client.GameID != -1 {
+			client.board.toggleSelected(*client.board.cursor)
+			client.printBoardAndMessages()
+		}
+	case keyEnter:
+		client.playAtCursor()
+	case ":":
+		client.startTyping("")
+	case "m":
+		// there's nobody to message on the home screen
+		if client.GameID != -1 {
+			client.startTyping("mg ")
+		}
+	case "y":
+		if client.goingHome || client.resigning {
+			client.handleCommand("y")
+		}
+	}
+}
+
+func (client *Client) moveCursor(dx int, dy int) {
+	if client.GameID == -1 && client.imported == "" {
+		return
+	}
+
+	client.board.moveCursor(dx, dy)
+	client.printBoardAndMessages()
+}
+
+// playAtCursor plays the selected stones, or a single stone under the cursor if none are
+// selected
+func (client *Client) playAtCursor() {
+	if client.board.cursor == nil {
+		return
+	}
+
+	moves := client.board.selected
+	if len(moves) == 0 {
+		moves = []gomoku.Coord{*client.board.cursor}
+	}
+
+	spaces := []string{}
+	for _, move := range moves {
+		spaces = append(spaces, move.String())
+	}
+	client.makeMove(strings.Join(spaces, ", "))
+}
+
+// startTyping starts a command, shown below the board as it's typed
+func (client *Client) startTyping(prefix string) {
+	client.typing = true
+	client.typed = prefix
+	client.echo(":" + prefix)
+}
+
+// typeKey adds a key to the command being typed, runs it on enter, and drops it on escape
+// or when it's deleted entirely
+func (client *Client) typeKey(key string) {
+	switch key {
+	case keyEnter:
+		text := client.typed
+		client.typing = false
+		client.typed = ""
+		client.echo("\n")
+		client.handleCommand(text)
+	case keyEscape:
+		client.stopTyping()
+	case keyBackspace:
+		if client.typed == "" {
+			client.stopTyping()
+			return
+		}
+		_, size := utf8.DecodeLastRuneInString(client.typed)
+		client.typed = client.typed[:len(client.typed)-size]
+		client.echo("\b \b")
+	case keyUp, keyDown, keyLeft, keyRight:
+	default:
+		client.typed += key
+		client.echo(key)
+	}
+}
+
+func (client *Client) stopTyping() {
+	client.typing = false
+	client.typed = ""
+	client.echo("\n")
+	if client.GameID != -1 {
+		client.printBoardAndMessages()
+	}
+}
+
+// echo shows what's typed in cursor mode, since the terminal no longer does
+func (client *Client) echo(text string) {
+	if !client.disablePrint {
+		fmt.Print(text)
+	}
+}
+
+// printPrompt explains the keys below the board in cursor mode, or shows the command being
+// typed
+func (client *Client) printPrompt() {
+	if !client.cursorMode {
+		return
+	}
+
+	if client.typing {
+		client.echo(":" + client.typed)
+		return
+	}
+
+	if client.board.cursor != nil {
+		client.printString("Cursor on " + client.board.cursor.String() + " ----- arrows or hjkl to move, enter to play, space to select a stone for a move of several, : for a command, m for a message")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"go_gomoku/gomoku"
+)
+
+func TestReadKey(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("\x1b[A\x1bOB\x1b[C\x1b[D k\r\x7fé\x1bx\x04"))
+	expected := []string{keyUp, keyDown, keyRight, keyLeft, " ", "k", keyEnter, keyBackspace, "é", keyEscape, "x"}
+
+	for _, want := range expected {
+		key, err := readKey(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != want {
+			t.Errorf("Expected key %q, got %q", want, key)
+		}
+	}
+
+	if _, err := readKey(reader); err == nil {
+		t.Error("Expected ctrl-D to end the input")
+	}
+}
+
+func TestBoardViewCursor(t *testing.T) {
+	board := NewBoardView(5, 4)
+	board.centreCursor()
+
+	for i := 0; i < 4; i++ {
+		board.moveCursor(-1, 1)
+	}
+	if *board.cursor != (gomoku.Coord{X: 1, Y: 5}) {
+		t.Errorf("Expected the cursor to stop at the edge of the board, got %v", *board.cursor)
+	}
+
+	if board.stoneAt(gomoku.Coord{X: 1, Y: 5}) != cursorMark {
+		t.Error("Expected the cursor to be drawn")
+	}
+
+	board.toggleSelected(gomoku.Coord{X: 2, Y: 2})
+	board.toggleSelected(gomoku.Coord{X: 3, Y: 3})
+	board.toggleSelected(gomoku.Coord{X: 2, Y: 2})
+	if len(board.selected) != 1 || board.stoneAt(gomoku.Coord{X: 3, Y: 3}) != selectedMark {
+		t.Errorf("Expected only 3 3 to be selected, got %v", board.selected)
+	}
+
+	board.setPosition(map[string]map[string]bool{"black": {"3 3": true}}, nil, nil)
+	if len(board.selected) != 0 {
+		t.Error("Expected a new position to clear the selection")
+	}
+}
+
+func TestLocalGameWithCursor(t *testing.T) {
+	client := newLocalTestClient()
+	client.UseCursor()
+
+	keys := strings.Join([]string{
+		":mk 9x9\n",
+		// select 5 5 and 5 6 for black and 1 1 for white
+		" l ",
+		"\x1b[A\x1b[A\x1b[A\x1b[A",
+		"hhhhh ",
+		"\r",
+		// white plays on 2 2
+		"jl\r",
+	}, "")
+	client.listenForKeys(strings.NewReader(keys))
+
+	expected := map[string]string{"5 5": "black", "5 6": "black", "1 1": "white", "2 2": "white"}
+	for space, color := range expected {
+		coord, _ := gomoku.ParseCoord(space)
+		if client.board.IsTakenBy(coord) != color {
+			t.Errorf("Expected %s on %s", color, space)
+		}
+	}
+
+	if client.turn != 3 || len(client.board.selected) != 0 {
+		t.Errorf("Expected turn 3 with nothing selected, got turn %d and %v", client.turn, client.board.selected)
+	}
+
+	// typing a command, with a typo taken back
+	client.listenForKeys(strings.NewReader(":tbb\x7f\r"))
+	if client.turn != 2 || client.board.IsTakenBy(gomoku.Coord{X: 2, Y: 2}) != gomoku.FREE {
+		t.Errorf("Expected the takeback to go back to turn 2, got turn %d", client.turn)
+	}
+
+	client.listenForKeys(strings.NewReader("mhello\r"))
+	if client.typing || client.messages[len(client.messages)-1].Content != "You need a server to do that!" {
+		t.Errorf("Expected the message to be sent as mg, got %v", client.messages)
+	}
+}
+
+func TestCursorHomeScreen(t *testing.T) {
+	client := newLocalTestClient()
+	client.UseCursor()
+
+	// without a game there's nobody to message, so m doesn't start typing 'mg'
+	client.listenForKeys(strings.NewReader("mk\r"))
+	if client.typing || client.GameID != -1 {
+		t.Errorf("Expected keys without ':' to do nothing on the home screen, got game %d (typing: %t)", client.GameID, client.typing)
+	}
+
+	client.listenForKeys(strings.NewReader(":mk\r"))
+	if client.GameID == -1 {
+		t.Error("Expected ':mk' to make a game")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// A room plays its games with the gomoku package's engine, and adds what only matters
+// between connected players: offers, rematches and the running score.
+
+// newGameRoom creates a room with no players, waiting for its game to start
+func newGameRoom(id int, settings GameSettings) *GameRoom {
+	return &GameRoom{
+		Game:     gomoku.NewGame(settings.engineSettings()),
+		ID:       id,
+		Players:  make(map[string]*Player),
+		Settings: settings,
+	}
+}
+
+// addPlayer seats a player in the room and in its game
+func (game *GameRoom) addPlayer(player *Player) {
+	game.Players[player.UserID] = player
+	game.AddPlayer(player.UserID)
+}
+
+// playTurn applies a player's turn, played at now, and records it in the game's history.
+// It returns a message describing the turn, or an error explaining why the turn isn't allowed.
+func (game *GameRoom) playTurn(userID string, data string, now time.Time) (string, error) {
+	message, err := game.PlayTurn(userID, data, now)
+	if err != nil {
+		return "", err
+	}
+
+	// any turn leaves an earlier takeback request behind
+	game.TakebackRequestedBy = ""
+
+	if game.IsOver {
+		game.finish()
+		return message, nil
+	}
+
+	// playing on declines the opponent's draw offer
+	if game.DrawOfferedBy == GetOpponentID(game, userID) {
+		game.DrawOfferedBy = ""
+	}
+
+	return message, nil
+}
+
+// begin starts the game, randomly choosing whether userID or their opponent goes first
+func (game *GameRoom) begin(userID string) {
+	firstPlayerID := userID
+	if rand.Intn(2) == 0 {
+		firstPlayerID = GetOpponentID(game, userID)
+	}
+	game.Start(firstPlayerID, time.Now())
+}
+
+// GetOpponentID returns the other player's id
+func GetOpponentID(game *GameRoom, userID string) string {
+	return game.Opponent(userID)
+}
+
+// IsTurn turns if it's a user's turn or not
+func IsTurn(game *GameRoom, userID string) bool {
+	return game.Game.IsTurn(userID)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+)
+
+const (
+	// frameHeaderSize is the length of the big-endian size prefix on every frame
+	frameHeaderSize = 4
+	// maxFrameSize is the largest payload either side will accept
+	maxFrameSize = 1 << 20
+)
+
+// errEmptyFrame is returned when a peer announces a zero-length frame
+var errEmptyFrame = errors.New("received an empty frame")
+
+// FrameSizeError is returned when a frame is larger than maxFrameSize
+type FrameSizeError struct {
+	Size uint32
+}
+
+func (err FrameSizeError) Error() string {
+	return "frame of " + strconv.FormatUint(uint64(err.Size), 10) + " bytes exceeds the maximum of " + strconv.Itoa(maxFrameSize) + " bytes"
+}
+
+// writeFrame writes data to w, prefixed with its length
+func writeFrame(w io.Writer, data []byte) error {
+	if len(data) == 0 {
+		return errEmptyFrame
+	}
+	if len(data) > maxFrameSize {
+		return FrameSizeError{Size: uint32(len(data))}
+	}
+
+	frame := make([]byte, frameHeaderSize+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[frameHeaderSize:], data)
+
+	_, err := w.Write(frame)
+	return err
+}
+
+// readFrame reads a single length-prefixed frame from r
+func readFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header)
+	if size == 0 {
+		return nil, errEmptyFrame
+	}
+	if size > maxFrameSize {
+		return nil, FrameSizeError{Size: size}
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return data, nil
+}
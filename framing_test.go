@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestFramingRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	payload := []byte("hello gomoku")
+
+	err := writeFrame(&buf, payload)
+	if err != nil {
+		t.Fatalf("Got error while writing frame: %s", err)
+	}
+
+	data, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("Got error while reading frame: %s", err)
+	}
+
+	if !bytes.Equal(data, payload) {
+		t.Errorf("Expected payload to be %s, got %s", payload, data)
+	}
+}
+
+func TestFramingCoalescedRequests(t *testing.T) {
+	var buf bytes.Buffer
+	requests := []Request{
+		Request{Action: MESSAGE, Data: "first", Success: true},
+		Request{Action: MESSAGE, Data: "second", Success: true},
+	}
+
+	// both frames arrive in a single read
+	for _, request := range requests {
+		data, err := gobToBytes(request)
+		if err != nil {
+			t.Fatalf("Got error while encoding gob: %s", err)
+		}
+		writeFrame(&buf, data)
+	}
+
+	for i, expected := range requests {
+		data, err := readFrame(&buf)
+		if err != nil {
+			t.Fatalf("Got error while reading frame %d: %s", i, err)
+		}
+		request, err := decodeGob(data)
+		if err != nil {
+			t.Fatalf("Got error while decoding frame %d: %s", i, err)
+		}
+		if request.Data != expected.Data {
+			t.Errorf("Expected frame %d to contain %s, got %s", i, expected.Data, request.Data)
+		}
+	}
+
+	if _, err := readFrame(&buf); err != io.EOF {
+		t.Errorf("Expected EOF after last frame, got %v", err)
+	}
+}
+
+func TestFramingLargeRequest(t *testing.T) {
+	var buf bytes.Buffer
+	request := Request{
+		Action: MOVE,
+		Board:  map[string]map[string]bool{"black": {}, "white": {}},
+	}
+	for i := 0; i < 1000; i++ {
+		request.Home = append(request.Home, OpenRoom{ID: i, UserID: "user-" + strconv.Itoa(i)})
+	}
+
+	data, err := gobToBytes(request)
+	if err != nil {
+		t.Fatalf("Got error while encoding gob: %s", err)
+	}
+	if len(data) <= 4096 {
+		t.Fatalf("Expected request to be larger than 4096 bytes, got %d", len(data))
+	}
+
+	writeFrame(&buf, data)
+	frame, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("Got error while reading frame: %s", err)
+	}
+
+	decoded, err := decodeGob(frame)
+	if err != nil {
+		t.Fatalf("Got error while decoding frame: %s", err)
+	}
+	if len(decoded.Home) != len(request.Home) {
+		t.Errorf("Expected %d open rooms, got %d", len(request.Home), len(decoded.Home))
+	}
+}
+
+func TestFramingTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	header := make([]byte, frameHeaderSize)
+	binary.BigEndian.PutUint32(header, maxFrameSize+1)
+	buf.Write(header)
+
+	_, err := readFrame(&buf)
+	if _, ok := err.(FrameSizeError); !ok {
+		t.Errorf("Expected FrameSizeError, got %v", err)
+	}
+
+	err = writeFrame(&buf, make([]byte, maxFrameSize+1))
+	if _, ok := err.(FrameSizeError); !ok {
+		t.Errorf("Expected FrameSizeError when writing, got %v", err)
+	}
+}
+
+func TestFramingGarbage(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrame(&buf, []byte("definitely not a gob"))
+
+	data, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("Got error while reading frame: %s", err)
+	}
+
+	_, err = decodeGob(data)
+	if err == nil {
+		t.Error("Expected error while decoding garbage")
+	}
+}
+
+func TestFramingTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	writeFrame(&buf, []byte("a complete frame"))
+	truncated := bytes.NewBuffer(buf.Bytes()[:buf.Len()-3])
+
+	_, err := readFrame(truncated)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected unexpected EOF, got %v", err)
+	}
+}
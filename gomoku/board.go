@@ -0,0 +1,232 @@
+package gomoku
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FREE is what IsTakenBy reports for an empty space
+const FREE = "FREE"
+
+// Axes are the four directions a line can run in: two diagonals, vertical and horizontal
+var Axes = [4][2]int{[2]int{-1, -1}, [2]int{-1, 0}, [2]int{-1, 1}, [2]int{0, -1}}
+
+// Coord is a space on the board, counting from 1. X is the row and Y the column.
+type Coord struct {
+	X int
+	Y int
+}
+
+func (coord Coord) String() string {
+	return strconv.Itoa(coord.X) + " " + strconv.Itoa(coord.Y)
+}
+
+// ParseCoord parses a space written as "<x> <y>", as in Coord.String
+func ParseCoord(space string) (Coord, bool) {
+	coordinates := strings.Split(space, " ")
+	if len(coordinates) != 2 {
+		return Coord{}, false
+	}
+
+	x, xErr := strconv.Atoi(coordinates[0])
+	y, yErr := strconv.Atoi(coordinates[1])
+	return Coord{X: x, Y: y}, xErr == nil && yErr == nil
+}
+
+// OpponentColor returns the color playing against color
+func OpponentColor(color string) string {
+	if color == "white" {
+		return "black"
+	}
+	return "white"
+}
+
+// boardWords is the number of 64-bit words needed for one bit per space on the largest board
+const boardWords = (MaxBoardSize*MaxBoardSize + 63) / 64
+
+// bitboard has one bit per space, numbered row by row from the top left
+type bitboard [boardWords]uint64
+
+func (bits *bitboard) has(index int) bool {
+	return bits[index/64]&(1<<uint(index%64)) != 0
+}
+
+func (bits *bitboard) set(index int) {
+	bits[index/64] |= 1 << uint(index%64)
+}
+
+func (bits *bitboard) clear(index int) {
+	bits[index/64] &^= 1 << uint(index%64)
+}
+
+// Board holds the stones of each color as a bitboard. Boards are values: assigning one
+// copies its stones.
+type Board struct {
+	Size      int
+	WinLength int
+	black     bitboard
+	white     bitboard
+}
+
+// NewBoard creates an empty board of the default size
+func NewBoard() Board {
+	return NewSizedBoard(DefaultBoardSize, DefaultWinLength)
+}
+
+// NewSizedBoard creates an empty size x size board won by winLength in a row
+func NewSizedBoard(size int, winLength int) Board {
+	return Board{
+		Size:      size,
+		WinLength: winLength,
+	}
+}
+
+// NewBoardFromSpaces creates a board from the stones of each color keyed by Coord.String,
+// as sent over the wire. Spaces that aren't on the board are ignored.
+func NewBoardFromSpaces(size int, winLength int, spaces map[string]map[string]bool) Board {
+	board := NewSizedBoard(size, winLength)
+	for color, colorSpaces := range spaces {
+		for space, taken := range colorSpaces {
+			coord, ok := ParseCoord(space)
+			if taken && ok && board.IsOnBoard(coord) {
+				board.Place(coord, color)
+			}
+		}
+	}
+	return board
+}
+
+// Copy returns a board with the same stones that can be changed independently
+func (board *Board) Copy() Board {
+	return *board
+}
+
+// IsOnBoard checks that a coordinate lies within the grid
+func (board *Board) IsOnBoard(coord Coord) bool {
+	return coord.X >= 1 && coord.X <= board.Size && coord.Y >= 1 && coord.Y <= board.Size
+}
+
+// index numbers a space on the board for its bitboards
+func (board *Board) index(coord Coord) int {
+	return (coord.X-1)*board.Size + coord.Y - 1
+}
+
+// stones returns the bitboard of color, or nil for anything but black and white
+func (board *Board) stones(color string) *bitboard {
+	switch color {
+	case "black":
+		return &board.black
+	case "white":
+		return &board.white
+	}
+	return nil
+}
+
+// has checks whether color has a stone on a space, which must be on the board
+func (board *Board) has(color string, coord Coord) bool {
+	stones := board.stones(color)
+	return stones != nil && stones.has(board.index(coord))
+}
+
+// IsTakenBy returns the color of the stone on a space, or FREE
+func (board *Board) IsTakenBy(move Coord) string {
+	if !board.IsOnBoard(move) {
+		return FREE
+	}
+
+	index := board.index(move)
+	if board.black.has(index) {
+		return "black"
+	}
+	if board.white.has(index) {
+		return "white"
+	}
+	return FREE
+}
+
+// Place puts a stone on a space, without checking whether the move is allowed
+func (board *Board) Place(move Coord, color string) {
+	if stones := board.stones(color); stones != nil && board.IsOnBoard(move) {
+		stones.set(board.index(move))
+	}
+}
+
+// Remove takes any stone off a space
+func (board *Board) Remove(move Coord) {
+	if board.IsOnBoard(move) {
+		index := board.index(move)
+		board.black.clear(index)
+		board.white.clear(index)
+	}
+}
+
+// Spaces returns the stones of each color keyed by Coord.String, as sent over the wire
+func (board *Board) Spaces() map[string]map[string]bool {
+	spaces := make(map[string]map[string]bool)
+	for _, color := range []string{"black", "white"} {
+		spaces[color] = make(map[string]bool)
+		for _, space := range board.ListSpaces(color) {
+			spaces[color][space] = true
+		}
+	}
+	return spaces
+}
+
+// ListSpaces returns the spaces taken by color, sorted
+func (board *Board) ListSpaces(color string) []string {
+	spaces := []string{}
+	stones := board.stones(color)
+	if stones == nil {
+		return spaces
+	}
+
+	for index := 0; index < board.Size*board.Size; index++ {
+		if stones.has(index) {
+			spaces = append(spaces, Coord{X: index/board.Size + 1, Y: index%board.Size + 1}.String())
+		}
+	}
+	sort.Strings(spaces)
+	return spaces
+}
+
+// run counts color's stones in a row from move along an axis, not including move itself
+func (board *Board) run(color string, axis [2]int, move Coord) int {
+	count := 0
+	next := Coord{X: move.X + axis[0], Y: move.Y + axis[1]}
+	for board.IsOnBoard(next) && board.has(color, next) {
+		count++
+		next = Coord{X: next.X + axis[0], Y: next.Y + axis[1]}
+	}
+	return count
+}
+
+// LineLength counts the stones in the line along an axis through move, including move itself
+func (board *Board) LineLength(color string, axis [2]int, move Coord) int {
+	complement := [2]int{axis[0] * -1, axis[1] * -1}
+	return 1 + board.run(color, axis, move) + board.run(color, complement, move)
+}
+
+// LineEnds returns the points just beyond each end of the line along an axis through move
+func (board *Board) LineEnds(color string, axis [2]int, move Coord) (Coord, Coord) {
+	complement := [2]int{axis[0] * -1, axis[1] * -1}
+	forward := board.run(color, axis, move) + 1
+	backward := board.run(color, complement, move) + 1
+
+	end1 := Coord{X: move.X + axis[0]*forward, Y: move.Y + axis[1]*forward}
+	end2 := Coord{X: move.X + complement[0]*backward, Y: move.Y + complement[1]*backward}
+	return end1, end2
+}
+
+// Line returns the stones in the line along an axis through move, including move itself,
+// from top to bottom, or left to right along a row
+func (board *Board) Line(color string, axis [2]int, move Coord) []Coord {
+	start, _ := board.LineEnds(color, axis, move)
+	length := board.LineLength(color, axis, move)
+
+	line := []Coord{}
+	for step := 1; step <= length; step++ {
+		line = append(line, Coord{X: start.X - axis[0]*step, Y: start.Y - axis[1]*step})
+	}
+	return line
+}
@@ -0,0 +1,357 @@
+package gomoku
+
+import (
+	"testing"
+)
+
+func coordsAreEqual(coord1 *Coord, coord2 *Coord) bool {
+	return coord1.X == coord2.X && coord1.Y == coord2.Y
+}
+
+func coordSlicesAreEqual(slice1 *[]Coord, slice2 *[]Coord) bool {
+	if len(*slice1) != len(*slice2) {
+		return false
+	}
+
+	for i := 0; i < len(*slice1); i++ {
+		coord1 := (*slice1)[i]
+		coord2 := (*slice2)[i]
+		if !coordsAreEqual(&coord1, &coord2) {
+			return false
+		}
+	}
+	return true
+}
+
+func getWinningCoordsSlices(gameBoard *Board) (*[]Coord, *[]Coord) {
+	winningCoordsWhite := []Coord{}
+	winningCoordsBlack := []Coord{}
+	for x := 0; x < 15; x++ {
+		for y := 0; y < 15; y++ {
+			coord := Coord{X: x, Y: y}
+			if (StandardRules{}).WinningLine(gameBoard, coord, "white") != nil {
+				winningCoordsWhite = append(winningCoordsWhite, coord)
+			}
+			if (StandardRules{}).WinningLine(gameBoard, coord, "black") != nil {
+				winningCoordsBlack = append(winningCoordsBlack, coord)
+			}
+		}
+	}
+	return &winningCoordsWhite, &winningCoordsBlack
+}
+
+func TestBoardNew(t *testing.T) {
+	gameBoard := NewBoard()
+	spaces := gameBoard.Spaces()
+	if spaces == nil {
+		t.Error("Expected board.Spaces to not be nil")
+	}
+	keys := make([]string, 0, len(spaces))
+	for k := range spaces {
+		keys = append(keys, k)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Expected board.Spaces to have 2 keys, found %d", len(keys))
+	}
+
+	if spaces["white"] == nil {
+		t.Error("Expected board.Spaces['white'] to not be nil")
+	}
+
+	if spaces["black"] == nil {
+		t.Error("Expected board.Spaces['black'] to not be nil")
+	}
+}
+
+func TestBoardcheckForWinEmpty(t *testing.T) {
+	gameBoard := NewBoard()
+	winningCoordsWhite, winningCoordsBlack := getWinningCoordsSlices(&gameBoard)
+	if len(*winningCoordsWhite) > 0 {
+		t.Errorf("Expected no winning spaces for white, found %d", len(*winningCoordsWhite))
+	}
+	if len(*winningCoordsBlack) > 0 {
+		t.Errorf("Expected no winning spaces for black, found %d", len(*winningCoordsBlack))
+	}
+}
+
+type BoardcheckForWinSuccessTestCase struct {
+	label                 string
+	coordStrings          []string
+	expectedWinningCoords []Coord
+}
+
+func getcheckForWinTestcases() []BoardcheckForWinSuccessTestCase {
+	testcases := []BoardcheckForWinSuccessTestCase{
+		BoardcheckForWinSuccessTestCase{
+			"horizontal",
+			[]string{"3 3", "4 3", "5 3", "6 3", "7 3"},
+			[]Coord{
+				Coord{X: 3, Y: 3},
+				Coord{X: 4, Y: 3},
+				Coord{X: 5, Y: 3},
+				Coord{X: 6, Y: 3},
+				Coord{X: 7, Y: 3},
+			},
+		},
+		BoardcheckForWinSuccessTestCase{
+			"diagonal_right",
+			[]string{"3 3", "4 4", "5 5", "6 6", "7 7"},
+			[]Coord{
+				Coord{X: 3, Y: 3},
+				Coord{X: 4, Y: 4},
+				Coord{X: 5, Y: 5},
+				Coord{X: 6, Y: 6},
+				Coord{X: 7, Y: 7},
+			},
+		},
+		BoardcheckForWinSuccessTestCase{
+			"vertical",
+			[]string{"3 3", "3 4", "3 5", "3 6", "3 7"},
+			[]Coord{
+				Coord{X: 3, Y: 3},
+				Coord{X: 3, Y: 4},
+				Coord{X: 3, Y: 5},
+				Coord{X: 3, Y: 6},
+				Coord{X: 3, Y: 7},
+			},
+		},
+		BoardcheckForWinSuccessTestCase{
+			"diagonal_left",
+			[]string{"7 3", "6 4", "5 5", "4 6", "3 7"},
+			[]Coord{
+				Coord{X: 3, Y: 7},
+				Coord{X: 4, Y: 6},
+				Coord{X: 5, Y: 5},
+				Coord{X: 6, Y: 4},
+				Coord{X: 7, Y: 3},
+			},
+		},
+	}
+	return testcases
+}
+
+func TestBoardcheckForWinSuccessWhite(t *testing.T) {
+	testcases := getcheckForWinTestcases()
+	for _, testcase := range testcases {
+		t.Run(testcase.label, func(t *testing.T) {
+			gameBoard := NewBoard()
+			for _, coordString := range testcase.coordStrings {
+				coord, _ := ParseCoord(coordString)
+				gameBoard.Place(coord, "white")
+			}
+
+			winningCoordsWhite, winningCoordsBlack := getWinningCoordsSlices(&gameBoard)
+
+			if len(*winningCoordsBlack) > 0 {
+				t.Errorf("Expected no winning spaces for black, found %d: %s", len(*winningCoordsBlack), winningCoordsBlack)
+			}
+
+			if !coordSlicesAreEqual(winningCoordsWhite, &testcase.expectedWinningCoords) {
+				t.Errorf("Expected winning spaces for white to be %s, got %s", testcase.expectedWinningCoords, winningCoordsWhite)
+			}
+		})
+	}
+}
+
+func TestBoardcheckForWinSuccessBlack(t *testing.T) {
+	testcases := getcheckForWinTestcases()
+	for _, testcase := range testcases {
+		t.Run(testcase.label, func(t *testing.T) {
+			gameBoard := NewBoard()
+			for _, coordString := range testcase.coordStrings {
+				coord, _ := ParseCoord(coordString)
+				gameBoard.Place(coord, "black")
+			}
+
+			winningCoordsWhite, winningCoordsBlack := getWinningCoordsSlices(&gameBoard)
+
+			if len(*winningCoordsWhite) > 0 {
+				t.Errorf("Expected no winning spaces for black, found %d: %s", len(*winningCoordsWhite), winningCoordsBlack)
+			}
+
+			if !coordSlicesAreEqual(winningCoordsBlack, &testcase.expectedWinningCoords) {
+				t.Errorf("Expected winning spaces for white to be %s, got %s", testcase.expectedWinningCoords, winningCoordsBlack)
+			}
+		})
+	}
+}
+
+func TestBoardcheckForWinConnectSix(t *testing.T) {
+	gameBoard := NewSizedBoard(19, 6)
+	for _, coordString := range []string{"3 3", "3 4", "3 5", "3 6", "3 7"} {
+		coord, _ := ParseCoord(coordString)
+		gameBoard.Place(coord, "black")
+	}
+
+	if (StandardRules{}).WinningLine(&gameBoard, Coord{X: 3, Y: 7}, "black") != nil {
+		t.Error("Expected five in a row not to win a connect-6 game")
+	}
+
+	gameBoard.Place(Coord{X: 3, Y: 8}, "black")
+	if (StandardRules{}).WinningLine(&gameBoard, Coord{X: 3, Y: 8}, "black") == nil {
+		t.Error("Expected six in a row to win a connect-6 game")
+	}
+}
+
+func TestBoardLine(t *testing.T) {
+	gameBoard := boardWithStones([]string{"7 3", "6 4", "5 5", "4 6", "2 8"}, []string{"3 7"})
+
+	line := gameBoard.Line("black", Axes[2], Coord{X: 5, Y: 5})
+	expected := []Coord{Coord{X: 4, Y: 6}, Coord{X: 5, Y: 5}, Coord{X: 6, Y: 4}, Coord{X: 7, Y: 3}}
+	if !coordSlicesAreEqual(&line, &expected) {
+		t.Errorf("Expected the line to be %v, got %v", expected, line)
+	}
+
+	line = gameBoard.Line("black", Axes[3], Coord{X: 5, Y: 5})
+	if len(line) != 1 || line[0] != (Coord{X: 5, Y: 5}) {
+		t.Errorf("Expected a line of just the move, got %v", line)
+	}
+}
+
+func TestBoardIsOnBoard(t *testing.T) {
+	gameBoard := NewSizedBoard(9, 5)
+	onBoard := []Coord{Coord{X: 1, Y: 1}, Coord{X: 9, Y: 9}, Coord{X: 5, Y: 1}}
+	offBoard := []Coord{Coord{X: 0, Y: 1}, Coord{X: 10, Y: 9}, Coord{X: 5, Y: 10}}
+
+	for _, coord := range onBoard {
+		if !gameBoard.IsOnBoard(coord) {
+			t.Errorf("Expected %s to be on a 9x9 board", coord)
+		}
+	}
+
+	for _, coord := range offBoard {
+		if gameBoard.IsOnBoard(coord) {
+			t.Errorf("Expected %s to be off a 9x9 board", coord)
+		}
+	}
+}
+
+func TestBoardSpacesRoundTrip(t *testing.T) {
+	gameBoard := NewSizedBoard(9, 5)
+	gameBoard.Place(Coord{X: 1, Y: 9}, "black")
+	gameBoard.Place(Coord{X: 9, Y: 1}, "white")
+	gameBoard.Place(Coord{X: 5, Y: 5}, "black")
+
+	spaces := gameBoard.Spaces()
+	if len(spaces["black"]) != 2 || !spaces["black"]["1 9"] || !spaces["black"]["5 5"] || len(spaces["white"]) != 1 || !spaces["white"]["9 1"] {
+		t.Fatalf("Expected the stones keyed by space, got %v", spaces)
+	}
+
+	spaces["black"]["10 10"] = true
+	restored := NewBoardFromSpaces(9, 5, spaces)
+	if restored != gameBoard {
+		t.Errorf("Expected the same stones back, got black %v and white %v", restored.ListSpaces("black"), restored.ListSpaces("white"))
+	}
+}
+
+func TestBoardCopyAndRemove(t *testing.T) {
+	gameBoard := NewBoard()
+	gameBoard.Place(Coord{X: 8, Y: 8}, "black")
+
+	copied := gameBoard.Copy()
+	copied.Place(Coord{X: 8, Y: 9}, "white")
+	copied.Remove(Coord{X: 8, Y: 8})
+
+	if gameBoard.IsTakenBy(Coord{X: 8, Y: 8}) != "black" || gameBoard.IsTakenBy(Coord{X: 8, Y: 9}) != FREE {
+		t.Error("Expected changes to the copy to leave the board alone")
+	}
+
+	if copied.IsTakenBy(Coord{X: 8, Y: 8}) != FREE || copied.IsTakenBy(Coord{X: 8, Y: 9}) != "white" {
+		t.Error("Expected the copy to have its own stones")
+	}
+
+	if gameBoard.IsTakenBy(Coord{X: 0, Y: 8}) != FREE || gameBoard.IsTakenBy(Coord{X: 16, Y: 8}) != FREE {
+		t.Error("Expected spaces off the board to be free")
+	}
+}
+
+// benchmarkBlack and benchmarkWhite are a crowded middle game, with black to play on 8 8
+var benchmarkBlack = []string{"7 7", "7 9", "9 7", "6 10", "10 6", "8 4", "8 5", "5 8", "11 11", "4 4", "12 3", "3 12", "9 10", "6 6"}
+var benchmarkWhite = []string{"7 8", "9 8", "8 7", "8 9", "6 8", "10 8", "5 5", "11 5", "5 11", "12 12", "3 3", "9 9", "10 10", "7 6"}
+
+// mapBoard is the board as it was stored before bitboards, with each color's stones in a map
+// keyed by "x y", kept so the benchmarks can compare the two
+type mapBoard struct {
+	spaces    map[string]map[string]bool
+	winLength int
+}
+
+func newMapBoard(black []string, white []string) mapBoard {
+	board := mapBoard{
+		spaces:    map[string]map[string]bool{"black": {}, "white": {}},
+		winLength: DefaultWinLength,
+	}
+	for _, space := range black {
+		board.spaces["black"][space] = true
+	}
+	for _, space := range white {
+		board.spaces["white"][space] = true
+	}
+	return board
+}
+
+func (board mapBoard) isTakenBy(move Coord) string {
+	spotStr := move.String()
+	for color := range board.spaces {
+		if board.spaces[color][spotStr] {
+			return color
+		}
+	}
+	return FREE
+}
+
+func (board mapBoard) checkAlongAxis(color string, axis [2]int, move Coord, num int) int {
+	next := Coord{X: move.X + axis[0], Y: move.Y + axis[1]}
+	for space := range board.spaces[color] {
+		coord, _ := ParseCoord(space)
+		if coord == next {
+			return board.checkAlongAxis(color, axis, next, num+1)
+		}
+	}
+	return num
+}
+
+func (board mapBoard) checkForWin(move Coord, color string) bool {
+	for _, axis := range Axes {
+		complement := [2]int{-axis[0], -axis[1]}
+		length := board.checkAlongAxis(color, complement, move, board.checkAlongAxis(color, axis, move, 1))
+		if length == board.winLength {
+			return true
+		}
+	}
+	return false
+}
+
+func BenchmarkMapBoardIsTakenBy(b *testing.B) {
+	gameBoard := newMapBoard(benchmarkBlack, benchmarkWhite)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gameBoard.isTakenBy(Coord{X: 1 + i%15, Y: 1 + (i/15)%15})
+	}
+}
+
+func BenchmarkMapBoardWinningLine(b *testing.B) {
+	gameBoard := newMapBoard(benchmarkBlack, benchmarkWhite)
+	move := Coord{X: 8, Y: 8}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gameBoard.checkForWin(move, "black")
+	}
+}
+
+func BenchmarkBoardIsTakenBy(b *testing.B) {
+	gameBoard := boardWithStones(benchmarkBlack, benchmarkWhite)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gameBoard.IsTakenBy(Coord{X: 1 + i%15, Y: 1 + (i/15)%15})
+	}
+}
+
+func BenchmarkBoardWinningLine(b *testing.B) {
+	gameBoard := boardWithStones(benchmarkBlack, benchmarkWhite)
+	move := Coord{X: 8, Y: 8}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		StandardRules{}.WinningLine(&gameBoard, move, "black")
+	}
+}
@@ -0,0 +1,242 @@
+package gomoku
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// time control kinds
+const (
+	SUDDENDEATH = "sudden death"
+	FISCHER     = "fischer"
+	BYOYOMI     = "byo-yomi"
+)
+
+// TimeControl describes how much time each player gets. The zero value means the game is
+// untimed.
+type TimeControl struct {
+	Kind string
+	// Main is the time each player starts with
+	Main time.Duration
+	// Increment is added to a player's clock after each of their turns under Fischer
+	Increment time.Duration
+	// Period is the length of each byo-yomi period, and Periods how many each player has
+	Period  time.Duration
+	Periods int
+}
+
+// IsTimeControl checks whether an option looks like a time control, e.g. "5m"
+func IsTimeControl(option string) bool {
+	return option != "" && option[0] >= '0' && option[0] <= '9' && strings.ContainsAny(option, "hms")
+}
+
+// ParseTimeControl parses "<main>" for sudden death, "<main>+<increment>" for Fischer or
+// "<main>/<period>[x<periods>]" for byo-yomi, with durations like "5m" or "30s"
+func ParseTimeControl(option string) (TimeControl, error) {
+	syntaxError := errors.New("The syntax for time controls is <main>, <main>+<increment> or <main>/<period>[x<periods>], ex: 5m, 5m+3s or 10m/30sx3")
+
+	control := TimeControl{Kind: SUDDENDEATH}
+	mainStr := option
+
+	if parts := strings.Split(option, "+"); len(parts) == 2 {
+		increment, err := time.ParseDuration(parts[1])
+		if err != nil || increment <= 0 {
+			return control, syntaxError
+		}
+		control.Kind = FISCHER
+		control.Increment = increment
+		mainStr = parts[0]
+	} else if parts := strings.Split(option, "/"); len(parts) == 2 {
+		control.Kind = BYOYOMI
+		control.Periods = 1
+		periodStr := parts[1]
+
+		if periodParts := strings.Split(periodStr, "x"); len(periodParts) == 2 {
+			periods, err := strconv.Atoi(periodParts[1])
+			if err != nil || periods < 1 {
+				return control, syntaxError
+			}
+			control.Periods = periods
+			periodStr = periodParts[0]
+		}
+
+		period, err := time.ParseDuration(periodStr)
+		if err != nil || period <= 0 {
+			return control, syntaxError
+		}
+		control.Period = period
+		mainStr = parts[0]
+	}
+
+	main, err := time.ParseDuration(mainStr)
+	if err != nil || main < 0 {
+		return control, syntaxError
+	}
+	control.Main = main
+
+	if main == 0 && control.Kind != BYOYOMI {
+		return control, errors.New("Players need some time on their clocks")
+	}
+
+	return control, nil
+}
+
+func (control TimeControl) String() string {
+	switch control.Kind {
+	case SUDDENDEATH:
+		return FormatDuration(control.Main) + " sudden death"
+	case FISCHER:
+		return FormatDuration(control.Main) + " + " + FormatDuration(control.Increment) + " Fischer"
+	case BYOYOMI:
+		return FormatDuration(control.Main) + " + " + strconv.Itoa(control.Periods) + "x" + FormatDuration(control.Period) + " byo-yomi"
+	}
+	return "untimed"
+}
+
+// FormatDuration shows a duration as m:ss, rounding up so that a clock only shows 0:00
+// once it has run out
+func FormatDuration(duration time.Duration) string {
+	if duration < 0 {
+		duration = 0
+	}
+	seconds := int((duration + time.Second - 1) / time.Second)
+	return strconv.Itoa(seconds/60) + ":" + strconv.Itoa(seconds%60/10) + strconv.Itoa(seconds%10)
+}
+
+// PlayerClock is the time a player has left
+type PlayerClock struct {
+	Remaining time.Duration
+	// Periods is how many byo-yomi periods are left
+	Periods int
+}
+
+// FormatClock shows the time a player has left under a time control
+func FormatClock(clock PlayerClock, control TimeControl) string {
+	formatted := FormatDuration(clock.Remaining)
+	if control.Kind == BYOYOMI {
+		formatted += " +" + strconv.Itoa(clock.Periods) + "x" + FormatDuration(control.Period)
+	}
+	return formatted
+}
+
+// GameClock tracks the time each player in a game has left
+type GameClock struct {
+	Control TimeControl
+	Players map[string]PlayerClock
+	// Running is the player whose clock is running, or empty when the clock is stopped
+	Running string
+	Started time.Time
+}
+
+// NewGameClock creates the clock for a time control, or returns nil if the game is untimed
+func NewGameClock(control TimeControl) *GameClock {
+	if control.Kind == "" {
+		return nil
+	}
+	return &GameClock{
+		Control: control,
+		Players: make(map[string]PlayerClock),
+	}
+}
+
+// Start gives every player their full time and starts the first player's clock
+func (clock *GameClock) Start(game *Game, now time.Time) {
+	for id := range game.Colors {
+		clock.Players[id] = PlayerClock{Remaining: clock.Control.Main, Periods: clock.Control.Periods}
+	}
+	clock.Running = game.FirstPlayerID
+	clock.Started = now
+}
+
+// Resume restarts the running clock from now, so that time the server was down isn't counted
+func (clock *GameClock) Resume(now time.Time) {
+	clock.Started = now
+}
+
+// spend returns what a player's clock would show after using elapsed time
+func (clock *GameClock) spend(player PlayerClock, elapsed time.Duration) PlayerClock {
+	if elapsed <= player.Remaining {
+		player.Remaining -= elapsed
+		return player
+	}
+
+	if clock.Control.Kind != BYOYOMI {
+		player.Remaining -= elapsed
+		return player
+	}
+
+	// each period that is used up completely is lost
+	overflow := elapsed - player.Remaining
+	player.Remaining = 0
+	player.Periods -= int(overflow / clock.Control.Period)
+	return player
+}
+
+// Deadline is when the running player's time runs out
+func (clock *GameClock) Deadline() time.Time {
+	player := clock.Players[clock.Running]
+	deadline := clock.Started.Add(player.Remaining)
+	if clock.Control.Kind == BYOYOMI {
+		deadline = deadline.Add(time.Duration(player.Periods) * clock.Control.Period)
+	}
+	return deadline
+}
+
+// Flagged returns the player whose time has run out, if any
+func (clock *GameClock) Flagged(now time.Time) string {
+	if clock.Running == "" || now.Before(clock.Deadline()) {
+		return ""
+	}
+	return clock.Running
+}
+
+// Press ends the running player's turn and starts the next player's clock
+func (clock *GameClock) Press(next string, now time.Time) {
+	player := clock.spend(clock.Players[clock.Running], now.Sub(clock.Started))
+	if clock.Control.Kind == FISCHER {
+		player.Remaining += clock.Control.Increment
+	}
+	clock.Players[clock.Running] = player
+
+	clock.Running = next
+	clock.Started = now
+}
+
+// SwitchTo charges the running player for their time so far and starts userID's clock,
+// without any increment
+func (clock *GameClock) SwitchTo(userID string, now time.Time) {
+	if clock.Running != "" {
+		clock.Players[clock.Running] = clock.spend(clock.Players[clock.Running], now.Sub(clock.Started))
+	}
+	clock.Running = userID
+	clock.Started = now
+}
+
+// Stop charges the running player for their turn and stops the clock
+func (clock *GameClock) Stop(now time.Time) {
+	if clock.Running == "" {
+		return
+	}
+	clock.Players[clock.Running] = clock.spend(clock.Players[clock.Running], now.Sub(clock.Started))
+	clock.Running = ""
+}
+
+// State reports the time every player has left as of now
+func (clock *GameClock) State(now time.Time) map[string]PlayerClock {
+	state := make(map[string]PlayerClock)
+	for id, player := range clock.Players {
+		if id == clock.Running {
+			player = clock.spend(player, now.Sub(clock.Started))
+		}
+		if player.Remaining < 0 {
+			player.Remaining = 0
+		}
+		if player.Periods < 0 {
+			player.Periods = 0
+		}
+		state[id] = player
+	}
+	return state
+}
@@ -0,0 +1,111 @@
+package gomoku
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeControlValid(t *testing.T) {
+	testcases := map[string]TimeControl{
+		"5m":        TimeControl{Kind: SUDDENDEATH, Main: 5 * time.Minute},
+		"1h":        TimeControl{Kind: SUDDENDEATH, Main: time.Hour},
+		"5m+3s":     TimeControl{Kind: FISCHER, Main: 5 * time.Minute, Increment: 3 * time.Second},
+		"10m/30s":   TimeControl{Kind: BYOYOMI, Main: 10 * time.Minute, Period: 30 * time.Second, Periods: 1},
+		"0s/1mx5":   TimeControl{Kind: BYOYOMI, Period: time.Minute, Periods: 5},
+		"1m30s+10s": TimeControl{Kind: FISCHER, Main: 90 * time.Second, Increment: 10 * time.Second},
+	}
+
+	for option, expected := range testcases {
+		control, err := ParseTimeControl(option)
+		if err != nil {
+			t.Errorf("Expected '%s' to be valid, got error: %s", option, err)
+		}
+		if control != expected {
+			t.Errorf("Expected '%s' to give %v, got %v", option, expected, control)
+		}
+	}
+}
+
+func TestParseTimeControlInvalid(t *testing.T) {
+	for _, option := range []string{"5x", "0m", "5m+0s", "5m+", "5m/30sx0", "5m/", "5m+3s+3s"} {
+		_, err := ParseTimeControl(option)
+		if err == nil {
+			t.Errorf("Expected '%s' to be invalid", option)
+		}
+	}
+}
+
+func TestGameClockSuddenDeath(t *testing.T) {
+	now := time.Now()
+	settings := DefaultSettings()
+	settings.TimeControl = TimeControl{Kind: SUDDENDEATH, Main: time.Minute}
+	game := newTestGame(settings, now)
+
+	game.Clock.Press("mock_user2", now.Add(20*time.Second))
+	if remaining := game.Clock.Players["mock_user1"].Remaining; remaining != 40*time.Second {
+		t.Errorf("Expected 40s left, got %s", remaining)
+	}
+
+	if flagged := game.Clock.Flagged(now.Add(79 * time.Second)); flagged != "" {
+		t.Errorf("Expected no flag to have fallen, got %s", flagged)
+	}
+
+	if flagged := game.Clock.Flagged(now.Add(80 * time.Second)); flagged != "mock_user2" {
+		t.Errorf("Expected mock_user2's flag to fall, got '%s'", flagged)
+	}
+}
+
+func TestGameClockFischer(t *testing.T) {
+	now := time.Now()
+	settings := DefaultSettings()
+	settings.TimeControl = TimeControl{Kind: FISCHER, Main: time.Minute, Increment: 5 * time.Second}
+	game := newTestGame(settings, now)
+
+	game.Clock.Press("mock_user2", now.Add(10*time.Second))
+	if remaining := game.Clock.Players["mock_user1"].Remaining; remaining != 55*time.Second {
+		t.Errorf("Expected 55s left, got %s", remaining)
+	}
+
+	state := game.Clock.State(now.Add(25 * time.Second))
+	if state["mock_user2"].Remaining != 45*time.Second || state["mock_user1"].Remaining != 55*time.Second {
+		t.Errorf("Expected clocks of 55s and 45s, got %v", state)
+	}
+}
+
+func TestGameClockByoYomi(t *testing.T) {
+	now := time.Now()
+	settings := DefaultSettings()
+	settings.TimeControl = TimeControl{Kind: BYOYOMI, Main: time.Minute, Period: 10 * time.Second, Periods: 3}
+	game := newTestGame(settings, now)
+
+	// moving within a period keeps it
+	game.Clock.Press("mock_user2", now.Add(65*time.Second))
+	player := game.Clock.Players["mock_user1"]
+	if player.Remaining != 0 || player.Periods != 3 {
+		t.Errorf("Expected no main time and 3 periods, got %v", player)
+	}
+
+	game.Clock.Press("mock_user1", now.Add(65*time.Second))
+
+	// going over a period uses it up
+	game.Clock.Press("mock_user2", now.Add(80*time.Second))
+	player = game.Clock.Players["mock_user1"]
+	if player.Periods != 2 {
+		t.Errorf("Expected 2 periods, got %v", player)
+	}
+
+	game.Clock.Press("mock_user1", now.Add(80*time.Second))
+	if flagged := game.Clock.Flagged(now.Add(100 * time.Second)); flagged != "mock_user1" {
+		t.Errorf("Expected mock_user1's flag to fall after using both periods, got '%s'", flagged)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	if formatted := FormatClock(PlayerClock{Remaining: 272 * time.Second}, TimeControl{Kind: FISCHER}); formatted != "4:32" {
+		t.Errorf("Expected 4:32, got %s", formatted)
+	}
+
+	if formatted := FormatClock(PlayerClock{Remaining: 0, Periods: 2}, TimeControl{Kind: BYOYOMI, Period: 30 * time.Second}); formatted != "0:00 +2x0:30" {
+		t.Errorf("Expected 0:00 +2x0:30, got %s", formatted)
+	}
+}
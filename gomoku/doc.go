@@ -0,0 +1,20 @@
+// Package gomoku implements the rules of Gomoku and its variants: boards of any size from
+// 5x5 to 25x25, the line length needed to win, the freestyle, standard, caro and renju rule
+// sets, the swap, swap2, pro and long pro openings, and time controls.
+//
+// A Game is created from Settings, seated with two players and started:
+//
+//	game := gomoku.NewGame(gomoku.DefaultSettings())
+//	game.AddPlayer("alice")
+//	game.AddPlayer("bob")
+//	game.Start("alice", time.Now())
+//
+// Turns are then played as the players type them, e.g. "8 8" for a single stone or
+// "8 8, 8 9, 6 6" for the three stones that start the swap opening:
+//
+//	message, err := game.PlayTurn("alice", "8 8, 8 9, 6 6", time.Now())
+//
+// PlayTurn rejects turns that are out of order or not allowed, and ends the game once a
+// player wins or no legal moves remain. Board, CheckMove and the RuleSet can also be used
+// on their own to analyse a position.
+package gomoku
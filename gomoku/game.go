@@ -0,0 +1,454 @@
+package gomoku
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game is a single game between two players: the board, whose turn it is, the opening and
+// the clock. It knows nothing about connections, so that servers, clients and analysis tools
+// can all drive it the same way.
+type Game struct {
+	Settings Settings
+	// Colors maps each player to their color, which is empty until the opening assigns it
+	Colors map[string]string
+	// Turn counts from 1, and is 0 until the game starts
+	Turn          int
+	FirstPlayerID string
+	Board         Board
+	Rules         RuleSet
+	Opening       Opening
+	// Clock is nil in untimed games
+	Clock *GameClock
+	// History lists every turn, exactly as the player submitted it
+	History []TurnRecord
+	// Moves lists every stone in the order it was placed
+	Moves  []MoveRecord
+	IsOver bool
+	Result Result
+	// WinningLine is the line of stones that won the game, if it was won on the board
+	WinningLine []Coord
+}
+
+// TurnRecord is a single turn, exactly as the player submitted it
+type TurnRecord struct {
+	Turn   int
+	UserID string
+	Data   string
+	Time   time.Time
+}
+
+// MoveRecord is a single stone placed during a game
+type MoveRecord struct {
+	Turn  int
+	Color string
+	Coord Coord
+	Time  time.Time
+}
+
+// NewGame creates a game with no players, waiting to be started
+func NewGame(settings Settings) *Game {
+	return &Game{
+		Settings: settings,
+		Colors:   make(map[string]string),
+		Board:    NewSizedBoard(settings.Size, settings.WinLength),
+		Rules:    RuleSetByName(settings.RuleSet),
+		Opening:  NewOpening(settings),
+		Clock:    NewGameClock(settings.TimeControl),
+	}
+}
+
+// AddPlayer seats a player, without a color until the opening assigns one
+func (game *Game) AddPlayer(userID string) {
+	game.Colors[userID] = ""
+}
+
+// Opponent returns the other player's id
+func (game *Game) Opponent(userID string) string {
+	for id := range game.Colors {
+		if id != userID {
+			return id
+		}
+	}
+	return ""
+}
+
+// IsTurn checks whether it's a player's turn. It's never the turn of someone who isn't playing.
+func (game *Game) IsTurn(userID string) bool {
+	if _, ok := game.Colors[userID]; !ok {
+		return false
+	}
+
+	if userID == game.FirstPlayerID {
+		return game.Turn%2 == 1
+	}
+	return game.Turn%2 == 0
+}
+
+// PlayerToMove returns the id of the player whose turn it is, or "" if the game isn't
+// being played
+func (game *Game) PlayerToMove() string {
+	if game.IsOver || game.Turn == 0 {
+		return ""
+	}
+
+	for id := range game.Colors {
+		if game.IsTurn(id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// AssignColors makes blackID play black and the other player white
+func (game *Game) AssignColors(blackID string) {
+	for id := range game.Colors {
+		if id == blackID {
+			game.Colors[id] = "black"
+		} else {
+			game.Colors[id] = "white"
+		}
+	}
+}
+
+// PlayerColors maps each player to their color, or returns nil before colors are chosen
+func (game *Game) PlayerColors() map[string]string {
+	colors := make(map[string]string)
+	for id, color := range game.Colors {
+		if color == "" {
+			return nil
+		}
+		colors[id] = color
+	}
+	return colors
+}
+
+// ParseMove parses "<x> <y>" into a free spot on the board
+func (game *Game) ParseMove(moveStr string) (Coord, error) {
+	coordinates := strings.Split(moveStr, " ")
+
+	if len(coordinates) != 2 {
+		return Coord{}, errors.New("The syntax for a move is mv <x> <y>")
+	}
+
+	x, xErr := strconv.Atoi(coordinates[0])
+	y, yErr := strconv.Atoi(coordinates[1])
+
+	move := Coord{
+		X: x,
+		Y: y,
+	}
+
+	isNil := xErr != nil || yErr != nil
+
+	if isNil || !game.Board.IsOnBoard(move) {
+		return Coord{}, errors.New("Both x and y must be integers from 1 to " + strconv.Itoa(game.Board.Size))
+	}
+
+	if game.Board.IsTakenBy(move) != FREE {
+		return Coord{}, errors.New("That spot is already taken!")
+	}
+
+	return move, nil
+}
+
+// ParseMoves parses exactly count comma-separated moves onto distinct free spots
+func (game *Game) ParseMoves(data string, count int) ([]Coord, error) {
+	moveStrs := strings.Split(data, ", ")
+	if len(moveStrs) != count {
+		return nil, errors.New("Please choose exactly " + strconv.Itoa(count) + " sets of two values")
+	}
+
+	moves := []Coord{}
+	for _, moveStr := range moveStrs {
+		move, err := game.ParseMove(moveStr)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, other := range moves {
+			if other == move {
+				return nil, errors.New("You can't place two pieces on the same spot!")
+			}
+		}
+
+		moves = append(moves, move)
+	}
+
+	return moves, nil
+}
+
+// CheckMove explains why color may not place a stone on move once the opening is over, or
+// returns nil if it may
+func (game *Game) CheckMove(move Coord, color string) error {
+	if !game.Board.IsOnBoard(move) {
+		return errors.New("Both x and y must be integers from 1 to " + strconv.Itoa(game.Board.Size))
+	}
+
+	if game.Board.IsTakenBy(move) != FREE {
+		return errors.New("That spot is already taken!")
+	}
+
+	reason := game.Rules.ForbiddenReason(&game.Board, move, color)
+	if reason != "" {
+		return errors.New("That move is forbidden for " + color + " under " + game.Rules.Name() + " rules: " + reason)
+	}
+
+	return nil
+}
+
+// PlayMove places a piece and adds it to the move list
+func (game *Game) PlayMove(move Coord, color string) {
+	game.Board.Place(move, color)
+	game.Moves = append(game.Moves, MoveRecord{Turn: game.Turn, Color: color, Coord: move})
+}
+
+// placeOpeningStone places a stone for the opening once colors have been chosen, reporting
+// whether it completed a line. With a short win length this can happen before the opening is
+// over, and PlayTurn ends the game once the opening's turn is done.
+func (game *Game) placeOpeningStone(move Coord, color string) bool {
+	game.PlayMove(move, color)
+	game.WinningLine = game.Rules.WinningLine(&game.Board, move, color)
+	return game.WinningLine != nil
+}
+
+// placeUnownedStones places stones for the opening before anyone has a color. A line completed
+// then would belong to nobody, so none of the stones are placed if any of them would complete one.
+func (game *Game) placeUnownedStones(moves []Coord, colors []string) error {
+	board := game.Board.Copy()
+	for i, move := range moves {
+		board.Place(move, colors[i])
+		if game.Rules.WinningLine(&board, move, colors[i]) != nil {
+			return errors.New("The " + colors[i] + " stone on " + move.String() + " would complete a line before colors are chosen. Please place it elsewhere!")
+		}
+	}
+
+	for i, move := range moves {
+		game.PlayMove(move, colors[i])
+	}
+	return nil
+}
+
+// playerWithColor returns the id of the player who plays color, or "" before colors are chosen
+func (game *Game) playerWithColor(color string) string {
+	for id, playerColor := range game.Colors {
+		if playerColor == color {
+			return id
+		}
+	}
+	return ""
+}
+
+// Start begins the game with firstPlayerID to move
+func (game *Game) Start(firstPlayerID string, now time.Time) {
+	game.Turn = 1
+	game.FirstPlayerID = firstPlayerID
+
+	if game.Clock != nil {
+		game.Clock.Start(game, now)
+	}
+}
+
+// PlayTurn applies a player's turn, played at now, and records it in the game's history.
+// During the opening the turn is handed to the opening; afterwards it is a single stone.
+// It returns a message describing the turn, or an error explaining why the turn isn't allowed.
+func (game *Game) PlayTurn(userID string, data string, now time.Time) (string, error) {
+	if _, ok := game.Colors[userID]; !ok {
+		return "", errors.New("You're not playing in this game!")
+	}
+
+	if game.IsOver {
+		return "", errors.New("The game is over!")
+	}
+
+	if !game.IsTurn(userID) {
+		return "", errors.New("It's not your turn!")
+	}
+
+	if game.Clock != nil && game.Clock.Flagged(now) == userID {
+		return "", errors.New("You ran out of time!")
+	}
+
+	var message string
+	firstMove := len(game.Moves)
+
+	if !game.Opening.Done() {
+		openingMessage, err := game.Opening.Play(game, userID, data)
+		if err != nil {
+			return "", err
+		}
+
+		message = openingMessage
+		if game.WinningLine != nil {
+			// the line belongs to whoever plays its color
+			winnerID := game.playerWithColor(game.Board.IsTakenBy(game.WinningLine[0]))
+			game.End(Result{Kind: WON, WinnerID: winnerID}, now)
+			message = "won!!!! (" + data + " )"
+		}
+	} else {
+		move, err := game.ParseMove(data)
+		if err != nil {
+			return "", err
+		}
+
+		color := game.Colors[userID]
+		err = game.CheckMove(move, color)
+		if err != nil {
+			return "", err
+		}
+
+		game.PlayMove(move, color)
+
+		line := game.Rules.WinningLine(&game.Board, move, color)
+		if line != nil {
+			game.WinningLine = line
+			game.End(Result{Kind: WON, WinnerID: userID}, now)
+			message = "won!!!! (" + data + " )"
+		} else {
+			message = "(played on " + data + " )"
+		}
+	}
+
+	for i := firstMove; i < len(game.Moves); i++ {
+		game.Moves[i].Time = now
+	}
+
+	game.History = append(game.History, TurnRecord{
+		Turn:   game.Turn,
+		UserID: userID,
+		Data:   data,
+		Time:   now,
+	})
+
+	if game.IsOver {
+		return message, nil
+	}
+
+	opponentID := game.Opponent(userID)
+	game.Turn++
+	if game.Clock != nil {
+		game.Clock.Press(opponentID, now)
+	}
+
+	if !game.HasLegalMove(opponentID) {
+		game.End(Result{Kind: DRAWN}, now)
+		message += " -- no legal moves remain, so the game is a draw!"
+	}
+
+	return message, nil
+}
+
+// End finishes the game with result and stops the clocks
+func (game *Game) End(result Result, now time.Time) {
+	game.IsOver = true
+	game.Result = result
+	if game.Clock != nil {
+		game.Clock.Stop(now)
+	}
+}
+
+// HasLegalMove checks whether userID could place a stone anywhere on the board
+func (game *Game) HasLegalMove(userID string) bool {
+	color := game.Colors[userID]
+	for x := 1; x <= game.Board.Size; x++ {
+		for y := 1; y <= game.Board.Size; y++ {
+			move := Coord{X: x, Y: y}
+			if game.Board.IsTakenBy(move) != FREE {
+				continue
+			}
+
+			if color == "" || !game.Opening.Done() || game.Rules.ForbiddenReason(&game.Board, move, color) == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reset clears the board for a new game between the same players, with firstPlayerID to move
+func (game *Game) Reset(firstPlayerID string, now time.Time) {
+	game.Board = NewSizedBoard(game.Settings.Size, game.Settings.WinLength)
+	game.Opening = NewOpening(game.Settings)
+	game.History = nil
+	game.Moves = nil
+	game.IsOver = false
+	game.Result = Result{}
+	game.WinningLine = nil
+	for id := range game.Colors {
+		game.Colors[id] = ""
+	}
+
+	game.Clock = NewGameClock(game.Settings.TimeControl)
+	game.Start(firstPlayerID, now)
+}
+
+// LastMove returns the stones placed on the last turn that placed any
+func (game *Game) LastMove() []Coord {
+	if len(game.Moves) == 0 {
+		return nil
+	}
+
+	turn := game.Moves[len(game.Moves)-1].Turn
+	first := len(game.Moves) - 1
+	for first > 0 && game.Moves[first-1].Turn == turn {
+		first--
+	}
+
+	stones := []Coord{}
+	for _, move := range game.Moves[first:] {
+		stones = append(stones, move.Coord)
+	}
+	return stones
+}
+
+// LastTurnBy returns the index in the history of userID's last turn, or -1 if they haven't
+// played yet
+func (game *Game) LastTurnBy(userID string) int {
+	last := -1
+	for i, turn := range game.History {
+		if turn.UserID == userID {
+			last = i
+		}
+	}
+	return last
+}
+
+// TakeBack undoes userID's last turn, along with any turn played after it, by replaying the
+// turns before it on a fresh board. Replaying also undoes any colors chosen or opening stages
+// reached by the turns taken back. The game is only changed once every turn has replayed. It
+// returns the number of the first turn taken back.
+func (game *Game) TakeBack(userID string, now time.Time) (int, error) {
+	last := game.LastTurnBy(userID)
+	if last == -1 {
+		return 0, errors.New("There are no moves to take back")
+	}
+
+	firstUndone := game.History[last].Turn
+
+	replay := NewGame(game.Settings)
+	replay.Rules = game.Rules
+	// the clock keeps running as it was, rather than being replayed
+	replay.Clock = nil
+	replay.FirstPlayerID = game.FirstPlayerID
+	replay.Turn = 1
+	for id := range game.Colors {
+		replay.AddPlayer(id)
+	}
+
+	for _, turn := range game.History[:last] {
+		_, err := replay.PlayTurn(turn.UserID, turn.Data, turn.Time)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	replay.Clock = game.Clock
+	*game = *replay
+	if game.Clock != nil {
+		game.Clock.SwitchTo(userID, now)
+	}
+
+	return firstUndone, nil
+}
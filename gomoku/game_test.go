@@ -0,0 +1,219 @@
+package gomoku
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestGame creates a game with settings between mock_user1 and mock_user2, started at now
+// with mock_user1 to move
+func newTestGame(settings Settings, now time.Time) *Game {
+	game := NewGame(settings)
+	game.AddPlayer("mock_user1")
+	game.AddPlayer("mock_user2")
+	game.Start("mock_user1", now)
+	return game
+}
+
+func playTestTurns(t *testing.T, game *Game, turns []string) {
+	for i, data := range turns {
+		userID := "mock_user1"
+		if i%2 == 1 {
+			userID = "mock_user2"
+		}
+
+		_, err := game.PlayTurn(userID, data, time.Now())
+		if err != nil {
+			t.Fatalf("Could not play '%s': %s", data, err)
+		}
+	}
+}
+
+func TestMoveList(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "pass", "2 2"})
+
+	expected := []MoveRecord{
+		MoveRecord{Turn: 1, Color: "black", Coord: Coord{X: 8, Y: 8}},
+		MoveRecord{Turn: 1, Color: "black", Coord: Coord{X: 8, Y: 9}},
+		MoveRecord{Turn: 1, Color: "white", Coord: Coord{X: 1, Y: 1}},
+		MoveRecord{Turn: 3, Color: "white", Coord: Coord{X: 2, Y: 2}},
+	}
+
+	if len(game.Moves) != len(expected) {
+		t.Fatalf("Expected %d moves, got %v", len(expected), game.Moves)
+	}
+
+	for i, move := range game.Moves {
+		if move.Turn != expected[i].Turn || move.Color != expected[i].Color || move.Coord != expected[i].Coord {
+			t.Errorf("Expected move %d to be %v, got %v", i, expected[i], move)
+		}
+		if move.Time.IsZero() {
+			t.Errorf("Expected move %d to have a time", i)
+		}
+	}
+}
+
+func TestTakeBackSwapTurn(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1"})
+
+	turn, err := game.TakeBack("mock_user1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if turn != 1 || game.Turn != 1 {
+		t.Errorf("Expected to be back on turn 1, got %d", game.Turn)
+	}
+
+	if len(game.Board.ListSpaces("black")) != 0 || len(game.Board.ListSpaces("white")) != 0 || len(game.Moves) != 0 || game.LastMove() != nil {
+		t.Error("Expected all three stones to be taken back")
+	}
+
+	if game.Opening.Stage() != SwapPlaceThree {
+		t.Errorf("Expected the opening to be back at its first stage, got %d", game.Opening.Stage())
+	}
+}
+
+func TestTakeBackColorChoice(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "pass"})
+
+	_, err := game.TakeBack("mock_user2", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.PlayerColors() != nil {
+		t.Errorf("Expected colors to be unassigned, got %v", game.PlayerColors())
+	}
+
+	if !game.IsTurn("mock_user2") || game.Opening.Stage() != SwapChooseColor {
+		t.Error("Expected mock_user2 to choose a color again")
+	}
+
+	if len(game.Board.ListSpaces("black")) != 2 {
+		t.Error("Expected the first turn's stones to stay on the board")
+	}
+}
+
+func TestTakeBackAfterReply(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2", "3 3", "4 4"})
+
+	// mock_user1 takes back their move on 3 3, so 4 4 goes too
+	turn, err := game.TakeBack("mock_user1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if turn != 3 || game.Turn != 3 || !game.IsTurn("mock_user1") {
+		t.Errorf("Expected it to be mock_user1's turn 3 again, got turn %d", game.Turn)
+	}
+
+	if game.Board.IsTakenBy(Coord{X: 3, Y: 3}) != FREE || game.Board.IsTakenBy(Coord{X: 4, Y: 4}) != FREE {
+		t.Error("Expected 3 3 and 4 4 to be free")
+	}
+
+	if game.Board.IsTakenBy(Coord{X: 2, Y: 2}) != "white" {
+		t.Error("Expected 2 2 to stay on the board")
+	}
+}
+
+func TestPlayTurnOutsider(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1"})
+
+	if game.IsTurn("spectator") || game.PlayerToMove() != "mock_user2" {
+		t.Error("Expected it to be mock_user2's turn and never a spectator's")
+	}
+
+	if _, err := game.PlayTurn("spectator", "pass", time.Now()); err == nil {
+		t.Fatal("Expected a spectator's turn to be refused")
+	}
+	if game.Turn != 2 || game.PlayerColors() != nil {
+		t.Errorf("Expected the game to be untouched on turn 2, got turn %d with colors %v", game.Turn, game.Colors)
+	}
+}
+
+func TestCheckMove(t *testing.T) {
+	game := NewGame(Settings{Size: 15, WinLength: 5, RuleSet: RENJU, Opening: SWAP})
+	game.Board = boardWithStones([]string{"7 8", "7 9", "8 7", "9 7"}, []string{"1 1"})
+
+	testcases := map[Coord]string{
+		Coord{X: 0, Y: 3}:  "Both x and y must be integers from 1 to 15",
+		Coord{X: 1, Y: 1}:  "That spot is already taken!",
+		Coord{X: 7, Y: 7}:  "That move is forbidden for black under renju rules: double three",
+		Coord{X: 12, Y: 1}: "",
+	}
+
+	for move, expected := range testcases {
+		err := game.CheckMove(move, "black")
+		if (err == nil && expected != "") || (err != nil && err.Error() != expected) {
+			t.Errorf("Expected '%s' for %s, got %v", expected, move, err)
+		}
+	}
+
+	if err := game.CheckMove(Coord{X: 7, Y: 7}, "white"); err != nil {
+		t.Errorf("Expected white to be allowed a double three, got %s", err)
+	}
+}
+
+func TestPlayTurnWin(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "pass", "2 2", "8 10", "3 3", "8 11", "4 4", "8 12"})
+
+	if !game.IsOver || game.Result.Kind != WON || game.Result.WinnerID != "mock_user2" {
+		t.Errorf("Expected mock_user2 to win, got %v", game.Result)
+	}
+
+	expectedLine := []Coord{Coord{X: 8, Y: 8}, Coord{X: 8, Y: 9}, Coord{X: 8, Y: 10}, Coord{X: 8, Y: 11}, Coord{X: 8, Y: 12}}
+	if !coordSlicesAreEqual(&game.WinningLine, &expectedLine) {
+		t.Errorf("Expected the winning line %v, got %v", expectedLine, game.WinningLine)
+	}
+
+	if lastMove := game.LastMove(); len(lastMove) != 1 || lastMove[0] != (Coord{X: 8, Y: 12}) {
+		t.Errorf("Expected the last move to be 8 12, got %v", lastMove)
+	}
+
+	if game.PlayerToMove() != "" {
+		t.Error("Expected no one to move once the game is over")
+	}
+
+	if _, err := game.PlayTurn("mock_user1", "5 5", time.Now()); err == nil {
+		t.Error("Expected no more turns to be allowed")
+	}
+}
+
+func BenchmarkHasLegalMove(b *testing.B) {
+	game := NewGame(Settings{Size: 15, WinLength: 5, RuleSet: RENJU, Opening: SWAP})
+	game.AddPlayer("mock_user1")
+	game.AddPlayer("mock_user2")
+	game.AssignColors("mock_user1")
+	game.Opening.SetStage(SwapDone)
+	game.Board = boardWithStones(benchmarkBlack, benchmarkWhite)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		game.HasLegalMove("mock_user1")
+	}
+}
+
+func TestTakeBackReplayFails(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2", "3 3", "4 4"})
+
+	// a turn that no longer replays leaves the game as it was
+	game.History[1].Data = "99 99"
+	_, err := game.TakeBack("mock_user1", time.Now())
+	if err == nil {
+		t.Fatal("Expected the takeback to fail")
+	}
+
+	if game.Turn != 5 || len(game.History) != 4 || len(game.Moves) != 6 {
+		t.Errorf("Expected the game to be unchanged, got turn %d with %d turns and %d moves", game.Turn, len(game.History), len(game.Moves))
+	}
+	if game.Board.IsTakenBy(Coord{X: 4, Y: 4}) != "white" || game.Colors["mock_user1"] != "black" {
+		t.Error("Expected the board and colors to be unchanged")
+	}
+}
@@ -0,0 +1,308 @@
+package gomoku
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// opening protocol names
+const (
+	SWAP    = "swap"
+	SWAP2   = "swap2"
+	PRO     = "pro"
+	LONGPRO = "longpro"
+)
+
+// Opening is a state machine that drives the first turns of a game, until Done
+// reports that regular play has begun
+type Opening interface {
+	Name() string
+	Done() bool
+	// Instructions explain the current stage to the player whose turn it is
+	Instructions() string
+	// Play applies a player's turn, returning a message describing it
+	Play(*Game, string, string) (string, error)
+	// Stage and SetStage let a saved game resume its opening where it left off
+	Stage() int
+	SetStage(int)
+}
+
+// assert that openings implement Opening
+var _ Opening = (*SwapOpening)(nil)
+var _ Opening = (*Swap2Opening)(nil)
+var _ Opening = (*ProOpening)(nil)
+
+// IsOpeningName checks whether name refers to a known opening protocol
+func IsOpeningName(name string) bool {
+	switch name {
+	case SWAP, SWAP2, PRO, LONGPRO:
+		return true
+	}
+	return false
+}
+
+// NewOpening creates a fresh opening state machine for a game's settings
+func NewOpening(settings Settings) Opening {
+	switch settings.Opening {
+	case SWAP2:
+		return &Swap2Opening{}
+	case PRO:
+		return &ProOpening{name: PRO, size: settings.Size, radius: 2}
+	case LONGPRO:
+		return &ProOpening{name: LONGPRO, size: settings.Size, radius: 3}
+	}
+	return &SwapOpening{}
+}
+
+// stages of the Swap opening
+const (
+	SwapPlaceThree = iota
+	SwapChooseColor
+	SwapDone
+)
+
+// SwapOpening: the first player places two black stones and one white, then the
+// second player either plays white or passes to take black
+type SwapOpening struct {
+	stage int
+}
+
+func (opening *SwapOpening) Name() string {
+	return SWAP
+}
+
+func (opening *SwapOpening) Done() bool {
+	return opening.stage == SwapDone
+}
+
+func (opening *SwapOpening) Stage() int {
+	return opening.stage
+}
+
+func (opening *SwapOpening) SetStage(stage int) {
+	opening.stage = stage
+}
+
+func (opening *SwapOpening) Instructions() string {
+	switch opening.stage {
+	case SwapPlaceThree:
+		return "You go first! Begin by placing two black pieces and then one white. Ex: 'mv 8 8, 8 7, 6 6'"
+	case SwapChooseColor:
+		return "If you want to play white, play a move as normal. Otherwise, type 'mv pass'."
+	}
+	return ""
+}
+
+func (opening *SwapOpening) Play(game *Game, userID string, data string) (string, error) {
+	switch opening.stage {
+	case SwapPlaceThree:
+		message, err := playFirstThree(game, data)
+		if err != nil {
+			return "", err
+		}
+		opening.stage = SwapChooseColor
+		return message, nil
+	case SwapChooseColor:
+		message, err := chooseColor(game, userID, data)
+		if err != nil {
+			return "", err
+		}
+		opening.stage = SwapDone
+		return message, nil
+	}
+	return "", errors.New("The opening is already over")
+}
+
+// stages of the Swap2 opening
+const (
+	Swap2PlaceThree = iota
+	Swap2ChooseOrPlaceTwo
+	Swap2FirstPlayerChooses
+	Swap2Done
+)
+
+// Swap2Opening: like Swap, except that the second player may instead place one more
+// black and one more white stone and hand the colour choice back to the first player
+type Swap2Opening struct {
+	stage int
+}
+
+func (opening *Swap2Opening) Name() string {
+	return SWAP2
+}
+
+func (opening *Swap2Opening) Done() bool {
+	return opening.stage == Swap2Done
+}
+
+func (opening *Swap2Opening) Stage() int {
+	return opening.stage
+}
+
+func (opening *Swap2Opening) SetStage(stage int) {
+	opening.stage = stage
+}
+
+func (opening *Swap2Opening) Instructions() string {
+	switch opening.stage {
+	case Swap2PlaceThree:
+		return "You go first! Begin by placing two black pieces and then one white. Ex: 'mv 8 8, 8 7, 6 6'"
+	case Swap2ChooseOrPlaceTwo:
+		return "To play white, play a move as normal. To play black, type 'mv pass'. Or place one more black and one more white piece to let your opponent choose. Ex: 'mv 9 9, 9 10'"
+	case Swap2FirstPlayerChooses:
+		return "Your opponent placed two more pieces, so you choose! To play white, play a move as normal. To play black, type 'mv pass'."
+	}
+	return ""
+}
+
+func (opening *Swap2Opening) Play(game *Game, userID string, data string) (string, error) {
+	switch opening.stage {
+	case Swap2PlaceThree:
+		message, err := playFirstThree(game, data)
+		if err != nil {
+			return "", err
+		}
+		opening.stage = Swap2ChooseOrPlaceTwo
+		return message, nil
+	case Swap2ChooseOrPlaceTwo:
+		if strings.Contains(data, ",") {
+			moves, err := game.ParseMoves(data, 2)
+			if err != nil {
+				return "", err
+			}
+			err = game.placeUnownedStones(moves, []string{"black", "white"})
+			if err != nil {
+				return "", err
+			}
+			opening.stage = Swap2FirstPlayerChooses
+			return "(played black on " + moves[0].String() + " and white on " + moves[1].String() + " -- back to player 1 to choose a color)", nil
+		}
+		fallthrough
+	case Swap2FirstPlayerChooses:
+		message, err := chooseColor(game, userID, data)
+		if err != nil {
+			return "", err
+		}
+		opening.stage = Swap2Done
+		return message, nil
+	}
+	return "", errors.New("The opening is already over")
+}
+
+// stages of the Pro and Long Pro openings
+const (
+	ProCenter = iota
+	ProSecondMove
+	ProThirdMove
+	ProDone
+)
+
+// ProOpening: the first player is black and must play in the centre, and their second
+// stone must be placed outside the central square. Pro uses a 5x5 square and Long Pro
+// a 7x7 one.
+type ProOpening struct {
+	name   string
+	size   int
+	radius int
+	stage  int
+}
+
+func (opening *ProOpening) Name() string {
+	return opening.name
+}
+
+func (opening *ProOpening) Done() bool {
+	return opening.stage == ProDone
+}
+
+func (opening *ProOpening) center() Coord {
+	middle := opening.size/2 + 1
+	return Coord{X: middle, Y: middle}
+}
+
+func (opening *ProOpening) squareName() string {
+	width := strconv.Itoa(2*opening.radius + 1)
+	return width + "x" + width
+}
+
+func (opening *ProOpening) Stage() int {
+	return opening.stage
+}
+
+func (opening *ProOpening) SetStage(stage int) {
+	opening.stage = stage
+}
+
+func (opening *ProOpening) Instructions() string {
+	switch opening.stage {
+	case ProCenter:
+		return "You go first and play black! Place your first piece in the centre. Ex: 'mv " + opening.center().String() + "'"
+	case ProSecondMove:
+		return "You play white! Play a move as normal."
+	case ProThirdMove:
+		return "Your second piece must be placed outside the central " + opening.squareName() + " square."
+	}
+	return ""
+}
+
+func (opening *ProOpening) Play(game *Game, userID string, data string) (string, error) {
+	if opening.stage == ProDone {
+		return "", errors.New("The opening is already over")
+	}
+
+	move, err := game.ParseMove(data)
+	if err != nil {
+		return "", err
+	}
+
+	center := opening.center()
+	switch opening.stage {
+	case ProCenter:
+		if move != center {
+			return "", errors.New("The first piece must be placed in the centre, on " + center.String())
+		}
+		game.AssignColors(userID)
+	case ProThirdMove:
+		dx := move.X - center.X
+		dy := move.Y - center.Y
+		if dx >= -opening.radius && dx <= opening.radius && dy >= -opening.radius && dy <= opening.radius {
+			return "", errors.New("This piece must be placed outside the central " + opening.squareName() + " square")
+		}
+	}
+
+	game.placeOpeningStone(move, game.Colors[userID])
+	opening.stage++
+	return "(played on " + move.String() + " )", nil
+}
+
+// playFirstThree places the two black stones and one white stone that start Swap and Swap2
+func playFirstThree(game *Game, data string) (string, error) {
+	moves, err := game.ParseMoves(data, 3)
+	if err != nil {
+		return "", err
+	}
+
+	err = game.placeUnownedStones(moves, []string{"black", "black", "white"})
+	if err != nil {
+		return "", err
+	}
+	return "(played black on " + moves[0].String() + ", black on " + moves[1].String() + ", and white on " + moves[2].String() + " )", nil
+}
+
+// chooseColor lets a player pass to take black, or play a white stone to take white
+func chooseColor(game *Game, userID string, data string) (string, error) {
+	if data == "pass" {
+		game.AssignColors(userID)
+		return "(passed and is now black -- back to the other player)", nil
+	}
+
+	move, err := game.ParseMove(data)
+	if err != nil {
+		return "", err
+	}
+
+	game.AssignColors(game.Opponent(userID))
+	game.placeOpeningStone(move, "white")
+	return "(played on " + move.String() + " )", nil
+}
@@ -0,0 +1,207 @@
+package gomoku
+
+import (
+	"testing"
+	"time"
+)
+
+func playOpeningTurns(t *testing.T, game *Game, turns [][2]string) {
+	for _, turn := range turns {
+		_, err := game.Opening.Play(game, turn[0], turn[1])
+		if err != nil {
+			t.Fatalf("Expected '%s' to be valid for %s, got error: %s", turn[1], turn[0], err)
+		}
+	}
+}
+
+func TestOpeningSwapPass(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "1 1, 1 2, 1 3"},
+		{"mock_user2", "pass"},
+	})
+
+	if !game.Opening.Done() {
+		t.Error("Expected opening to be done")
+	}
+	if game.Colors["mock_user2"] != "black" {
+		t.Errorf("Expected second player to be black, got %s", game.Colors["mock_user2"])
+	}
+	if game.Colors["mock_user1"] != "white" {
+		t.Errorf("Expected first player to be white, got %s", game.Colors["mock_user1"])
+	}
+}
+
+func TestOpeningSwapInvalid(t *testing.T) {
+	game := newTestGame(DefaultSettings(), time.Now())
+	invalidMoves := map[string]string{
+		"1 1, 1 2":       "Please choose exactly 3 sets of two values",
+		"1 1, 1 1, 1 2":  "You can't place two pieces on the same spot!",
+		"1 1, 1 2, 16 1": "Both x and y must be integers from 1 to 15",
+	}
+
+	for move, expectedMessage := range invalidMoves {
+		_, err := game.Opening.Play(game, "mock_user1", move)
+		if err == nil || err.Error() != expectedMessage {
+			t.Errorf("Expected error %s for '%s', got %v", expectedMessage, move, err)
+		}
+	}
+
+	if len(game.Board.ListSpaces("black")) != 0 {
+		t.Error("Expected invalid moves to leave the board empty")
+	}
+}
+
+func TestOpeningSwap2PlaceTwo(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Opening = SWAP2
+	game := newTestGame(settings, time.Now())
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "1 1, 1 2, 1 3"},
+		{"mock_user2", "9 9, 9 10"},
+	})
+
+	if game.Opening.Done() {
+		t.Fatal("Expected first player to still have to choose a color")
+	}
+	if game.PlayerColors() != nil {
+		t.Error("Expected colors to not be chosen yet")
+	}
+
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "pass"},
+	})
+
+	if !game.Opening.Done() {
+		t.Error("Expected opening to be done")
+	}
+	if game.Colors["mock_user1"] != "black" {
+		t.Errorf("Expected first player to be black, got %s", game.Colors["mock_user1"])
+	}
+
+	expectedBlackSpaces := []string{"1 1", "1 2", "9 9"}
+	boardSpacesBlack := game.Board.ListSpaces("black")
+	if len(boardSpacesBlack) != len(expectedBlackSpaces) {
+		t.Fatalf("Expected black spaces %s, got %s", expectedBlackSpaces, boardSpacesBlack)
+	}
+	for i, space := range boardSpacesBlack {
+		if space != expectedBlackSpaces[i] {
+			t.Fatalf("Expected black spaces %s, got %s", expectedBlackSpaces, boardSpacesBlack)
+		}
+	}
+}
+
+func TestOpeningSwap2PlayWhite(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Opening = SWAP2
+	game := newTestGame(settings, time.Now())
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "1 1, 1 2, 1 3"},
+		{"mock_user2", "9 9"},
+	})
+
+	if !game.Opening.Done() {
+		t.Error("Expected opening to be done")
+	}
+	if game.Colors["mock_user2"] != "white" {
+		t.Errorf("Expected second player to be white, got %s", game.Colors["mock_user2"])
+	}
+}
+
+func TestOpeningPro(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Opening = PRO
+	game := newTestGame(settings, time.Now())
+
+	_, err := game.Opening.Play(game, "mock_user1", "7 7")
+	if err == nil {
+		t.Error("Expected first move off the centre to be rejected")
+	}
+
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "8 8"},
+		{"mock_user2", "8 9"},
+	})
+
+	if game.Colors["mock_user1"] != "black" {
+		t.Errorf("Expected first player to be black, got %s", game.Colors["mock_user1"])
+	}
+
+	_, err = game.Opening.Play(game, "mock_user1", "10 10")
+	if err == nil || err.Error() != "This piece must be placed outside the central 5x5 square" {
+		t.Errorf("Expected third move inside the central square to be rejected, got %v", err)
+	}
+
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "11 8"},
+	})
+
+	if !game.Opening.Done() {
+		t.Error("Expected opening to be done")
+	}
+}
+
+func TestOpeningLongPro(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Opening = LONGPRO
+	game := newTestGame(settings, time.Now())
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "8 8"},
+		{"mock_user2", "8 9"},
+	})
+
+	_, err := game.Opening.Play(game, "mock_user1", "11 8")
+	if err == nil || err.Error() != "This piece must be placed outside the central 7x7 square" {
+		t.Errorf("Expected third move inside the central square to be rejected, got %v", err)
+	}
+
+	playOpeningTurns(t, game, [][2]string{
+		{"mock_user1", "12 8"},
+	})
+
+	if !game.Opening.Done() {
+		t.Error("Expected opening to be done")
+	}
+}
+
+func TestOpeningStoneCompletesLine(t *testing.T) {
+	settings := DefaultSettings()
+	settings.Size = 7
+	settings.WinLength = 3
+	settings.Opening = SWAP2
+	game := newTestGame(settings, time.Now())
+
+	now := time.Now()
+	_, err := game.PlayTurn("mock_user1", "1 1, 1 2, 5 1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody owns a line completed before colors are chosen, so the stones are refused
+	if _, err := game.PlayTurn("mock_user2", "1 3, 6 6", now); err == nil {
+		t.Fatal("Expected a black stone completing three in a row before colors are chosen to be refused")
+	}
+	if game.Turn != 2 || game.Board.IsTakenBy(Coord{X: 1, Y: 3}) != FREE || game.Board.IsTakenBy(Coord{X: 6, Y: 6}) != FREE {
+		t.Fatal("Expected the refused stones to leave the game untouched")
+	}
+
+	if _, err := game.PlayTurn("mock_user2", "3 7, 5 2", now); err != nil {
+		t.Fatal(err)
+	}
+
+	// taking white with a stone that completes white's line wins for whoever plays white
+	message, err := game.PlayTurn("mock_user1", "5 3", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !game.IsOver || game.Result.Kind != WON || game.Result.WinnerID != "mock_user1" || game.Colors["mock_user1"] != "white" {
+		t.Fatalf("Expected white's three in a row to win for mock_user1, got %+v", game.Result)
+	}
+	if message != "won!!!! (5 3 )" {
+		t.Errorf("Expected a winning message, got %s", message)
+	}
+	if len(game.WinningLine) != 3 {
+		t.Errorf("Expected a winning line of 3 stones, got %v", game.WinningLine)
+	}
+}
@@ -0,0 +1,18 @@
+package gomoku
+
+// ways a game can end
+const (
+	WON      = "won"
+	RESIGNED = "resigned"
+	DRAWN    = "drawn"
+	TIMEDOUT = "timed out"
+	// FORFEITED games were lost by a player who didn't show up to play them
+	FORFEITED = "forfeited"
+)
+
+// Result is how a game ended. Kind is empty while the game is in progress, and WinnerID
+// is empty for draws.
+type Result struct {
+	Kind     string
+	WinnerID string
+}
@@ -0,0 +1,270 @@
+package gomoku
+
+import "strconv"
+
+// rule set names
+const (
+	FREESTYLE = "freestyle"
+	STANDARD  = "standard"
+	CARO      = "caro"
+	RENJU     = "renju"
+)
+
+// RuleSet decides which lines win and which moves are allowed
+type RuleSet interface {
+	Name() string
+	// Description explains the rules for a game won by winLength in a row
+	Description(winLength int) string
+	// WinningLine returns the stones of the line that a stone of color just placed on move
+	// wins with, or nil if it doesn't win
+	WinningLine(*Board, Coord, string) []Coord
+	// ForbiddenReason explains why color may not play move, or returns "" if it may
+	ForbiddenReason(*Board, Coord, string) string
+}
+
+// assert that rule sets implement RuleSet
+var _ RuleSet = (*FreestyleRules)(nil)
+var _ RuleSet = (*StandardRules)(nil)
+var _ RuleSet = (*CaroRules)(nil)
+var _ RuleSet = (*RenjuRules)(nil)
+
+// RuleSetByName returns the rule set with the given name, or nil if there is none
+func RuleSetByName(name string) RuleSet {
+	switch name {
+	case FREESTYLE:
+		return FreestyleRules{}
+	case STANDARD:
+		return StandardRules{}
+	case CARO:
+		return CaroRules{}
+	case RENJU:
+		return RenjuRules{}
+	}
+	return nil
+}
+
+// FreestyleRules: a line of the win length or longer wins
+type FreestyleRules struct{}
+
+func (rules FreestyleRules) Name() string {
+	return FREESTYLE
+}
+
+func (rules FreestyleRules) Description(winLength int) string {
+	return "Freestyle: a line of " + strconv.Itoa(winLength) + " or more wins"
+}
+
+func (rules FreestyleRules) WinningLine(board *Board, move Coord, color string) []Coord {
+	for _, axis := range Axes {
+		if board.LineLength(color, axis, move) >= board.WinLength {
+			return board.Line(color, axis, move)
+		}
+	}
+	return nil
+}
+
+func (rules FreestyleRules) ForbiddenReason(board *Board, move Coord, color string) string {
+	return ""
+}
+
+// StandardRules: only a line of exactly the win length wins; overlines don't count
+type StandardRules struct{}
+
+func (rules StandardRules) Name() string {
+	return STANDARD
+}
+
+func (rules StandardRules) Description(winLength int) string {
+	return "Standard: exactly " + strconv.Itoa(winLength) + " in a row wins, overlines don't count"
+}
+
+func (rules StandardRules) WinningLine(board *Board, move Coord, color string) []Coord {
+	for _, axis := range Axes {
+		if board.LineLength(color, axis, move) == board.WinLength {
+			return board.Line(color, axis, move)
+		}
+	}
+	return nil
+}
+
+func (rules StandardRules) ForbiddenReason(board *Board, move Coord, color string) string {
+	return ""
+}
+
+// CaroRules: a line of the win length or longer wins, unless the opponent has blocked both ends
+type CaroRules struct{}
+
+func (rules CaroRules) Name() string {
+	return CARO
+}
+
+func (rules CaroRules) Description(winLength int) string {
+	return "Caro: " + strconv.Itoa(winLength) + " or more in a row wins, unless both ends are blocked by the opponent"
+}
+
+func (rules CaroRules) WinningLine(board *Board, move Coord, color string) []Coord {
+	opponent := OpponentColor(color)
+	for _, axis := range Axes {
+		if board.LineLength(color, axis, move) < board.WinLength {
+			continue
+		}
+
+		end1, end2 := board.LineEnds(color, axis, move)
+		if board.IsTakenBy(end1) != opponent || board.IsTakenBy(end2) != opponent {
+			return board.Line(color, axis, move)
+		}
+	}
+	return nil
+}
+
+func (rules CaroRules) ForbiddenReason(board *Board, move Coord, color string) string {
+	return ""
+}
+
+// RenjuRules: black must make exactly five and may not play double threes, double fours or
+// overlines; white wins with five or more and has no restrictions
+type RenjuRules struct{}
+
+func (rules RenjuRules) Name() string {
+	return RENJU
+}
+
+func (rules RenjuRules) Description(winLength int) string {
+	return "Renju: black must make exactly " + strconv.Itoa(winLength) + " and may not play a double three, double four or overline"
+}
+
+func (rules RenjuRules) WinningLine(board *Board, move Coord, color string) []Coord {
+	if color == "black" {
+		return StandardRules{}.WinningLine(board, move, color)
+	}
+	return FreestyleRules{}.WinningLine(board, move, color)
+}
+
+// ForbiddenReason explains why black may not play move, or returns "" if it is allowed.
+// A three is counted when one more stone can turn it into a straight four; whether that
+// stone would itself be forbidden is not considered.
+func (rules RenjuRules) ForbiddenReason(board *Board, move Coord, color string) string {
+	if color != "black" {
+		return ""
+	}
+
+	board.Place(move, color)
+	defer board.Remove(move)
+
+	// making exactly five always wins, whatever else it makes
+	for _, axis := range Axes {
+		if board.LineLength(color, axis, move) == board.WinLength {
+			return ""
+		}
+	}
+
+	fours := 0
+	threes := 0
+	for _, axis := range Axes {
+		if board.LineLength(color, axis, move) > board.WinLength {
+			return "overline"
+		}
+
+		axisFours := board.countFours(color, axis, move)
+		if axisFours > 0 {
+			fours += axisFours
+		} else if board.hasOpenThree(color, axis, move) {
+			threes++
+		}
+	}
+
+	if fours >= 2 {
+		return "double four"
+	}
+
+	if threes >= 2 {
+		return "double three"
+	}
+
+	return ""
+}
+
+// completionPoints lists the empty points along an axis that would extend the line
+// through move to exactly the win length
+func (board *Board) completionPoints(color string, axis [2]int, move Coord) []Coord {
+	points := []Coord{}
+	for _, direction := range [2]int{-1, 1} {
+		for step := 1; step < board.WinLength; step++ {
+			point := Coord{
+				X: move.X + axis[0]*step*direction,
+				Y: move.Y + axis[1]*step*direction,
+			}
+
+			if !board.IsOnBoard(point) {
+				break
+			}
+
+			taken := board.IsTakenBy(point)
+			if taken == color {
+				continue
+			}
+			if taken != FREE {
+				break
+			}
+
+			// only the first empty point in each direction can join the line
+			board.Place(point, color)
+			if board.LineLength(color, axis, move) == board.WinLength {
+				points = append(points, point)
+			}
+			board.Remove(point)
+			break
+		}
+	}
+	return points
+}
+
+// countFours counts the fours along an axis through move; an open four counts once
+func (board *Board) countFours(color string, axis [2]int, move Coord) int {
+	points := board.completionPoints(color, axis, move)
+	if len(points) == 2 {
+		distance := points[0].X - points[1].X
+		if distance == 0 {
+			distance = points[0].Y - points[1].Y
+		}
+		if distance == board.WinLength || distance == -board.WinLength {
+			return 1
+		}
+	}
+	return len(points)
+}
+
+// hasOpenThree checks whether one more stone along an axis makes a straight four through move
+func (board *Board) hasOpenThree(color string, axis [2]int, move Coord) bool {
+	for _, direction := range [2]int{-1, 1} {
+		for step := 1; step < board.WinLength-1; step++ {
+			point := Coord{
+				X: move.X + axis[0]*step*direction,
+				Y: move.Y + axis[1]*step*direction,
+			}
+
+			if !board.IsOnBoard(point) {
+				break
+			}
+
+			taken := board.IsTakenBy(point)
+			if taken == color {
+				continue
+			}
+			if taken != FREE {
+				break
+			}
+
+			board.Place(point, color)
+			straightFour := board.LineLength(color, axis, move) == board.WinLength-1 &&
+				len(board.completionPoints(color, axis, move)) == 2
+			board.Remove(point)
+
+			if straightFour {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package gomoku
+
+import (
+	"testing"
+)
+
+func boardWithStones(black []string, white []string) Board {
+	gameBoard := NewBoard()
+	for _, coordString := range black {
+		coord, _ := ParseCoord(coordString)
+		gameBoard.Place(coord, "black")
+	}
+	for _, coordString := range white {
+		coord, _ := ParseCoord(coordString)
+		gameBoard.Place(coord, "white")
+	}
+	return gameBoard
+}
+
+func TestRulesOverline(t *testing.T) {
+	gameBoard := boardWithStones([]string{"7 1", "7 2", "7 3", "7 4", "7 5", "7 6"}, []string{})
+	move := Coord{X: 7, Y: 6}
+
+	if (FreestyleRules{}).WinningLine(&gameBoard, move, "black") == nil {
+		t.Error("Expected an overline to win under freestyle rules")
+	}
+	if (StandardRules{}).WinningLine(&gameBoard, move, "black") != nil {
+		t.Error("Expected an overline not to win under standard rules")
+	}
+	if (RenjuRules{}).WinningLine(&gameBoard, move, "black") != nil {
+		t.Error("Expected an overline not to win for black under renju rules")
+	}
+	if (CaroRules{}).WinningLine(&gameBoard, move, "black") == nil {
+		t.Error("Expected an overline to win under caro rules")
+	}
+}
+
+func TestRulesRenjuWhiteOverline(t *testing.T) {
+	gameBoard := boardWithStones([]string{}, []string{"7 1", "7 2", "7 3", "7 4", "7 5", "7 6"})
+	move := Coord{X: 7, Y: 6}
+
+	if (RenjuRules{}).WinningLine(&gameBoard, move, "white") == nil {
+		t.Error("Expected an overline to win for white under renju rules")
+	}
+}
+
+func TestRulesDescriptionWinLength(t *testing.T) {
+	if description := (StandardRules{}).Description(4); description != "Standard: exactly 4 in a row wins, overlines don't count" {
+		t.Errorf("Expected the description to use the win length, got %s", description)
+	}
+}
+
+func TestRulesCaroBlockedFive(t *testing.T) {
+	gameBoard := boardWithStones([]string{"7 2", "7 3", "7 4", "7 5", "7 6"}, []string{"7 1", "7 7"})
+	move := Coord{X: 7, Y: 6}
+
+	if (CaroRules{}).WinningLine(&gameBoard, move, "black") != nil {
+		t.Error("Expected a five blocked on both ends not to win under caro rules")
+	}
+	if (StandardRules{}).WinningLine(&gameBoard, move, "black") == nil {
+		t.Error("Expected a five blocked on both ends to win under standard rules")
+	}
+
+	gameBoard.Remove(Coord{X: 7, Y: 7})
+	if (CaroRules{}).WinningLine(&gameBoard, move, "black") == nil {
+		t.Error("Expected a five blocked on one end to win under caro rules")
+	}
+}
+
+type RenjuForbiddenTestCase struct {
+	label          string
+	black          []string
+	white          []string
+	move           Coord
+	expectedReason string
+}
+
+func TestRulesRenjuForbidden(t *testing.T) {
+	testcases := []RenjuForbiddenTestCase{
+		RenjuForbiddenTestCase{
+			"double_three",
+			[]string{"7 8", "7 9", "8 7", "9 7"},
+			[]string{},
+			Coord{X: 7, Y: 7},
+			"double three",
+		},
+		RenjuForbiddenTestCase{
+			"double_four",
+			[]string{"7 8", "7 9", "7 10", "8 7", "9 7", "10 7"},
+			[]string{},
+			Coord{X: 7, Y: 7},
+			"double four",
+		},
+		RenjuForbiddenTestCase{
+			"double_four_one_line",
+			[]string{"7 3", "7 5", "7 6", "7 9"},
+			[]string{},
+			Coord{X: 7, Y: 7},
+			"double four",
+		},
+		RenjuForbiddenTestCase{
+			"overline",
+			[]string{"7 1", "7 2", "7 3", "7 5", "7 6"},
+			[]string{},
+			Coord{X: 7, Y: 4},
+			"overline",
+		},
+		RenjuForbiddenTestCase{
+			"five_beats_double_three",
+			[]string{"7 3", "7 4", "7 5", "7 6", "8 6", "9 6", "8 8", "9 9"},
+			[]string{},
+			Coord{X: 7, Y: 7},
+			"",
+		},
+		RenjuForbiddenTestCase{
+			"single_three",
+			[]string{"7 8", "7 9"},
+			[]string{},
+			Coord{X: 7, Y: 7},
+			"",
+		},
+		RenjuForbiddenTestCase{
+			"blocked_three",
+			[]string{"7 8", "7 9", "8 7", "9 7"},
+			[]string{"7 6", "7 10"},
+			Coord{X: 7, Y: 7},
+			"",
+		},
+		RenjuForbiddenTestCase{
+			"four_three",
+			[]string{"7 8", "7 9", "7 10", "8 7", "9 7"},
+			[]string{},
+			Coord{X: 7, Y: 7},
+			"",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.label, func(t *testing.T) {
+			gameBoard := boardWithStones(testcase.black, testcase.white)
+			reason := (RenjuRules{}).ForbiddenReason(&gameBoard, testcase.move, "black")
+			if reason != testcase.expectedReason {
+				t.Errorf("Expected reason to be '%s', got '%s'", testcase.expectedReason, reason)
+			}
+
+			if gameBoard.IsTakenBy(testcase.move) != FREE {
+				t.Error("Expected forbidden check to leave the board unchanged")
+			}
+
+			whiteReason := (RenjuRules{}).ForbiddenReason(&gameBoard, testcase.move, "white")
+			if whiteReason != "" {
+				t.Errorf("Expected white to have no forbidden moves, got '%s'", whiteReason)
+			}
+		})
+	}
+}
+
+func BenchmarkRenjuForbiddenReason(b *testing.B) {
+	gameBoard := boardWithStones(benchmarkBlack, benchmarkWhite)
+	move := Coord{X: 8, Y: 8}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RenjuRules{}.ForbiddenReason(&gameBoard, move, "black")
+	}
+}
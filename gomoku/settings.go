@@ -0,0 +1,68 @@
+package gomoku
+
+import (
+	"errors"
+	"strconv"
+)
+
+// limits on the board and the line needed to win
+const (
+	DefaultBoardSize = 15
+	DefaultWinLength = 5
+	MinBoardSize     = 5
+	MaxBoardSize     = 25
+	MinWinLength     = 3
+)
+
+// Settings are the rules a game is played with
+type Settings struct {
+	Size      int
+	WinLength int
+	RuleSet   string
+	Opening   string
+	// TimeControl is the zero value for untimed games
+	TimeControl TimeControl
+}
+
+// DefaultSettings returns the standard rules on a 15x15 board with the Swap opening
+func DefaultSettings() Settings {
+	return Settings{
+		Size:      DefaultBoardSize,
+		WinLength: DefaultWinLength,
+		RuleSet:   STANDARD,
+		Opening:   SWAP,
+	}
+}
+
+// Validate checks that a game can be played with the settings
+func (settings Settings) Validate() error {
+	if settings.Size < MinBoardSize || settings.Size > MaxBoardSize {
+		return errors.New("Board size must be between " + strconv.Itoa(MinBoardSize) + " and " + strconv.Itoa(MaxBoardSize))
+	}
+
+	if settings.WinLength < MinWinLength || settings.WinLength > settings.Size {
+		return errors.New("Win length must be between " + strconv.Itoa(MinWinLength) + " and the board size")
+	}
+
+	if RuleSetByName(settings.RuleSet) == nil {
+		return errors.New("Unrecognized rule set: " + settings.RuleSet)
+	}
+
+	if !IsOpeningName(settings.Opening) {
+		return errors.New("Unrecognized opening: " + settings.Opening)
+	}
+
+	if settings.RuleSet == RENJU && settings.WinLength != DefaultWinLength {
+		return errors.New("Renju rules can only be played with five in a row")
+	}
+
+	if settings.Opening == PRO && settings.Size < 7 {
+		return errors.New("The pro opening needs a board of at least 7x7")
+	}
+
+	if settings.Opening == LONGPRO && settings.Size < 9 {
+		return errors.New("The long pro opening needs a board of at least 9x9")
+	}
+
+	return nil
+}
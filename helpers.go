@@ -3,37 +3,10 @@ package main
 import (
 	"bytes"
 	"encoding/gob"
-	"fmt"
-	"os"
-	"os/exec"
-	"runtime"
+	"errors"
 )
 
-func clearWindows() {
-	cmd := exec.Command("cmd", "/c", "cls")
-	cmd.Stdout = os.Stdout
-	cmd.Run()
-}
-
-func clearLinuxOrDarwin() {
-	cmd := exec.Command("clear")
-	cmd.Stdout = os.Stdout
-	cmd.Run()
-}
-
-func clearScreen() {
-	switch runtime.GOOS {
-	case "linux":
-	case "darwin":
-		clearLinuxOrDarwin()
-	case "windows":
-		clearWindows()
-	default:
-		panic("Your platform is unsupported! Can't clear the terminal screen.")
-	}
-}
-
-func decodeGob(message []byte) Request {
+func decodeGob(message []byte) (Request, error) {
 	var network bytes.Buffer
 	network.Write(message)
 	var request Request
@@ -43,10 +16,10 @@ func decodeGob(message []byte) Request {
 	err := dec.Decode(&request)
 
 	if err != nil {
-		fmt.Println("Error decoding GOB data:", err)
+		return request, errors.New("Error decoding GOB data: " + err.Error())
 	}
 
-	return request
+	return request, nil
 }
 
 func gobToBytes(key interface{}) ([]byte, error) {
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// the players of a local game, who take turns at the same terminal
+const (
+	localPlayer1 = "Player 1"
+	localPlayer2 = "Player 2"
+)
+
+// LocalGame stands in for the server in hot-seat games, answering the client's requests
+// with an in-process engine. Every request is made by the player whose turn it is.
+type LocalGame struct {
+	game *GameRoom
+}
+
+// handleRequest plays a request from the client and returns the responses the server
+// would have sent
+func (local *LocalGame) handleRequest(req Request) []Request {
+	errorResponse := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  req.Action,
+		Success: false,
+	}
+
+	switch req.Action {
+	case CREATE:
+		return local.handleCreate(req)
+	case HOME:
+		local.game = nil
+		return []Request{Request{Action: HOME, Success: true}}
+	}
+
+	game := local.game
+	if game == nil {
+		errorResponse.Data = "You're not in a game yet!"
+		return []Request{errorResponse}
+	}
+
+	// whoever is at the keyboard is playing the side to move
+	req.UserID = game.PlayerToMove()
+
+	switch req.Action {
+	case MOVE:
+		return local.handleMove(req)
+	case RESIGN, DRAW:
+		if game.IsOver {
+			errorResponse.Data = "The game is over!"
+			return []Request{errorResponse}
+		}
+
+		data := "agreed to a draw"
+		result := gomoku.Result{Kind: gomoku.DRAWN}
+		if req.Action == RESIGN {
+			data = "resigned"
+			result = gomoku.Result{Kind: gomoku.RESIGNED, WinnerID: GetOpponentID(game, req.UserID)}
+		}
+		game.end(result, time.Now())
+		return []Request{game.gameOverResponse(req, data)}
+	case TAKEBACK:
+		return local.handleTakeback(req)
+	case REMATCH:
+		if !game.IsOver {
+			errorResponse.Data = "The game isn't over yet!"
+			return []Request{errorResponse}
+		}
+
+		game.rematch(time.Now())
+		return []Request{Request{
+			GameID:       game.ID,
+			UserID:       req.UserID,
+			Action:       REMATCH,
+			Success:      true,
+			YourTurn:     true,
+			Turn:         game.Turn,
+			Board:        game.Board.Spaces(),
+			Settings:     game.Settings,
+			Score:        game.Score,
+			Instructions: game.Opening.Instructions(),
+		}}
+	case EXPORT:
+		exported, err := exportRecord(game.record(), req.Data)
+		if err != nil {
+			errorResponse.Data = err.Error()
+			return []Request{errorResponse}
+		}
+		return []Request{Request{GameID: game.ID, Action: EXPORT, Success: true, Data: exported}}
+	}
+
+	errorResponse.Data = "You need a server to do that!"
+	return []Request{errorResponse}
+}
+
+// handleCreate starts a game between the two local players, with Player 1 going first
+func (local *LocalGame) handleCreate(req Request) []Request {
+	access, options, err := parseRoomAccess(req.Data)
+	if err == nil && access.Private {
+		err = errors.New("Private rooms need a server")
+	}
+	settings := GameSettings{}
+	if err == nil {
+		settings, err = parseGameSettings(options)
+	}
+	if err == nil && (settings.Bot != "" || settings.TimeControl.Kind != "" || settings.Rated) {
+		err = errors.New("Bots, time controls and rated games need a server")
+	}
+	if err != nil {
+		return []Request{Request{Action: CREATE, Success: false, Data: err.Error()}}
+	}
+
+	game := newGameRoom(0, settings)
+	game.addPlayer(&Player{UserID: localPlayer1})
+	game.addPlayer(&Player{UserID: localPlayer2})
+	game.Start(localPlayer1, time.Now())
+	local.game = game
+
+	return []Request{
+		Request{GameID: game.ID, Action: CREATE, Success: true, Settings: settings},
+		Request{
+			GameID:       game.ID,
+			UserID:       localPlayer2,
+			Action:       OTHERJOINED,
+			Success:      true,
+			YourTurn:     true,
+			Turn:         game.Turn,
+			Settings:     settings,
+			Instructions: game.Opening.Instructions(),
+		},
+	}
+}
+
+func (local *LocalGame) handleMove(req Request) []Request {
+	game := local.game
+
+	message, err := game.playTurn(req.UserID, req.Data, time.Now())
+	if err != nil {
+		return []Request{Request{GameID: game.ID, UserID: req.UserID, Action: MOVE, Success: false, Data: err.Error()}}
+	}
+
+	response := Request{
+		GameID:      game.ID,
+		UserID:      req.UserID,
+		Action:      MOVE,
+		Success:     true,
+		GameOver:    game.IsOver,
+		YourTurn:    !game.IsOver,
+		Data:        message,
+		Board:       game.Board.Spaces(),
+		Colors:      game.PlayerColors(),
+		LastMove:    game.LastMove(),
+		WinningLine: game.WinningLine,
+		Result:      game.Result,
+	}
+
+	if !game.IsOver {
+		response.Turn = game.Turn
+		response.Instructions = game.Opening.Instructions()
+	}
+
+	return []Request{response}
+}
+
+// handleTakeback takes back the last turn straight away, since both players are at the
+// keyboard to agree to it
+func (local *LocalGame) handleTakeback(req Request) []Request {
+	game := local.game
+
+	if game.IsOver || len(game.History) == 0 {
+		return []Request{Request{GameID: game.ID, UserID: req.UserID, Action: TAKEBACK, Success: false, Data: "There are no moves to take back"}}
+	}
+
+	takerID := game.History[len(game.History)-1].UserID
+	_, err := game.TakeBack(takerID, time.Now())
+	if err != nil {
+		return []Request{Request{GameID: game.ID, UserID: takerID, Action: TAKEBACK, Success: false, Data: err.Error()}}
+	}
+
+	return []Request{Request{
+		GameID:       game.ID,
+		UserID:       takerID,
+		Action:       TAKEBACK,
+		Success:      true,
+		YourTurn:     true,
+		Data:         "took back their last move",
+		Turn:         game.Turn,
+		Board:        game.Board.Spaces(),
+		Colors:       game.PlayerColors(),
+		LastMove:     game.LastMove(),
+		Instructions: game.Opening.Instructions(),
+	}}
+}
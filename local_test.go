@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"go_gomoku/gomoku"
+)
+
+func newLocalTestClient() *Client {
+	client := NewClient("GoGomoku")
+	client.disablePrint = true
+	client.local = &LocalGame{}
+	client.userID = localPlayer1
+	return client
+}
+
+func TestLocalGameToWin(t *testing.T) {
+	client := newLocalTestClient()
+
+	client.listenForInput(strings.NewReader(strings.Join([]string{
+		"mk",
+		"mv 8 8, 8 9, 1 1",
+		"mv 2 2",
+		"mv 8 10",
+		"mv 3 3",
+		"mv 8 11",
+		"mv 4 4",
+		"mv 8 12",
+	}, "\n")))
+
+	if !client.gameOver || client.result.Kind != gomoku.WON || client.result.WinnerID != localPlayer1 {
+		t.Fatalf("Expected Player 1 to win, got %v", client.result)
+	}
+
+	if client.board.IsTakenBy(gomoku.Coord{X: 8, Y: 12}) != "black" {
+		t.Error("Expected the client's board to show the winning move")
+	}
+
+	if len(client.board.winningLine) != 5 || client.board.stoneAt(gomoku.Coord{X: 8, Y: 8}) != "black"+winningMark {
+		t.Errorf("Expected the winning line to be marked, got %v", client.board.winningLine)
+	}
+
+	client.listenForInput(strings.NewReader("re\n"))
+
+	if client.gameOver || client.turn != 1 || len(client.board.ListSpaces("black")) != 0 {
+		t.Error("Expected a rematch to start on an empty board")
+	}
+
+	if client.local.game.FirstPlayerID != localPlayer2 || client.local.game.Score.Wins[localPlayer1] != 1 {
+		t.Errorf("Expected Player 2 to go first in the rematch, with Player 1 a game up")
+	}
+}
+
+func TestLocalGameTakebackAndResign(t *testing.T) {
+	client := newLocalTestClient()
+
+	client.listenForInput(strings.NewReader("mk 9x9\nmv 5 5, 5 6, 1 1\nmv 2 2\ntb\n"))
+
+	if client.turn != 2 || client.board.IsTakenBy(gomoku.Coord{X: 2, Y: 2}) != gomoku.FREE {
+		t.Errorf("Expected the takeback to go back to turn 2, got turn %d", client.turn)
+	}
+
+	if client.local.game.PlayerToMove() != localPlayer2 {
+		t.Error("Expected Player 2 to move again")
+	}
+
+	client.listenForInput(strings.NewReader("rs\ny\n"))
+
+	if !client.gameOver || client.result.Kind != gomoku.RESIGNED || client.result.WinnerID != localPlayer1 {
+		t.Errorf("Expected Player 2 to resign, got %v", client.result)
+	}
+}
+
+func TestLocalGameNeedsServer(t *testing.T) {
+	client := newLocalTestClient()
+
+	client.listenForInput(strings.NewReader("jn 3\nmk bot\n"))
+
+	if client.GameID != -1 || client.local.game != nil {
+		t.Error("Expected no game to be joined or created")
+	}
+
+	if len(client.messages) != 2 || client.messages[0].Content != "You need a server to do that!" {
+		t.Errorf("Expected the commands to be refused, got %v", client.messages)
+	}
+}
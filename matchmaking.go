@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// how far apart two queued players' ratings may be, and how quickly that widens as they wait
+const (
+	initialRatingBand = 100
+	ratingBandStep    = 50
+	ratingBandWait    = 10 * time.Second
+	// matchmakingInterval is how often the queue is checked for players whose bands now overlap
+	matchmakingInterval = time.Second
+)
+
+// QueueEntry is a player waiting for an opponent
+type QueueEntry struct {
+	UserID       string
+	SocketClient *SocketClient
+	Settings     GameSettings
+	Rating       float64
+	Joined       time.Time
+}
+
+// band is how far from their own rating a player will accept an opponent, after waiting
+// until now
+func (entry *QueueEntry) band(now time.Time) float64 {
+	steps := int(now.Sub(entry.Joined) / ratingBandWait)
+	return float64(initialRatingBand + steps*ratingBandStep)
+}
+
+// matches checks whether two queued players want the same game and are close enough in rating
+func (entry *QueueEntry) matches(other *QueueEntry, now time.Time) bool {
+	if entry.Settings != other.Settings {
+		return false
+	}
+	band := math.Max(entry.band(now), other.band(now))
+	return math.Abs(entry.Rating-other.Rating) <= band
+}
+
+// MatchQueue holds the players waiting to be paired, longest waiting first
+type MatchQueue struct {
+	M       sync.Mutex
+	entries []*QueueEntry
+}
+
+// add puts a player in the queue, replacing any entry they already had
+func (queue *MatchQueue) add(entry *QueueEntry) {
+	queue.M.Lock()
+	defer queue.M.Unlock()
+
+	queue.removeLocked(entry.UserID)
+	queue.entries = append(queue.entries, entry)
+}
+
+// remove takes a player out of the queue, reporting whether they were in it
+func (queue *MatchQueue) remove(userID string) bool {
+	queue.M.Lock()
+	defer queue.M.Unlock()
+
+	return queue.removeLocked(userID)
+}
+
+func (queue *MatchQueue) removeLocked(userID string) bool {
+	for i, entry := range queue.entries {
+		if entry.UserID == userID {
+			queue.entries = append(queue.entries[:i], queue.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// pairs takes every pair of players who match at now out of the queue, pairing those who
+// have waited longest first and dropping players whose connection has closed
+func (queue *MatchQueue) pairs(now time.Time) [][2]*QueueEntry {
+	queue.M.Lock()
+	defer queue.M.Unlock()
+
+	pairs := [][2]*QueueEntry{}
+	waiting := []*QueueEntry{}
+	for _, entry := range queue.entries {
+		if entry.SocketClient != nil && entry.SocketClient.isClosed() {
+			continue
+		}
+
+		paired := false
+		for i, other := range waiting {
+			if other.matches(entry, now) {
+				pairs = append(pairs, [2]*QueueEntry{other, entry})
+				waiting = append(waiting[:i], waiting[i+1:]...)
+				paired = true
+				break
+			}
+		}
+		if !paired {
+			waiting = append(waiting, entry)
+		}
+	}
+
+	queue.entries = waiting
+	return pairs
+}
+
+// queuePlayer checks that a player may queue for a game with the options in req.Data
+func (server *Server) queuePlayer(req Request, socketClient *SocketClient) (*QueueEntry, error) {
+	settings, err := parseGameSettings(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Bot != "" {
+		return nil, errors.New("Bots don't need a queue: use mk bot instead")
+	}
+	if settings.Rated && server.names.Name(req.UserID) == "" {
+		return nil, errors.New("Choose a name with nm before playing rated games")
+	}
+
+	rating, err := server.ratings.Rating(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueEntry{
+		UserID:       req.UserID,
+		SocketClient: socketClient,
+		Settings:     settings,
+		Rating:       rating.Rating,
+		Joined:       time.Now(),
+	}, nil
+}
+
+// handleQueue puts the player in the matchmaking queue with the options in req.Data, or
+// takes them out of it if req.Data is "cancel"
+func (server *Server) handleQueue(req Request, socketClient *SocketClient) []SocketClientResponse {
+	response := Request{
+		UserID: req.UserID,
+		Action: QUEUE,
+		GameID: -1,
+	}
+
+	if req.Data == "cancel" {
+		if server.queue.remove(req.UserID) {
+			response.Success = true
+			response.Data = "You left the queue"
+		} else {
+			response.Data = "You aren't in the queue"
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	entry, err := server.queuePlayer(req, socketClient)
+	if err != nil {
+		response.Data = err.Error()
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	server.queue.add(entry)
+	response.Success = true
+	response.Settings = entry.Settings
+	response.Data = "Looking for an opponent rated within " + strconv.Itoa(initialRatingBand) + " of " + strconv.Itoa(int(math.Round(entry.Rating))) + " for a " + entry.Settings.String() + " game. Type 'queue cancel' to stop looking."
+
+	responses := []SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+	}
+
+	// someone may already be waiting for the same game
+	return append(responses, server.matchQueuedPlayers(time.Now())...)
+}
+
+// matchQueuedPlayers starts a game for every pair of queued players who match at now
+func (server *Server) matchQueuedPlayers(now time.Time) []SocketClientResponse {
+	responses := []SocketClientResponse{}
+	for _, pair := range server.queue.pairs(now) {
+		log.Println("Matched", pair[0].UserID, "and", pair[1].UserID)
+		_, gameResponses := server.startPairedGame(pair[0].UserID, pair[0].SocketClient, pair[1].UserID, pair[1].SocketClient, pair[0].Settings)
+		responses = append(responses, gameResponses...)
+	}
+	return responses
+}
+
+// startPairedGame creates a room for two players chosen by the server, as though the first
+// had made it and the second had joined it
+func (server *Server) startPairedGame(firstID string, firstClient *SocketClient, secondID string, secondClient *SocketClient, settings GameSettings) (*GameRoom, []SocketClientResponse) {
+	game := server.createGame(Request{UserID: firstID}, firstClient, settings, RoomAccess{})
+	gameID := game.ID
+	game.M.Lock()
+	defer game.M.Unlock()
+
+	created := Request{
+		GameID:   gameID,
+		Action:   CREATE,
+		Success:  true,
+		Settings: settings,
+		Token:    game.Players[firstID].Token,
+	}
+
+	responses := []SocketClientResponse{
+		SocketClientResponse{
+			firstClient,
+			created,
+		},
+	}
+	return game, append(responses, server.handleJoin(Request{GameID: gameID, UserID: secondID, Action: JOIN}, secondClient, game)...)
+}
+
+// runMatchmaking pairs queued players every second, so that bands widen as players wait,
+// until the server stops
+func (server *Server) runMatchmaking() {
+	ticker := time.NewTicker(matchmakingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-server.quit:
+			return
+		case now := <-ticker.C:
+			for _, socketClientResponse := range server.matchQueuedPlayers(now) {
+				socketClientResponse.send()
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueBandWidens(t *testing.T) {
+	start := time.Now()
+	queue := &MatchQueue{}
+	queue.add(&QueueEntry{UserID: "mock_user1", Settings: DefaultGameSettings(), Rating: 1500, Joined: start})
+	queue.add(&QueueEntry{UserID: "mock_user2", Settings: DefaultGameSettings(), Rating: 1660, Joined: start})
+
+	if pairs := queue.pairs(start); len(pairs) != 0 {
+		t.Fatalf("Expected players 160 apart not to be paired at first, got %v", pairs)
+	}
+	if pairs := queue.pairs(start.Add(15 * time.Second)); len(pairs) != 0 {
+		t.Fatalf("Expected a band of 150 not to be enough, got %v", pairs)
+	}
+
+	pairs := queue.pairs(start.Add(20 * time.Second))
+	if len(pairs) != 1 || pairs[0][0].UserID != "mock_user1" || pairs[0][1].UserID != "mock_user2" {
+		t.Fatalf("Expected the players to be paired once the band reached 200, got %v", pairs)
+	}
+	if len(queue.entries) != 0 {
+		t.Errorf("Expected paired players to leave the queue, got %v", queue.entries)
+	}
+}
+
+func TestQueueMatchesSettings(t *testing.T) {
+	now := time.Now()
+	timed, _ := parseGameSettings("5m")
+	queue := &MatchQueue{}
+	queue.add(&QueueEntry{UserID: "mock_user1", Settings: DefaultGameSettings(), Rating: 1500, Joined: now})
+	queue.add(&QueueEntry{UserID: "mock_user2", Settings: timed, Rating: 1500, Joined: now})
+	queue.add(&QueueEntry{UserID: "mock_user3", Settings: timed, Rating: 1500, Joined: now})
+
+	pairs := queue.pairs(now)
+	if len(pairs) != 1 || pairs[0][0].UserID != "mock_user2" || pairs[0][1].UserID != "mock_user3" {
+		t.Fatalf("Expected only the players asking for 5m to be paired, got %v", pairs)
+	}
+
+	if !queue.remove("mock_user1") || queue.remove("mock_user1") {
+		t.Error("Expected the remaining player to be removed once")
+	}
+}
+
+func TestHandleQueue(t *testing.T) {
+	server := NewServer()
+
+	responses := server.handleQueue(Request{UserID: "mock_user1", Action: QUEUE, Data: "bot"}, nil)
+	if responses[0].response.Success {
+		t.Error("Expected bots to be refused")
+	}
+
+	responses = server.handleQueue(Request{UserID: "mock_user1", Action: QUEUE, Data: "9x9"}, nil)
+	if len(responses) != 1 || !responses[0].response.Success {
+		t.Fatalf("Expected the player to wait in the queue, got %v", responses)
+	}
+
+	responses = server.handleQueue(Request{UserID: "mock_user2", Action: QUEUE, Data: "9x9"}, nil)
+	if len(responses) != 4 {
+		t.Fatalf("Expected the queue, create, join and joined responses, got %v", responses)
+	}
+
+	created := responses[1].response
+	game := server.games[created.GameID]
+	if created.Action != CREATE || game == nil || len(game.Players) != 2 || game.Settings.Size != 9 {
+		t.Fatalf("Expected a 9x9 game between the queued players, got %v", created)
+	}
+	if responses[2].response.Action != JOIN || responses[3].response.Action != OTHERJOINED {
+		t.Errorf("Expected the second player to join the game, got %v", responses[2:])
+	}
+
+	responses = server.handleQueue(Request{UserID: "mock_user1", Action: QUEUE, Data: "cancel"}, nil)
+	if responses[0].response.Success {
+		t.Error("Expected a matched player to have left the queue already")
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// the lengths allowed for a player's name
+const (
+	minNameLength = 3
+	maxNameLength = 16
+)
+
+// reservedNames can't be registered, since the client already uses them for someone else
+var reservedNames = []string{"you", "opponent", "server", "gogomoku", "black", "white"}
+
+// Account is a user the server knows, with a hash of the credential that proves a client is
+// them. Guests have accounts without a name.
+type Account struct {
+	Name           string
+	UserID         string
+	CredentialHash string
+}
+
+// NameRegistry keeps the users who have signed in to the server and the names they registered
+type NameRegistry struct {
+	M sync.Mutex
+	// accounts are keyed by lower-case name, so that names differing only in case clash
+	accounts map[string]*Account
+	// byUser also has the guests, who have no name
+	byUser map[string]*Account
+}
+
+// NewNameRegistry creates a registry with the accounts already saved, if any
+func NewNameRegistry(accounts []Account) *NameRegistry {
+	registry := &NameRegistry{
+		accounts: make(map[string]*Account),
+		byUser:   make(map[string]*Account),
+	}
+
+	for i := range accounts {
+		account := accounts[i]
+		if account.Name != "" {
+			registry.accounts[strings.ToLower(account.Name)] = &account
+		}
+		registry.byUser[account.UserID] = &account
+	}
+	return registry
+}
+
+// hashCredential is what the registry keeps instead of the credential itself
+func hashCredential(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:])
+}
+
+// owns checks a credential against an account's, without leaking how much of it matched
+func (account *Account) owns(credential string) bool {
+	hash := hashCredential(credential)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(account.CredentialHash)) == 1
+}
+
+// validateName checks that a name is 3 to 16 letters, digits, dashes and underscores,
+// starting with a letter, and doesn't look like a bot, a guest or one of the client's labels
+func validateName(name string) error {
+	if len(name) < minNameLength || len(name) > maxNameLength {
+		return errors.New("Names must be 3 to 16 characters long")
+	}
+
+	for i, char := range name {
+		isLetter := char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+		isDigit := char >= '0' && char <= '9'
+		if i == 0 && !isLetter {
+			return errors.New("Names must start with a letter")
+		}
+		if !isLetter && !isDigit && char != '-' && char != '_' {
+			return errors.New("Names may only contain letters, digits, dashes and underscores")
+		}
+	}
+
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(lower, "bot-") || strings.HasPrefix(lower, "guest-") {
+		return errors.New("Names can't start with bot- or guest-")
+	}
+	for _, reserved := range reservedNames {
+		if lower == reserved {
+			return errors.New("The name " + name + " is reserved")
+		}
+	}
+
+	return nil
+}
+
+// validateUserID refuses the IDs the server gives its own bots, and the guest labels, so that
+// nobody can sign in as them
+func validateUserID(userID string) error {
+	if userID == "" {
+		return errors.New("You need a user ID to sign in")
+	}
+
+	lower := strings.ToLower(userID)
+	if strings.HasPrefix(lower, "bot-") || strings.HasPrefix(lower, "guest-") {
+		return errors.New("User IDs can't start with bot- or guest-")
+	}
+
+	return nil
+}
+
+// Register gives userID a name, or signs them back in to the name they registered before.
+// A user who already has a name must present its credential to sign in or to change it.
+// It returns the credential to present next time.
+func (registry *NameRegistry) Register(userID string, name string, credential string) (string, error) {
+	err := validateUserID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	err = validateName(name)
+	if err != nil {
+		return "", err
+	}
+
+	registry.M.Lock()
+	defer registry.M.Unlock()
+
+	current := registry.byUser[userID]
+	if current != nil && !current.owns(credential) {
+		return "", errors.New("You need your saved credential to use the name " + current.Name)
+	}
+
+	taken := registry.accounts[strings.ToLower(name)]
+	if taken != nil && taken != current {
+		return "", errors.New("The name " + taken.Name + " is already taken")
+	}
+
+	if current != nil {
+		if current.Name != "" {
+			delete(registry.accounts, strings.ToLower(current.Name))
+		}
+		current.Name = name
+		registry.accounts[strings.ToLower(name)] = current
+		return credential, nil
+	}
+
+	credential = newSessionToken()
+	account := &Account{Name: name, UserID: userID, CredentialHash: hashCredential(credential)}
+	registry.accounts[strings.ToLower(name)] = account
+	registry.byUser[userID] = account
+	return credential, nil
+}
+
+// SignIn proves that a client is userID, without choosing a name. The first client to sign in
+// as a user is given a credential for it, which it must present from then on. It returns the
+// credential to present next time.
+func (registry *NameRegistry) SignIn(userID string, credential string) (string, error) {
+	err := validateUserID(userID)
+	if err != nil {
+		return "", err
+	}
+
+	registry.M.Lock()
+	defer registry.M.Unlock()
+
+	if current := registry.byUser[userID]; current != nil {
+		if !current.owns(credential) {
+			return "", errors.New("You need your saved credential to sign in")
+		}
+		return credential, nil
+	}
+
+	credential = newSessionToken()
+	registry.byUser[userID] = &Account{UserID: userID, CredentialHash: hashCredential(credential)}
+	return credential, nil
+}
+
+// Name returns the name userID registered, or an empty string
+func (registry *NameRegistry) Name(userID string) string {
+	registry.M.Lock()
+	defer registry.M.Unlock()
+
+	if account := registry.byUser[userID]; account != nil {
+		return account.Name
+	}
+	return ""
+}
+
+// Accounts lists every user who has signed in, with or without a name, to be saved
+func (registry *NameRegistry) Accounts() []Account {
+	registry.M.Lock()
+	defer registry.M.Unlock()
+
+	accounts := []Account{}
+	for _, account := range registry.byUser {
+		accounts = append(accounts, *account)
+	}
+	return accounts
+}
+
+// displayName is how a player appears to others: their registered name, the bot's id, or
+// a short guest label for players without a name
+func (server *Server) displayName(game *GameRoom, userID string) string {
+	if name := server.names.Name(userID); name != "" {
+		return name
+	}
+
+	if player := game.Players[userID]; player != nil && player.Bot != nil {
+		return userID
+	}
+
+	return guestName(userID)
+}
+
+// guestName is a short label for a player without a registered name
+func guestName(userID string) string {
+	if len(userID) > 8 {
+		return "guest-" + userID[:8]
+	}
+	return "guest-" + userID
+}
+
+// gameNames maps the players in a game who have registered names to those names
+func (server *Server) gameNames(game *GameRoom) map[string]string {
+	names := make(map[string]string)
+	for id := range game.Players {
+		if name := server.names.Name(id); name != "" {
+			names[id] = name
+		}
+	}
+	return names
+}
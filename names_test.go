@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	valid := []string{"alice", "Bob_99", "renju-fan", "abcdefghijklmnop"}
+	for _, name := range valid {
+		if err := validateName(name); err != nil {
+			t.Errorf("Expected %s to be a valid name, got: %s", name, err)
+		}
+	}
+
+	invalid := []string{"", "al", "abcdefghijklmnopq", "9lives", "two words", "bot-3", "Guest-1234", "Opponent", "you", "ünicode"}
+	for _, name := range invalid {
+		if err := validateName(name); err == nil {
+			t.Errorf("Expected %s to be refused", name)
+		}
+	}
+}
+
+func TestNameRegistry(t *testing.T) {
+	registry := NewNameRegistry(nil)
+
+	credential, err := registry.Register("mock_user1", "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential == "" {
+		t.Fatal("Expected a credential for the new name")
+	}
+
+	if _, err := registry.Register("mock_user2", "Alice", ""); err == nil {
+		t.Error("Expected a name differing only in case to be taken")
+	}
+
+	if _, err := registry.Register("mock_user1", "alice", "wrong"); err == nil {
+		t.Error("Expected signing in with the wrong credential to fail")
+	}
+
+	again, err := registry.Register("mock_user1", "alice", credential)
+	if err != nil || again != credential {
+		t.Errorf("Expected to sign back in with the saved credential, got %s", err)
+	}
+
+	_, err = registry.Register("mock_user1", "alicia", credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registry.Name("mock_user1") != "alicia" {
+		t.Errorf("Expected the name to change to alicia, got %s", registry.Name("mock_user1"))
+	}
+
+	if _, err := registry.Register("mock_user2", "alice", ""); err != nil {
+		t.Errorf("Expected the old name to be free again, got: %s", err)
+	}
+
+	restored := NewNameRegistry(registry.Accounts())
+	if restored.Name("mock_user1") != "alicia" || restored.Name("mock_user2") != "alice" {
+		t.Error("Expected the names to be restored from the saved accounts")
+	}
+	if _, err := restored.Register("mock_user1", "alicia", credential); err != nil {
+		t.Errorf("Expected the credential to still work after a restore, got: %s", err)
+	}
+}
+
+func TestNameRegistrySignIn(t *testing.T) {
+	registry := NewNameRegistry(nil)
+
+	credential, err := registry.SignIn("mock_user1", "")
+	if err != nil || credential == "" {
+		t.Fatalf("Expected a guest to be given a credential, got: %v", err)
+	}
+
+	if _, err := registry.SignIn("mock_user1", ""); err == nil {
+		t.Error("Expected signing in as someone else's guest to fail")
+	}
+
+	if _, err := registry.Register("mock_user1", "alice", ""); err == nil {
+		t.Error("Expected naming someone else's guest to fail")
+	}
+
+	if _, err := registry.Register("mock_user1", "alice", credential); err != nil {
+		t.Errorf("Expected the guest to choose a name with their credential, got: %s", err)
+	}
+
+	for _, userID := range []string{"bot-0", "BOT-1", "guest-0123"} {
+		if _, err := registry.SignIn(userID, ""); err == nil {
+			t.Errorf("Expected signing in as %s to be refused", userID)
+		}
+		if _, err := registry.Register(userID, "mallory", ""); err == nil {
+			t.Errorf("Expected registering a name for %s to be refused", userID)
+		}
+	}
+
+	restored := NewNameRegistry(registry.Accounts())
+	if again, err := restored.SignIn("mock_user1", credential); err != nil || again != credential {
+		t.Errorf("Expected the guest's credential to survive a restore, got: %v", err)
+	}
+}
+
+func TestServerNames(t *testing.T) {
+	server := NewServer()
+
+	server.handleRequest(Request{UserID: "mock_user1", Action: REGISTER, Data: "alice"}, nil)
+	server.handleRequest(Request{UserID: "mock_user1", Action: CREATE}, nil)
+
+	home := server.handleSendToHome(nil)[0].response
+	if len(home.Home) != 1 || home.Home[0].Name != "alice" {
+		t.Fatalf("Expected alice's game to be open, got %v", home.Home)
+	}
+
+	game := server.games[0]
+	game.M.Lock()
+	responses := server.handleJoin(Request{GameID: 0, UserID: "0123456789abcdef"}, nil, game)
+	game.M.Unlock()
+	joined := responses[1].response
+	if joined.Action != OTHERJOINED || joined.Names["mock_user1"] != "alice" {
+		t.Errorf("Expected OTHERJOINED to carry the names, got %v", joined.Names)
+	}
+
+	home = server.handleSendToHome(nil)[0].response
+	if len(home.Live) != 1 {
+		t.Fatalf("Expected one game in progress, got %d", len(home.Live))
+	}
+	names := []string{home.Live[0].Name, home.Live[0].OpponentName}
+	if !(names[0] == "alice" && names[1] == "guest-01234567" || names[0] == "guest-01234567" && names[1] == "alice") {
+		t.Errorf("Expected alice to play a guest, got %v", names)
+	}
+}
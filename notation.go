@@ -0,0 +1,446 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go_gomoku/gomoku"
+)
+
+// notations games can be exported to and imported from
+const (
+	PSQ  = "psq"
+	SGF  = "sgf"
+	TEXT = "txt"
+)
+
+// sgfGomoku is the SGF game type for Gomoku
+const sgfGomoku = "4"
+
+// GameRecord is a game as written in a notation
+type GameRecord struct {
+	Size  int
+	Moves []gomoku.MoveRecord
+	// Black and White are the players' IDs, when known
+	Black string
+	White string
+	// Result is written as in SGF, e.g. "B+R" when white resigned or "0" for a draw,
+	// and is empty while the game is in progress
+	Result string
+}
+
+// isNotation checks whether a format is one games can be exported to
+func isNotation(format string) bool {
+	return format == PSQ || format == SGF || format == TEXT
+}
+
+// record captures the game's moves so far
+func (game *GameRoom) record() GameRecord {
+	record := GameRecord{
+		Size:  game.Settings.Size,
+		Moves: append([]gomoku.MoveRecord{}, game.Moves...),
+	}
+
+	for id, color := range game.Colors {
+		switch color {
+		case "black":
+			record.Black = id
+		case "white":
+			record.White = id
+		}
+	}
+
+	switch game.Result.Kind {
+	case gomoku.DRAWN:
+		record.Result = "0"
+	case gomoku.WON, gomoku.RESIGNED, gomoku.TIMEDOUT, gomoku.FORFEITED:
+		winner := "B"
+		if game.Colors[game.Result.WinnerID] == "white" {
+			winner = "W"
+		}
+		record.Result = winner + "+"
+		if game.Result.Kind == gomoku.RESIGNED {
+			record.Result += "R"
+		} else if game.Result.Kind == gomoku.TIMEDOUT {
+			record.Result += "T"
+		} else if game.Result.Kind == gomoku.FORFEITED {
+			record.Result += "F"
+		}
+	}
+
+	return record
+}
+
+// board places the record's stones on an empty board
+func (record GameRecord) board() gomoku.Board {
+	winLength := gomoku.DefaultWinLength
+	if record.Size < winLength {
+		winLength = record.Size
+	}
+
+	board := gomoku.NewSizedBoard(record.Size, winLength)
+	for _, move := range record.Moves {
+		board.Place(move.Coord, move.Color)
+	}
+	return board
+}
+
+// lastMove returns the record's last stone, or nil if it has none
+func (record GameRecord) lastMove() []gomoku.Coord {
+	if len(record.Moves) == 0 {
+		return nil
+	}
+	return []gomoku.Coord{record.Moves[len(record.Moves)-1].Coord}
+}
+
+// addMove appends a stone to the record, checking that its space is on the board and free
+func (record *GameRecord) addMove(coord gomoku.Coord, color string) error {
+	if coord.X < 1 || coord.X > record.Size || coord.Y < 1 || coord.Y > record.Size {
+		return errors.New("Move " + strconv.Itoa(len(record.Moves)+1) + " is off the board")
+	}
+
+	for _, move := range record.Moves {
+		if move.Coord == coord {
+			return errors.New("Move " + strconv.Itoa(len(record.Moves)+1) + " is on a space that is already taken")
+		}
+	}
+
+	record.Moves = append(record.Moves, gomoku.MoveRecord{Turn: len(record.Moves) + 1, Color: color, Coord: coord})
+	return nil
+}
+
+// alternatingMoves orders the moves so that black and white take turns, for notations that
+// don't say which color each stone is. Openings that place several stones in one turn are
+// interleaved, and any stones left over once one color runs out go last.
+func alternatingMoves(moves []gomoku.MoveRecord) []gomoku.MoveRecord {
+	byColor := map[string][]gomoku.MoveRecord{}
+	for _, move := range moves {
+		byColor[move.Color] = append(byColor[move.Color], move)
+	}
+
+	ordered := []gomoku.MoveRecord{}
+	for len(byColor["black"]) > 0 || len(byColor["white"]) > 0 {
+		for _, color := range []string{"black", "white"} {
+			if len(byColor[color]) > 0 {
+				ordered = append(ordered, byColor[color][0])
+				byColor[color] = byColor[color][1:]
+			}
+		}
+	}
+	return ordered
+}
+
+// alternatingColor is the color of the nth stone of a game in which black and white take turns
+func alternatingColor(n int) string {
+	if n%2 == 0 {
+		return "black"
+	}
+	return "white"
+}
+
+// exportRecord writes a game in one of the notations
+func exportRecord(record GameRecord, format string) (string, error) {
+	switch format {
+	case PSQ:
+		return exportPSQ(record), nil
+	case SGF:
+		return exportSGF(record), nil
+	case TEXT:
+		return exportText(record), nil
+	}
+	return "", errors.New("Games can be exported as psq, sgf or txt")
+}
+
+// parseRecord reads a game in any of the notations, telling them apart by how they start
+func parseRecord(text string) (GameRecord, error) {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "("):
+		return parseSGF(trimmed)
+	case strings.HasPrefix(trimmed, "Piskvorky"):
+		return parsePSQ(trimmed)
+	}
+	return parseText(trimmed, gomoku.DefaultBoardSize)
+}
+
+// exportPSQ writes a game as a Piskvork .psq file: a header with the board size, then one
+// "<column>,<row>,<milliseconds>" line per stone, with black and white taking turns
+func exportPSQ(record GameRecord) string {
+	size := strconv.Itoa(record.Size)
+	lines := []string{"Piskvorky " + size + "x" + size + ", 11:11, 0"}
+
+	var last time.Time
+	for _, move := range alternatingMoves(record.Moves) {
+		thinking := int64(0)
+		if !last.IsZero() && move.Time.After(last) {
+			thinking = move.Time.Sub(last).Milliseconds()
+		}
+		if !move.Time.IsZero() {
+			last = move.Time
+		}
+		lines = append(lines, strconv.Itoa(move.Coord.Y)+","+strconv.Itoa(move.Coord.X)+","+strconv.FormatInt(thinking, 10))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// parsePSQ reads a Piskvork .psq file. The moves end at the first line that isn't a move,
+// which is where Piskvork lists the programs that played.
+func parsePSQ(text string) (GameRecord, error) {
+	record := GameRecord{}
+	lines := strings.Split(strings.ReplaceAll(text, "\r", ""), "\n")
+
+	header := strings.Fields(strings.Replace(lines[0], ",", " ", -1))
+	if len(header) < 2 || header[0] != "Piskvorky" {
+		return record, errors.New("A psq file starts with 'Piskvorky <n>x<n>'")
+	}
+	dimensions := strings.Split(header[1], "x")
+	size, err := strconv.Atoi(dimensions[0])
+	if len(dimensions) != 2 || err != nil || dimensions[0] != dimensions[1] {
+		return record, errors.New("The board in a psq file must be square")
+	}
+	if size < gomoku.MinBoardSize || size > gomoku.MaxBoardSize {
+		return record, errors.New("Board size must be between " + strconv.Itoa(gomoku.MinBoardSize) + " and " + strconv.Itoa(gomoku.MaxBoardSize))
+	}
+	record.Size = size
+
+	for _, line := range lines[1:] {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) < 2 {
+			break
+		}
+		column, columnErr := strconv.Atoi(fields[0])
+		row, rowErr := strconv.Atoi(fields[1])
+		if columnErr != nil || rowErr != nil {
+			break
+		}
+
+		err = record.addMove(gomoku.Coord{X: row, Y: column}, alternatingColor(len(record.Moves)))
+		if err != nil {
+			return record, err
+		}
+	}
+
+	return record, nil
+}
+
+// sgfCoord writes a space as SGF does: the column and then the row, as letters from 'a'
+func sgfCoord(coord gomoku.Coord) string {
+	return string(rune('a'+coord.Y-1)) + string(rune('a'+coord.X-1))
+}
+
+// sgfEscape escapes the characters that end or escape an SGF property value
+func sgfEscape(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	return strings.ReplaceAll(value, "]", "\\]")
+}
+
+// exportSGF writes a game as an SGF record, with a node for each stone
+func exportSGF(record GameRecord) string {
+	var builder strings.Builder
+	builder.WriteString("(;FF[4]GM[" + sgfGomoku + "]CA[UTF-8]AP[go_gomoku]SZ[" + strconv.Itoa(record.Size) + "]")
+	if record.Black != "" {
+		builder.WriteString("PB[" + sgfEscape(record.Black) + "]")
+	}
+	if record.White != "" {
+		builder.WriteString("PW[" + sgfEscape(record.White) + "]")
+	}
+	if record.Result != "" {
+		builder.WriteString("RE[" + sgfEscape(record.Result) + "]")
+	}
+	builder.WriteString("\n")
+
+	for _, move := range record.Moves {
+		property := "B"
+		if move.Color == "white" {
+			property = "W"
+		}
+		builder.WriteString(";" + property + "[" + sgfCoord(move.Coord) + "]")
+	}
+
+	builder.WriteString(")\n")
+	return builder.String()
+}
+
+// sgfProperty is a single property of an SGF node, e.g. B[hh]
+type sgfProperty struct {
+	ident  string
+	values []string
+}
+
+// parseSGFNodes reads the nodes of an SGF game tree, following only the main line when the
+// tree has variations
+func parseSGFNodes(text string) ([][]sgfProperty, error) {
+	nodes := [][]sgfProperty{}
+	ident := ""
+	depth := 0
+
+	for i := 0; i < len(text); i++ {
+		char := text[i]
+		switch {
+		case char == '(':
+			depth++
+		case char == ')':
+			// the end of the first variation is the end of the main line
+			return nodes, nil
+		case char == ';':
+			nodes = append(nodes, []sgfProperty{})
+		case char == '[':
+			if len(nodes) == 0 {
+				return nil, errors.New("SGF property values must belong to a node")
+			}
+
+			var value strings.Builder
+			i++
+			for ; i < len(text) && text[i] != ']'; i++ {
+				if text[i] == '\\' && i+1 < len(text) {
+					i++
+				}
+				value.WriteByte(text[i])
+			}
+			if i == len(text) {
+				return nil, errors.New("An SGF property value is missing its ']'")
+			}
+
+			node := nodes[len(nodes)-1]
+			if ident != "" {
+				node = append(node, sgfProperty{ident: ident})
+				ident = ""
+			}
+			if len(node) == 0 {
+				return nil, errors.New("SGF property values must follow a property name")
+			}
+			node[len(node)-1].values = append(node[len(node)-1].values, value.String())
+			nodes[len(nodes)-1] = node
+		case char >= 'A' && char <= 'Z':
+			ident += string(char)
+		case char >= 'a' && char <= 'z':
+			// older versions of SGF allowed lowercase letters in property names, e.g. AddBlack
+		case unicode.IsSpace(rune(char)):
+		default:
+			return nil, errors.New("Unexpected '" + string(char) + "' in SGF")
+		}
+	}
+
+	if depth == 0 {
+		return nil, errors.New("An SGF record starts with '('")
+	}
+	return nodes, nil
+}
+
+// parseSGF reads an SGF record of a Gomoku game
+func parseSGF(text string) (GameRecord, error) {
+	record := GameRecord{Size: gomoku.DefaultBoardSize}
+
+	nodes, err := parseSGFNodes(text)
+	if err != nil {
+		return record, err
+	}
+	if len(nodes) == 0 {
+		return record, errors.New("The SGF record has no nodes")
+	}
+
+	for _, property := range nodes[0] {
+		value := property.values[0]
+		switch property.ident {
+		case "GM":
+			if value != sgfGomoku {
+				return record, errors.New("The SGF record isn't a Gomoku game")
+			}
+		case "SZ":
+			size, err := strconv.Atoi(value)
+			if err != nil || size < gomoku.MinBoardSize || size > gomoku.MaxBoardSize {
+				return record, errors.New("Board size must be between " + strconv.Itoa(gomoku.MinBoardSize) + " and " + strconv.Itoa(gomoku.MaxBoardSize))
+			}
+			record.Size = size
+		case "PB":
+			record.Black = value
+		case "PW":
+			record.White = value
+		case "RE":
+			record.Result = value
+		}
+	}
+
+	for _, node := range nodes {
+		for _, property := range node {
+			color := ""
+			switch property.ident {
+			case "B", "AB":
+				color = "black"
+			case "W", "AW":
+				color = "white"
+			default:
+				continue
+			}
+
+			for _, value := range property.values {
+				if len(value) != 2 {
+					return record, errors.New("SGF moves are two letters, ex: B[hh]")
+				}
+				coord := gomoku.Coord{X: int(value[1]-'a') + 1, Y: int(value[0]-'a') + 1}
+				err = record.addMove(coord, color)
+				if err != nil {
+					return record, err
+				}
+			}
+		}
+	}
+
+	return record, nil
+}
+
+// textCoord writes a space as RenLib does: a column letter and a row number counted from
+// the bottom of the board, e.g. h8
+func textCoord(coord gomoku.Coord, size int) string {
+	return string(rune('a'+coord.Y-1)) + strconv.Itoa(size-coord.X+1)
+}
+
+// exportText writes a game as a list of moves that RenLib can paste in, with black and
+// white taking turns
+func exportText(record GameRecord) string {
+	moves := []string{}
+	for _, move := range alternatingMoves(record.Moves) {
+		moves = append(moves, textCoord(move.Coord, record.Size))
+	}
+	return strings.Join(moves, " ") + "\n"
+}
+
+// parseText reads a list of moves like "h8 i9 j10", which may also be written without
+// spaces, on a board of the given size
+func parseText(text string, size int) (GameRecord, error) {
+	record := GameRecord{Size: size}
+	text = strings.ToLower(text)
+
+	for i := 0; i < len(text); {
+		char := text[i]
+		if unicode.IsSpace(rune(char)) || char == ',' {
+			i++
+			continue
+		}
+		if char < 'a' || char > 'z' {
+			return record, errors.New("Moves are written as a column letter and a row number, ex: h8")
+		}
+
+		j := i + 1
+		for j < len(text) && text[j] >= '0' && text[j] <= '9' {
+			j++
+		}
+		row, err := strconv.Atoi(text[i+1 : j])
+		if err != nil {
+			return record, errors.New("Moves are written as a column letter and a row number, ex: h8")
+		}
+
+		coord := gomoku.Coord{X: size - row + 1, Y: int(char-'a') + 1}
+		err = record.addMove(coord, alternatingColor(len(record.Moves)))
+		if err != nil {
+			return record, err
+		}
+		i = j
+	}
+
+	return record, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// newNotationTestGame plays a short swap game: black on 8 8 and 8 9, white on 1 1, then
+// white on 2 2 and black on 3 3
+func newNotationTestGame(t *testing.T) *GameRoom {
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2", "3 3"})
+	return game
+}
+
+func compareMoves(t *testing.T, expected []gomoku.MoveRecord, actual []gomoku.MoveRecord) {
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d moves, got %v", len(expected), actual)
+	}
+
+	for i := range expected {
+		if actual[i].Color != expected[i].Color || actual[i].Coord != expected[i].Coord {
+			t.Errorf("Expected move %d to be %s on %s, got %s on %s", i, expected[i].Color, expected[i].Coord, actual[i].Color, actual[i].Coord)
+		}
+	}
+}
+
+func TestPSQRoundTrip(t *testing.T) {
+	game := newNotationTestGame(t)
+	record := game.record()
+
+	exported := exportPSQ(record)
+	imported, err := parseRecord(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Size != gomoku.DefaultBoardSize {
+		t.Errorf("Expected size %d, got %d", gomoku.DefaultBoardSize, imported.Size)
+	}
+
+	// psq has no colors, so the opening's stones are written with black and white taking turns
+	compareMoves(t, alternatingMoves(record.Moves), imported.Moves)
+
+	board := imported.board()
+	for _, color := range []string{"black", "white"} {
+		if len(board.ListSpaces(color)) != len(game.Board.ListSpaces(color)) {
+			t.Errorf("Expected the imported board to have the same %s stones", color)
+		}
+		for _, space := range game.Board.ListSpaces(color) {
+			if !board.Spaces()[color][space] {
+				t.Errorf("Expected %s on %s", color, space)
+			}
+		}
+	}
+}
+
+func TestPSQTimes(t *testing.T) {
+	start := time.Now()
+	record := GameRecord{
+		Size: 15,
+		Moves: []gomoku.MoveRecord{
+			gomoku.MoveRecord{Color: "black", Coord: gomoku.Coord{X: 8, Y: 8}, Time: start},
+			gomoku.MoveRecord{Color: "white", Coord: gomoku.Coord{X: 8, Y: 9}, Time: start.Add(1500 * time.Millisecond)},
+		},
+	}
+
+	expected := "Piskvorky 15x15, 11:11, 0\n8,8,0\n9,8,1500\n"
+	if exported := exportPSQ(record); exported != expected {
+		t.Errorf("Expected %q, got %q", expected, exported)
+	}
+}
+
+func TestParsePSQFromPiskvork(t *testing.T) {
+	text := "Piskvorky 20x20, 11:11, 0\r\n10,10,0\r\n11,10,312\r\n10,11,20\r\npbrain-embryo.exe\r\npbrain-yixin.exe\r\n-1\r\n"
+
+	record, err := parseRecord(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Size != 20 {
+		t.Errorf("Expected size 20, got %d", record.Size)
+	}
+
+	compareMoves(t, []gomoku.MoveRecord{
+		gomoku.MoveRecord{Color: "black", Coord: gomoku.Coord{X: 10, Y: 10}},
+		gomoku.MoveRecord{Color: "white", Coord: gomoku.Coord{X: 10, Y: 11}},
+		gomoku.MoveRecord{Color: "black", Coord: gomoku.Coord{X: 11, Y: 10}},
+	}, record.Moves)
+}
+
+func TestSGFRoundTrip(t *testing.T) {
+	game := newNotationTestGame(t)
+	game.end(gomoku.Result{Kind: gomoku.RESIGNED, WinnerID: "mock_user2"}, time.Now())
+	record := game.record()
+
+	if record.Result != "W+R" {
+		t.Errorf("Expected result W+R, got %s", record.Result)
+	}
+
+	imported, err := parseRecord(exportSGF(record))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Size != record.Size || imported.Black != "mock_user1" || imported.White != "mock_user2" || imported.Result != "W+R" {
+		t.Errorf("Expected the game info to survive, got %v", imported)
+	}
+
+	// sgf keeps the order the stones were played in
+	compareMoves(t, record.Moves, imported.Moves)
+}
+
+func TestParseSGFVariations(t *testing.T) {
+	text := "(;GM[4]SZ[9]PB[a \\] b]\n;B[ee](;W[ef];B[ff])(;W[dd]))"
+
+	record, err := parseSGF(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Size != 9 || record.Black != "a ] b" {
+		t.Errorf("Expected size 9 and an unescaped name, got %v", record)
+	}
+
+	compareMoves(t, []gomoku.MoveRecord{
+		gomoku.MoveRecord{Color: "black", Coord: gomoku.Coord{X: 5, Y: 5}},
+		gomoku.MoveRecord{Color: "white", Coord: gomoku.Coord{X: 6, Y: 5}},
+		gomoku.MoveRecord{Color: "black", Coord: gomoku.Coord{X: 6, Y: 6}},
+	}, record.Moves)
+}
+
+func TestParseSGFErrors(t *testing.T) {
+	texts := []string{
+		"(;GM[1]SZ[19];B[aa])",
+		"(;GM[4]SZ[15];B[hh];W[hh])",
+		"(;GM[4]SZ[9];B[zz])",
+		"(;GM[4]SZ[15];B[hh",
+	}
+
+	for _, text := range texts {
+		_, err := parseSGF(text)
+		if err == nil {
+			t.Errorf("Expected %q not to parse", text)
+		}
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	record := newNotationTestGame(t).record()
+
+	exported := exportText(record)
+	if exported != "h8 a15 i8 b14 c13\n" {
+		t.Errorf("Expected RenLib coordinates, got %q", exported)
+	}
+
+	imported, err := parseRecord("h8a15i8b14c13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareMoves(t, alternatingMoves(record.Moves), imported.Moves)
+}
+
+func TestExportFromServer(t *testing.T) {
+	server := NewServer()
+	game := newNotationTestGame(t)
+	server.games[game.ID] = game
+
+	responses := server.handleExport(Request{GameID: game.ID, UserID: "mock_user1", Action: EXPORT, Data: SGF}, nil, game)
+	if !responses[0].response.Success || responses[0].response.Data != exportSGF(game.record()) {
+		t.Errorf("Expected the game as sgf, got %v", responses[0].response)
+	}
+
+	responses = server.handleExport(Request{GameID: game.ID, UserID: "mock_user1", Action: EXPORT, Data: "pdf"}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
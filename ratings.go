@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// the Elo rating every player starts with, and how far a single game can move it
+const (
+	initialRating = 1500
+	ratingFactor  = 32
+)
+
+// leaderboardSize is how many players lb shows
+const leaderboardSize = 10
+
+// PlayerRating is a player's rating and record in rated games
+type PlayerRating struct {
+	UserID string
+	Rating float64
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+// newPlayerRating is the rating of a player who hasn't played a rated game yet
+func newPlayerRating(userID string) PlayerRating {
+	return PlayerRating{UserID: userID, Rating: initialRating}
+}
+
+// updateElo returns the new ratings of two players after a game, where score is what the
+// first player scored: 1 for a win, 0.5 for a draw and 0 for a loss
+func updateElo(first float64, second float64, score float64) (float64, float64) {
+	expected := 1 / (1 + math.Pow(10, (second-first)/400))
+	change := ratingFactor * (score - expected)
+	return first + change, second - change
+}
+
+// RatingStore keeps the ratings of players who have played rated games
+type RatingStore interface {
+	// Rating returns a player's rating, or the initial rating if they haven't played
+	Rating(userID string) (PlayerRating, error)
+	SaveRatings(ratings ...PlayerRating) error
+	// Leaderboard lists every rated player, best first
+	Leaderboard() ([]PlayerRating, error)
+}
+
+// assert that stores implement RatingStore
+var _ RatingStore = (*MemoryRatingStore)(nil)
+var _ RatingStore = (*FileRatingStore)(nil)
+
+// MemoryRatingStore keeps ratings for as long as the server runs
+type MemoryRatingStore struct {
+	M       sync.Mutex
+	ratings map[string]PlayerRating
+}
+
+// NewMemoryRatingStore creates a store with no ratings in it
+func NewMemoryRatingStore() *MemoryRatingStore {
+	return &MemoryRatingStore{ratings: make(map[string]PlayerRating)}
+}
+
+func (store *MemoryRatingStore) Rating(userID string) (PlayerRating, error) {
+	store.M.Lock()
+	defer store.M.Unlock()
+
+	if rating, ok := store.ratings[userID]; ok {
+		return rating, nil
+	}
+	return newPlayerRating(userID), nil
+}
+
+func (store *MemoryRatingStore) SaveRatings(ratings ...PlayerRating) error {
+	store.M.Lock()
+	defer store.M.Unlock()
+
+	for _, rating := range ratings {
+		store.ratings[rating.UserID] = rating
+	}
+	return nil
+}
+
+func (store *MemoryRatingStore) Leaderboard() ([]PlayerRating, error) {
+	store.M.Lock()
+	defer store.M.Unlock()
+
+	return store.sorted(), nil
+}
+
+// sorted lists the ratings best first, breaking ties by user ID so the order is stable;
+// the caller must hold the store's lock
+func (store *MemoryRatingStore) sorted() []PlayerRating {
+	ratings := []PlayerRating{}
+	for _, rating := range store.ratings {
+		ratings = append(ratings, rating)
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Rating != ratings[j].Rating {
+			return ratings[i].Rating > ratings[j].Rating
+		}
+		return ratings[i].UserID < ratings[j].UserID
+	})
+	return ratings
+}
+
+// FileRatingStore keeps ratings in memory and writes them all to a JSON file whenever
+// they change
+type FileRatingStore struct {
+	*MemoryRatingStore
+	path string
+}
+
+// NewFileRatingStore creates a store backed by the file at path, loading any ratings
+// already saved there
+func NewFileRatingStore(path string) (*FileRatingStore, error) {
+	store := &FileRatingStore{MemoryRatingStore: NewMemoryRatingStore(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := []PlayerRating{}
+	err = json.Unmarshal(data, &ratings)
+	if err != nil {
+		return nil, errors.New("Could not read ratings from " + path + ": " + err.Error())
+	}
+	for _, rating := range ratings {
+		store.ratings[rating.UserID] = rating
+	}
+	return store, nil
+}
+
+// SaveRatings updates the ratings and atomically rewrites the file
+func (store *FileRatingStore) SaveRatings(ratings ...PlayerRating) error {
+	store.M.Lock()
+	defer store.M.Unlock()
+
+	for _, rating := range ratings {
+		store.ratings[rating.UserID] = rating
+	}
+
+	data, err := json.Marshal(store.sorted())
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(store.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := store.path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, store.path)
+}
+
+// rateGame updates both players' ratings and records once a rated game is over; the
+// caller must hold the game's lock
+func (server *Server) rateGame(game *GameRoom) {
+	if !game.Settings.Rated || len(game.Players) != 2 {
+		return
+	}
+
+	firstID := game.FirstPlayerID
+	secondID := GetOpponentID(game, firstID)
+
+	first, err := server.ratings.Rating(firstID)
+	if err != nil {
+		log.Println("Could not load rating:", err)
+		return
+	}
+	second, err := server.ratings.Rating(secondID)
+	if err != nil {
+		log.Println("Could not load rating:", err)
+		return
+	}
+
+	score := 0.5
+	switch game.Result.WinnerID {
+	case firstID:
+		score = 1
+		first.Wins++
+		second.Losses++
+	case secondID:
+		score = 0
+		first.Losses++
+		second.Wins++
+	default:
+		first.Draws++
+		second.Draws++
+	}
+
+	first.Rating, second.Rating = updateElo(first.Rating, second.Rating, score)
+
+	err = server.ratings.SaveRatings(first, second)
+	if err != nil {
+		log.Println("Could not save ratings:", err)
+	}
+}
+
+// ratingEntry describes a player's place on the leaderboard
+func (server *Server) ratingEntry(rank int, rating PlayerRating) RatingEntry {
+	name := server.names.Name(rating.UserID)
+	if name == "" {
+		name = guestName(rating.UserID)
+	}
+
+	return RatingEntry{
+		Rank:   rank,
+		Name:   name,
+		Rating: int(math.Round(rating.Rating)),
+		Wins:   rating.Wins,
+		Losses: rating.Losses,
+		Draws:  rating.Draws,
+	}
+}
+
+// handleLeaderboard sends the best rated players, and the player's own place
+func (server *Server) handleLeaderboard(req Request, socketClient *SocketClient) []SocketClientResponse {
+	response := Request{
+		UserID: req.UserID,
+		Action: LEADERBOARD,
+	}
+
+	ratings, err := server.ratings.Leaderboard()
+	if err != nil {
+		log.Println("Could not load ratings:", err)
+		response.Data = "Could not load the leaderboard"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	response.Success = true
+	response.Leaderboard = []RatingEntry{}
+	for i, rating := range ratings {
+		if i < leaderboardSize {
+			response.Leaderboard = append(response.Leaderboard, server.ratingEntry(i+1, rating))
+		}
+		if rating.UserID == req.UserID {
+			response.YourRating = server.ratingEntry(i+1, rating)
+		}
+	}
+
+	return []SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+	}
+}
+
+// String describes an entry as e.g. "3. alice 1532 (4 wins, 1 loss, 0 draws)"
+func (entry RatingEntry) String() string {
+	return strconv.Itoa(entry.Rank) + ". " + entry.Name + " " + strconv.Itoa(entry.Rating) + " (" +
+		plural(entry.Wins, "win", "wins") + ", " + plural(entry.Losses, "loss", "losses") + ", " +
+		plural(entry.Draws, "draw", "draws") + ")"
+}
+
+func plural(n int, one string, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return strconv.Itoa(n) + " " + many
+}
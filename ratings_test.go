@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateElo(t *testing.T) {
+	winner, loser := updateElo(1500, 1500, 1)
+	if winner != 1516 || loser != 1484 {
+		t.Errorf("Expected an even game to move 16 points, got %v and %v", winner, loser)
+	}
+
+	first, second := updateElo(1700, 1300, 0.5)
+	if math.Round(first) != 1687 || math.Round(second) != 1313 {
+		t.Errorf("Expected a draw to cost the stronger player, got %v and %v", first, second)
+	}
+}
+
+func TestFileRatingStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratings.json")
+	store, err := NewFileRatingStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rating, _ := store.Rating("mock_user1")
+	if rating.Rating != initialRating {
+		t.Errorf("Expected a new player to start at %d, got %v", initialRating, rating.Rating)
+	}
+
+	err = store.SaveRatings(
+		PlayerRating{UserID: "mock_user1", Rating: 1484, Losses: 1},
+		PlayerRating{UserID: "mock_user2", Rating: 1516, Wins: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewFileRatingStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderboard, _ := restored.Leaderboard()
+	if len(leaderboard) != 2 || leaderboard[0].UserID != "mock_user2" || leaderboard[1].Losses != 1 {
+		t.Errorf("Expected the ratings to be restored best first, got %v", leaderboard)
+	}
+}
+
+func TestRatedGame(t *testing.T) {
+	server := NewServer()
+	server.names.Register("mock_user1", "alice", "")
+	server.names.Register("mock_user2", "bob", "")
+
+	responses := server.handleCreate(Request{UserID: "mock_user1", Action: CREATE, Data: "rated"}, nil)
+	if !responses[0].response.Success {
+		t.Fatal(responses[0].response.Data)
+	}
+	game := server.games[responses[0].response.GameID]
+	server.handleJoin(Request{GameID: game.ID, UserID: "mock_user2", Action: JOIN}, nil, game)
+
+	server.handleResign(Request{GameID: game.ID, UserID: "mock_user2", Action: RESIGN}, nil, game)
+	if !game.IsOver {
+		t.Fatal("Expected the game to be over")
+	}
+
+	board := server.handleLeaderboard(Request{UserID: "mock_user2", Action: LEADERBOARD}, nil)[0].response
+	expected := []RatingEntry{
+		{Rank: 1, Name: "alice", Rating: 1516, Wins: 1},
+		{Rank: 2, Name: "bob", Rating: 1484, Losses: 1},
+	}
+	if len(board.Leaderboard) != 2 || board.Leaderboard[0] != expected[0] || board.Leaderboard[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, board.Leaderboard)
+	}
+	if board.YourRating != expected[1] {
+		t.Errorf("Expected bob's own rating to be %v, got %v", expected[1], board.YourRating)
+	}
+	if expected[1].String() != "2. bob 1484 (0 wins, 1 loss, 0 draws)" {
+		t.Errorf("Unexpected description: %s", expected[1])
+	}
+}
+
+func TestCasualAndUnnamedGames(t *testing.T) {
+	server := NewServer()
+
+	responses := server.handleCreate(Request{UserID: "mock_user1", Action: CREATE, Data: "rated"}, nil)
+	if responses[0].response.Success {
+		t.Error("Expected a rated game to need a name")
+	}
+
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	game.onFinish = server.rateGame
+	server.games[game.ID] = game
+	server.handleResign(Request{GameID: game.ID, UserID: "mock_user2", Action: RESIGN}, nil, game)
+
+	board := server.handleLeaderboard(Request{UserID: "mock_user1", Action: LEADERBOARD}, nil)[0].response
+	if len(board.Leaderboard) != 0 || board.YourRating.Rank != 0 {
+		t.Errorf("Expected a casual game to leave the leaderboard empty, got %v", board.Leaderboard)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go_gomoku/gomoku"
+)
+
+// the renderers that can be chosen with -render or GOMOKU_RENDER
+const (
+	// ANSIRENDER draws with box-drawing characters and colours stones and coordinates
+	ANSIRENDER = "ansi"
+	// UNICODERENDER draws with box-drawing characters, without colour
+	UNICODERENDER = "unicode"
+	// ASCIIRENDER draws with plain ASCII, for terminals that can't display Unicode
+	ASCIIRENDER = "ascii"
+	// AUTORENDER picks a renderer from the terminal's environment
+	AUTORENDER = "auto"
+)
+
+// ANSI escape sequences
+const (
+	escapeClear    = "\033[H\033[2J"
+	escapeReset    = "\033[0m"
+	escapeBlack    = "\033[1;31m"
+	escapeWhite    = "\033[1;36m"
+	escapeLastMove = "\033[7m"
+	escapeWinning  = "\033[1;33m"
+	escapeLabel    = "\033[2m"
+	escapeCursor   = "\033[1;32m"
+)
+
+// how a renderer clears the screen
+const (
+	// clearEscape writes escapeClear, on terminals that understand escape sequences
+	clearEscape = "escape"
+	// clearCommand runs cls, on Windows consoles
+	clearCommand = "cls"
+	// clearNewlines scrolls the last screen away, on terminals that can't be cleared
+	clearNewlines = "newlines"
+)
+
+// screenHeight is how many blank lines scroll a screen out of sight
+const screenHeight = 50
+
+// glyphSet is the characters a board is drawn with
+type glyphSet struct {
+	topLeft            string
+	topRight           string
+	bottomLeft         string
+	bottomRight        string
+	horizontal         string
+	vertical           string
+	topIntersection    string
+	bottomIntersection string
+	leftIntersection   string
+	rightIntersection  string
+	fullIntersection   string
+	// stones is keyed by what BoardView.stoneAt returns
+	stones map[string]string
+}
+
+var unicodeGlyphs = glyphSet{
+	topLeft:            "┏",
+	topRight:           "┓",
+	bottomLeft:         "┗",
+	bottomRight:        "┛",
+	horizontal:         "─",
+	vertical:           "┃",
+	topIntersection:    "┳",
+	bottomIntersection: "┻",
+	leftIntersection:   "┣",
+	rightIntersection:  "┫",
+	fullIntersection:   "╋",
+	stones: map[string]string{
+		"black":                "◉",
+		"white":                "◯",
+		"black" + lastMoveMark: "◈",
+		"white" + lastMoveMark: "◇",
+		"black" + winningMark:  "★",
+		"white" + winningMark:  "☆",
+		cursorMark:             "▣",
+		selectedMark:           "◌",
+	},
+}
+
+var asciiGlyphs = glyphSet{
+	topLeft:            "+",
+	topRight:           "+",
+	bottomLeft:         "+",
+	bottomRight:        "+",
+	horizontal:         "-",
+	vertical:           "|",
+	topIntersection:    "+",
+	bottomIntersection: "+",
+	leftIntersection:   "+",
+	rightIntersection:  "+",
+	fullIntersection:   "+",
+	stones: map[string]string{
+		"black":                "x",
+		"white":                "o",
+		"black" + lastMoveMark: "X",
+		"white" + lastMoveMark: "O",
+		"black" + winningMark:  "#",
+		"white" + winningMark:  "@",
+		cursorMark:             "*",
+		selectedMark:           "?",
+	},
+}
+
+// Renderer clears the screen and draws boards for a kind of terminal
+type Renderer struct {
+	name   string
+	glyphs glyphSet
+	// color colours stones, highlights and coordinates with ANSI escape sequences
+	color bool
+	// clear is how the screen is cleared, which depends on the terminal rather than the renderer
+	clear string
+}
+
+// NewRenderer creates one of the renderers by name
+func NewRenderer(name string) (*Renderer, error) {
+	switch name {
+	case ANSIRENDER:
+		return &Renderer{name: name, glyphs: unicodeGlyphs, color: true, clear: clearEscape}, nil
+	case UNICODERENDER:
+		return &Renderer{name: name, glyphs: unicodeGlyphs, clear: clearNewlines}, nil
+	case ASCIIRENDER:
+		return &Renderer{name: name, glyphs: asciiGlyphs, clear: clearNewlines}, nil
+	}
+	return nil, errors.New("Unknown renderer '" + name + "': choose ansi, unicode, ascii or auto")
+}
+
+// SelectRenderer chooses a renderer by name, falling back to GOMOKU_RENDER and then to
+// whatever the terminal supports
+func SelectRenderer(name string, getenv func(string) string) (*Renderer, error) {
+	if name == "" {
+		name = getenv("GOMOKU_RENDER")
+	}
+	if name == "" || name == AUTORENDER {
+		name = detectRenderer(getenv)
+	}
+
+	renderer, err := NewRenderer(name)
+	if err != nil {
+		return nil, err
+	}
+	if !renderer.color {
+		renderer.clear = detectClear(getenv, runtime.GOOS)
+	}
+	return renderer, nil
+}
+
+// detectClear uses escape sequences on terminals that aren't dumb, cls on Windows consoles,
+// and otherwise blank lines
+func detectClear(getenv func(string) string, goos string) string {
+	term := getenv("TERM")
+	switch {
+	case term != "" && term != "dumb":
+		return clearEscape
+	case goos == "windows":
+		return clearCommand
+	}
+	return clearNewlines
+}
+
+// detectRenderer uses ASCII unless the locale is UTF-8, and colour unless the terminal is
+// dumb or NO_COLOR is set
+func detectRenderer(getenv func(string) string) string {
+	locale := getenv("LC_ALL")
+	if locale == "" {
+		locale = getenv("LC_CTYPE")
+	}
+	if locale == "" {
+		locale = getenv("LANG")
+	}
+
+	locale = strings.ToLower(locale)
+	if !strings.Contains(locale, "utf-8") && !strings.Contains(locale, "utf8") {
+		return ASCIIRENDER
+	}
+
+	term := getenv("TERM")
+	if term == "" || term == "dumb" || getenv("NO_COLOR") != "" {
+		return UNICODERENDER
+	}
+	return ANSIRENDER
+}
+
+// defaultRenderer is used until a renderer is chosen
+func defaultRenderer() *Renderer {
+	renderer, _ := SelectRenderer("", os.Getenv)
+	return renderer
+}
+
+// Clear clears the screen and moves the cursor to the top left, ready to redraw
+func (renderer *Renderer) Clear(w io.Writer) {
+	switch renderer.clear {
+	case clearEscape:
+		fmt.Fprint(w, escapeClear)
+	case clearCommand:
+		cmd := exec.Command("cmd", "/c", "cls")
+		cmd.Stdout = w
+		cmd.Run()
+	default:
+		fmt.Fprint(w, strings.Repeat("\n", screenHeight))
+	}
+}
+
+// Board draws a board, marking its last move and winning line
+func (renderer *Renderer) Board(w io.Writer, board *BoardView) {
+	prevOccupied := gomoku.FREE
+	occupied := gomoku.FREE
+
+	for y := 0; y <= board.lastRow(); y++ {
+		row := ""
+		for x := 1; x <= board.lastColumn(); x++ {
+			label := renderer.axisLabel(board, x, y)
+			row += label
+
+			if y == 0 && label != "" {
+				continue
+			}
+
+			coord := board.getCoord(x, y)
+
+			occupied = board.stoneAt(coord)
+
+			if y%2 == 1 {
+				row += renderer.rowChar(board, x, y, occupied, prevOccupied)
+			} else {
+				row += renderer.columnChar(board, x)
+			}
+			prevOccupied = occupied
+		}
+
+		fmt.Fprintln(w, row)
+	}
+}
+
+// paint wraps text in an escape sequence, if the renderer uses colour
+func (renderer *Renderer) paint(escape string, text string) string {
+	if !renderer.color || text == "" {
+		return text
+	}
+	return escape + text + escapeReset
+}
+
+// stone draws a stone as returned by BoardView.stoneAt
+func (renderer *Renderer) stone(occupied string) string {
+	glyph := renderer.glyphs.stones[occupied]
+
+	escape := escapeBlack
+	switch {
+	case occupied == cursorMark || occupied == selectedMark:
+		escape = escapeCursor
+	case strings.HasPrefix(occupied, "white"):
+		escape = escapeWhite
+	}
+
+	switch {
+	case strings.HasSuffix(occupied, winningMark):
+		escape = escapeWinning
+	case strings.HasSuffix(occupied, lastMoveMark):
+		escape += escapeLastMove
+	}
+
+	return renderer.paint(escape, glyph)
+}
+
+func (renderer *Renderer) intersectionOrSpace(horizontal string, intersection string, occupied string, spaceFirst bool) string {
+	space := intersection
+	if occupied != gomoku.FREE {
+		space = renderer.stone(occupied)
+	}
+
+	if spaceFirst {
+		return space + horizontal
+	}
+
+	return horizontal + space
+}
+
+func (renderer *Renderer) rowChar(board *BoardView, x int, y int, occupied string, prevOccupied string) string {
+	glyphs := renderer.glyphs
+	HORIZONTALS := [3]string{
+		glyphs.horizontal,
+		strings.Repeat(glyphs.horizontal, 2),
+		strings.Repeat(glyphs.horizontal, 3),
+	}
+
+	// leave a gap after a stone, so that it stands out from the grid
+	if prevOccupied != gomoku.FREE {
+		HORIZONTALS[0] = " "
+		HORIZONTALS[1] = " " + glyphs.horizontal
+		HORIZONTALS[2] = " " + strings.Repeat(glyphs.horizontal, 2)
+	}
+
+	left, middle, right := glyphs.leftIntersection, glyphs.fullIntersection, glyphs.rightIntersection
+	if y == 1 {
+		left, middle, right = glyphs.topLeft, glyphs.topIntersection, glyphs.topRight
+	} else if y == board.lastRow() {
+		left, middle, right = glyphs.bottomLeft, glyphs.bottomIntersection, glyphs.bottomRight
+	}
+
+	switch x {
+	case 1:
+		return renderer.intersectionOrSpace(HORIZONTALS[1], left, occupied, true)
+	case board.lastColumn():
+		return renderer.intersectionOrSpace(HORIZONTALS[2], right, occupied, false)
+	default:
+		if x%2 == 0 {
+			return renderer.intersectionOrSpace(HORIZONTALS[0], middle, occupied, false)
+		}
+
+		return HORIZONTALS[1]
+	}
+}
+
+func (renderer *Renderer) columnChar(board *BoardView, x int) string {
+	vertical := renderer.glyphs.vertical
+
+	if x == 1 {
+		return vertical + "  "
+	}
+
+	if x == board.lastColumn() {
+		return "   " + vertical
+	}
+
+	if x%2 == 0 {
+		return " " + vertical
+	}
+
+	return "  "
+}
+
+// axisLabel numbers the columns along the top row, and the rows down the left
+func (renderer *Renderer) axisLabel(board *BoardView, x int, y int) string {
+	// x axis
+	ret := ""
+	if y == 0 {
+		if x == 1 {
+			ret += "   "
+		}
+
+		if x%2 == 1 {
+			char := renderer.paint(escapeLabel, strconv.Itoa(((x-1)/2)+1))
+
+			ret += char + " "
+
+			if x == board.lastColumn() {
+				// keep single-digit labels aligned with their columns
+				if board.Size <= 10 {
+					ret += " "
+				}
+				ret += " " + renderer.paint(escapeLabel, strconv.Itoa(board.Size))
+				return ret
+			}
+		} else {
+			if x >= 20 {
+				ret += " "
+				return ret
+			}
+
+			ret += "  "
+		}
+		return ret
+	}
+
+	// y axis
+	if x == 1 {
+		if y%2 == 1 {
+			char := renderer.paint(escapeLabel, strconv.Itoa(((y-1)/2)+1))
+
+			if y >= 19 {
+				ret += char + " "
+			} else {
+				ret += char + "  "
+			}
+		} else {
+			ret += "   "
+		}
+		return ret
+	}
+
+	return ret
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go_gomoku/gomoku"
+)
+
+func newRenderTestBoard() BoardView {
+	board := NewBoardView(5, 4)
+	board.setPosition(map[string]map[string]bool{
+		"black": {"1 1": true, "3 3": true},
+		"white": {"2 3": true, "5 5": true},
+	}, []gomoku.Coord{{X: 3, Y: 3}}, nil)
+	return board
+}
+
+func TestRenderASCII(t *testing.T) {
+	renderer, err := NewRenderer(ASCIIRENDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	board := newRenderTestBoard()
+	var buffer bytes.Buffer
+	renderer.Board(&buffer, &board)
+
+	expected := strings.Join([]string{
+		"   1   2   3   4   5",
+		"1  x-- +---+---+---+",
+		"   |   |   |   |   |",
+		"2  +---+---o --+---+",
+		"   |   |   |   |   |",
+		"3  +---+---X --+---+",
+		"   |   |   |   |   |",
+		"4  +---+---+---+---+",
+		"   |   |   |   |   |",
+		"5  +---+---+---+---o",
+		"",
+	}, "\n")
+
+	if buffer.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, buffer.String())
+	}
+}
+
+func TestRenderANSI(t *testing.T) {
+	renderer, err := NewRenderer(ANSIRENDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	board := newRenderTestBoard()
+	var buffer bytes.Buffer
+	renderer.Clear(&buffer)
+	renderer.Board(&buffer, &board)
+	output := buffer.String()
+
+	if !strings.HasPrefix(output, escapeClear) {
+		t.Error("Expected the screen to be cleared before the board is drawn")
+	}
+
+	expected := []string{
+		escapeBlack + "◉" + escapeReset,
+		escapeWhite + "◯" + escapeReset,
+		escapeBlack + escapeLastMove + "◈" + escapeReset,
+		escapeLabel + "5" + escapeReset,
+	}
+	for _, text := range expected {
+		if !strings.Contains(output, text) {
+			t.Errorf("Expected %q in the board", text)
+		}
+	}
+
+	board.setPosition(board.Spaces(), nil, []gomoku.Coord{{X: 1, Y: 1}})
+	buffer.Reset()
+	renderer.Board(&buffer, &board)
+
+	if !strings.Contains(buffer.String(), escapeWinning+"★"+escapeReset) {
+		t.Error("Expected the winning line to be highlighted")
+	}
+}
+
+func TestSelectRenderer(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"", map[string]string{"LANG": "en_US.UTF-8", "TERM": "xterm-256color"}, ANSIRENDER},
+		{"", map[string]string{"LANG": "en_US.UTF-8", "TERM": "xterm", "NO_COLOR": "1"}, UNICODERENDER},
+		{"", map[string]string{"LANG": "en_US.UTF-8", "TERM": "dumb"}, UNICODERENDER},
+		{"", map[string]string{"LANG": "C", "TERM": "xterm"}, ASCIIRENDER},
+		{"", map[string]string{"LC_ALL": "POSIX", "LANG": "en_US.UTF-8", "TERM": "xterm"}, ASCIIRENDER},
+		{"", map[string]string{"GOMOKU_RENDER": "unicode"}, UNICODERENDER},
+		{"auto", map[string]string{"GOMOKU_RENDER": "unicode"}, ASCIIRENDER},
+		{"ascii", map[string]string{"GOMOKU_RENDER": "ansi"}, ASCIIRENDER},
+	}
+
+	for _, test := range tests {
+		renderer, err := SelectRenderer(test.name, func(key string) string { return test.env[key] })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if renderer.name != test.expected {
+			t.Errorf("Expected %s with '%s' and %v, got %s", test.expected, test.name, test.env, renderer.name)
+		}
+	}
+
+	if _, err := SelectRenderer("fancy", func(string) string { return "" }); err == nil {
+		t.Error("Expected an unknown renderer to be refused")
+	}
+}
+
+func TestRendererClear(t *testing.T) {
+	tests := []struct {
+		env      map[string]string
+		goos     string
+		expected string
+	}{
+		{map[string]string{"LANG": "en_US.UTF-8", "TERM": "xterm-256color"}, "linux", escapeClear},
+		{map[string]string{"LANG": "C", "TERM": "xterm"}, "linux", escapeClear},
+		{map[string]string{"LANG": "en_US.UTF-8", "TERM": "dumb"}, "linux", strings.Repeat("\n", screenHeight)},
+		{map[string]string{"LANG": "C"}, "darwin", strings.Repeat("\n", screenHeight)},
+	}
+
+	for _, test := range tests {
+		renderer, err := SelectRenderer("", func(key string) string { return test.env[key] })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !renderer.color {
+			renderer.clear = detectClear(func(key string) string { return test.env[key] }, test.goos)
+		}
+
+		buffer := bytes.Buffer{}
+		renderer.Clear(&buffer)
+		if buffer.String() != test.expected {
+			t.Errorf("Expected %q to clear the screen with %v on %s, got %q", test.expected, test.env, test.goos, buffer.String())
+		}
+	}
+
+	if clear := detectClear(func(string) string { return "" }, "windows"); clear != clearCommand {
+		t.Errorf("Expected Windows consoles to be cleared with cls, got %s", clear)
+	}
+}
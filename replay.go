@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"go_gomoku/gomoku"
+)
+
+// Replay steps through a saved game, without a connection to a server
+type Replay struct {
+	disablePrint bool
+	renderer     *Renderer
+	path         string
+	record       GameRecord
+	// position is how many of the record's moves are on the board
+	position int
+	// notice is shown below the board until the next command
+	notice string
+}
+
+// NewReplay loads a game saved as psq, sgf or txt, starting at the empty board
+func NewReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := parseRecord(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replay{path: path, record: record, renderer: defaultRenderer()}, nil
+}
+
+// board shows the stones played up to the current position
+func (replay *Replay) board() gomoku.Board {
+	return replay.played().board()
+}
+
+// played is the record of the moves up to the current position
+func (replay *Replay) played() GameRecord {
+	record := replay.record
+	record.Moves = record.Moves[:replay.position]
+	return record
+}
+
+// goTo moves to just after the nth move, staying within the game
+func (replay *Replay) goTo(n int) {
+	if n < 0 {
+		n = 0
+	}
+	if n > len(replay.record.Moves) {
+		n = len(replay.record.Moves)
+	}
+	replay.position = n
+}
+
+// playerName names the player of a color, using the name in the record if there is one
+func (replay *Replay) playerName(color string) string {
+	name := colorName(color)
+	id := replay.record.Black
+	if color == "white" {
+		id = replay.record.White
+	}
+	if id != "" {
+		name += " (" + id + ")"
+	}
+	return name
+}
+
+// describePosition says which move was just played and whose turn it was next
+func (replay *Replay) describePosition() string {
+	total := len(replay.record.Moves)
+	description := "Move " + strconv.Itoa(replay.position) + " of " + strconv.Itoa(total)
+
+	if replay.position == 0 {
+		description += ": the empty board"
+	} else {
+		move := replay.record.Moves[replay.position-1]
+		description += ": " + replay.playerName(move.Color) + " played " + move.Coord.String()
+	}
+
+	if replay.position < total {
+		next := replay.record.Moves[replay.position]
+		description += " ----- " + replay.playerName(next.Color) + " to play"
+	} else if replay.record.Result != "" {
+		description += " ----- Result: " + replay.record.Result
+	}
+
+	return description
+}
+
+func (replay *Replay) print() {
+	if replay.disablePrint {
+		return
+	}
+
+	replay.renderer.Clear(os.Stdout)
+	fmt.Println("Replaying " + replay.path)
+	fmt.Println(replay.describePosition())
+	played := replay.played()
+	board := BoardView{Board: played.board(), lastMove: played.lastMove()}
+	replay.renderer.Board(os.Stdout, &board)
+	fmt.Println("Type nx (or press enter) for the next move, pv for the previous move, gt <n> to go to move n, st for the start, en for the end, qt to quit")
+	if replay.notice != "" {
+		fmt.Println(replay.notice)
+	}
+}
+
+// handleCommand runs a single command, returning false once the viewer should quit
+func (replay *Replay) handleCommand(text string) bool {
+	replay.notice = ""
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		replay.goTo(replay.position + 1)
+		return true
+	}
+
+	if len(text) < 2 {
+		replay.notice = "Unrecognized command!"
+		return true
+	}
+
+	switch text[:2] {
+	case "nx":
+		replay.goTo(replay.position + 1)
+	case "pv":
+		replay.goTo(replay.position - 1)
+	case "st":
+		replay.goTo(0)
+	case "en":
+		replay.goTo(len(replay.record.Moves))
+	case "gt":
+		n, err := strconv.Atoi(strings.TrimSpace(text[2:]))
+		if err != nil {
+			replay.notice = "The syntax for jumping to a move is gt <n>"
+			return true
+		}
+		replay.goTo(n)
+	case "qt":
+		return false
+	default:
+		replay.notice = "Unrecognized command!"
+	}
+
+	return true
+}
+
+// Run shows the board and follows commands until the player quits
+func (replay *Replay) Run(readstream io.Reader) {
+	replay.print()
+
+	scanner := bufio.NewScanner(readstream)
+	for scanner.Scan() {
+		if !replay.handleCommand(scanner.Text()) {
+			return
+		}
+		replay.print()
+	}
+
+	if scanner.Err() != nil {
+		fmt.Fprintf(os.Stderr, "Error reading console input!")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_gomoku/gomoku"
+)
+
+func newTestReplay(t *testing.T) *Replay {
+	path := filepath.Join(t.TempDir(), "game.sgf")
+	err := os.WriteFile(path, []byte("(;GM[4]SZ[15]PB[mock_user1]PW[mock_user2]RE[B+];B[hh];W[hi];B[ih])"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplay(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.disablePrint = true
+	return replay
+}
+
+func countStones(board gomoku.Board) int {
+	return len(board.ListSpaces("black")) + len(board.ListSpaces("white"))
+}
+
+func TestReplaySteps(t *testing.T) {
+	replay := newTestReplay(t)
+
+	if countStones(replay.board()) != 0 {
+		t.Error("Expected the replay to start at the empty board")
+	}
+
+	commands := []struct {
+		command  string
+		position int
+	}{
+		{"", 1},
+		{"nx", 2},
+		{"pv", 1},
+		{"en", 3},
+		{"nx", 3},
+		{"st", 0},
+		{"pv", 0},
+		{"gt 2", 2},
+		{"gt 40", 3},
+	}
+
+	for _, command := range commands {
+		replay.handleCommand(command.command)
+		if replay.position != command.position {
+			t.Errorf("Expected '%s' to go to move %d, got %d", command.command, command.position, replay.position)
+		}
+		if countStones(replay.board()) != command.position {
+			t.Errorf("Expected %d stones after '%s'", command.position, command.command)
+		}
+	}
+}
+
+func TestReplayDescribePosition(t *testing.T) {
+	replay := newTestReplay(t)
+
+	replay.goTo(2)
+	expected := "Move 2 of 3: White (mock_user2) played 9 8 ----- Black (mock_user1) to play"
+	if description := replay.describePosition(); description != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, description)
+	}
+
+	replay.goTo(3)
+	expected = "Move 3 of 3: Black (mock_user1) played 8 9 ----- Result: B+"
+	if description := replay.describePosition(); description != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, description)
+	}
+}
+
+func TestReplayRun(t *testing.T) {
+	replay := newTestReplay(t)
+
+	replay.Run(strings.NewReader("nx\ngt x\nen\nqt\nst\n"))
+
+	if replay.position != 3 {
+		t.Errorf("Expected to stop at the end after quitting, got move %d", replay.position)
+	}
+}
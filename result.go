@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// MatchScore is the running score of the games played in a room
+type MatchScore struct {
+	Wins  map[string]int
+	Draws int
+}
+
+// end finishes the game with result, stops the clocks and adds the result to the score
+func (game *GameRoom) end(result gomoku.Result, now time.Time) {
+	game.End(result, now)
+	game.finish()
+}
+
+// finish clears any open offers once the game is over, adds its result to the score and
+// rates it
+func (game *GameRoom) finish() {
+	game.DrawOfferedBy = ""
+	game.TakebackRequestedBy = ""
+
+	if game.onFinish != nil {
+		game.onFinish(game)
+	}
+
+	if game.Result.WinnerID == "" {
+		game.Score.Draws++
+		return
+	}
+	if game.Score.Wins == nil {
+		game.Score.Wins = make(map[string]int)
+	}
+	game.Score.Wins[game.Result.WinnerID]++
+}
+
+// finishGame rates a game once it's over, and records its result in its tournament, if any
+func (server *Server) finishGame(game *GameRoom) {
+	server.rateGame(game)
+	if game.tournament != nil {
+		server.recordTournamentGame(game)
+	}
+}
+
+// rematch starts a new game in the same room, with the other player going first
+func (game *GameRoom) rematch(now time.Time) {
+	game.Rematches++
+	game.RematchOfferedBy = ""
+	game.Reset(GetOpponentID(game, game.FirstPlayerID), now)
+}
+
+// broadcast sends response to both players and everyone watching
+func (game *GameRoom) broadcast(response Request) []SocketClientResponse {
+	responses := []SocketClientResponse{}
+	for _, player := range game.Players {
+		responses = append(responses, SocketClientResponse{player.SocketClient, response})
+	}
+	return append(responses, game.spectatorResponses(response)...)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+func TestResign(t *testing.T) {
+	server := NewServer()
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	server.games[game.ID] = game
+
+	server.handleResign(Request{GameID: game.ID, UserID: "mock_user2", Action: RESIGN}, nil, game)
+
+	if !game.IsOver {
+		t.Error("Expected game to be over")
+	}
+
+	expected := gomoku.Result{Kind: gomoku.RESIGNED, WinnerID: "mock_user1"}
+	if game.Result != expected {
+		t.Errorf("Expected %v, got %v", expected, game.Result)
+	}
+}
+
+func TestDrawOfferAndAccept(t *testing.T) {
+	server := NewServer()
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	server.games[game.ID] = game
+
+	responses := server.handleDraw(Request{GameID: game.ID, UserID: "mock_user1", Action: DRAW}, nil, game)
+	if game.IsOver || game.DrawOfferedBy != "mock_user1" {
+		t.Fatal("Expected a draw offer to be open")
+	}
+	if len(responses) != 2 || !responses[0].response.Success {
+		t.Errorf("Expected both players to hear about the offer, got %v", responses)
+	}
+
+	responses = server.handleDraw(Request{GameID: game.ID, UserID: "mock_user1", Action: DRAW}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected offering a draw twice to fail")
+	}
+
+	server.handleDraw(Request{GameID: game.ID, UserID: "mock_user2", Action: DRAW}, nil, game)
+	if !game.IsOver || game.Result.Kind != gomoku.DRAWN {
+		t.Errorf("Expected accepted draw to end the game, got %v", game.Result)
+	}
+}
+
+func TestDrawOfferDeclinedByPlaying(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	game.DrawOfferedBy = "mock_user2"
+
+	_, err := game.playTurn("mock_user1", "3 3", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if game.DrawOfferedBy != "" {
+		t.Error("Expected playing on to decline the draw offer")
+	}
+}
+
+func TestDrawWhenBoardIsFull(t *testing.T) {
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	game.Board = gomoku.NewSizedBoard(5, 5)
+	game.Settings.Size = 5
+
+	rows := []string{
+		"BBWWB",
+		"WWBBW",
+		"BBWWB",
+		"WWBBW",
+		"BBWW.",
+	}
+	for x, row := range rows {
+		for y, stone := range row {
+			move := gomoku.Coord{X: x + 1, Y: y + 1}
+			if stone == 'B' {
+				game.PlayMove(move, "black")
+			} else if stone == 'W' {
+				game.PlayMove(move, "white")
+			}
+		}
+	}
+
+	message, err := game.playTurn("mock_user1", "5 5", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !game.IsOver || game.Result.Kind != gomoku.DRAWN {
+		t.Errorf("Expected a full board to be a draw, got %v (%s)", game.Result, message)
+	}
+}
+
+func TestRematch(t *testing.T) {
+	server := NewServer()
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	server.games[game.ID] = game
+
+	responses := server.handleRematch(Request{GameID: game.ID, UserID: "mock_user1", Action: REMATCH}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected a rematch to need the game to be over")
+	}
+
+	game.end(gomoku.Result{Kind: gomoku.WON, WinnerID: "mock_user1"}, time.Now())
+
+	server.handleRematch(Request{GameID: game.ID, UserID: "mock_user1", Action: REMATCH}, nil, game)
+	if !game.IsOver || game.RematchOfferedBy != "mock_user1" {
+		t.Fatal("Expected the rematch to wait for the other player")
+	}
+
+	server.handleRematch(Request{GameID: game.ID, UserID: "mock_user2", Action: REMATCH}, nil, game)
+	if game.IsOver || game.Turn != 1 || game.Rematches != 1 {
+		t.Errorf("Expected a new game to start, got turn %d, rematches %d", game.Turn, game.Rematches)
+	}
+
+	if game.FirstPlayerID != "mock_user2" {
+		t.Errorf("Expected mock_user2 to go first, got %s", game.FirstPlayerID)
+	}
+
+	if len(game.Board.ListSpaces("black")) != 0 || game.PlayerColors() != nil {
+		t.Error("Expected the board and colors to be reset")
+	}
+
+	if game.Score.Wins["mock_user1"] != 1 || game.Score.Wins["mock_user2"] != 0 {
+		t.Errorf("Expected the score to be kept, got %v", game.Score)
+	}
+}
+
+func TestRematchAfterOpponentLeft(t *testing.T) {
+	server := NewServer()
+	game := newTestGame(DefaultGameSettings())
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+	server.games[game.ID] = game
+	game.end(gomoku.Result{Kind: gomoku.DRAWN}, time.Now())
+	game.Players["mock_user2"].Left = true
+
+	responses := server.handleRematch(Request{GameID: game.ID, UserID: "mock_user1", Action: REMATCH}, nil, game)
+	if responses[0].response.Success || responses[0].response.Data != "Your opponent has left the room" {
+		t.Errorf("Expected rematch to fail, got %v", responses[0].response)
+	}
+}
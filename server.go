@@ -1,40 +1,108 @@
 package main
 
 import (
+	"bufio"
+	"io"
 	"log"
-	"math/rand"
 	"net"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
+
+	"github.com/google/uuid"
+	"go_gomoku/gomoku"
 )
 
 // GameRoom contains all info related to a room
 type GameRoom struct {
+	*gomoku.Game
 	M 		      sync.Mutex
 	ID            int
 	Players       map[string]*Player
-	Turn          int
-	Board         Board
-	FirstPlayerID string
-	IsOver        bool
+	// Settings are the options the room was created with, including the game's own
+	Settings      GameSettings
+	// Access says who may join the room, and whether it's listed on the home screen
+	Access        RoomAccess
+	Messages      []Message
+	Spectators    map[*SocketClient]bool
+	// DrawOfferedBy is the player with an open draw offer, if any
+	DrawOfferedBy string
+	Score         MatchScore
+	// Rematches counts the games played in the room after the first
+	Rematches     int
+	// RematchOfferedBy is the player who asked for a rematch, if any
+	RematchOfferedBy string
+	// TakebackRequestedBy is the player who asked to take back their last move, if any
+	TakebackRequestedBy string
+	// onFinish is called whenever a game in the room is over, to rate it
+	onFinish      func(*GameRoom)
+	// tournament is the tournament the game was paired in, if any, and round and pairing
+	// locate it among the tournament's pairings
+	tournament    *Tournament
+	round         int
+	pairing       int
+	// timer fires when the current player's time runs out
+	timer         *time.Timer
+	// noShow fires when a tournament player has taken too long over their first turn
+	noShow        *time.Timer
 }
 
-// PlayMove places a piece
-func (game *GameRoom) PlayMove(move Coord, color string) {
-	moveStr := move.String()
-	game.Board.Spaces[color][moveStr] = true
+// watch subscribes a spectator to the game's moves
+func (game *GameRoom) watch(socketClient *SocketClient) {
+	if game.Spectators == nil {
+		game.Spectators = make(map[*SocketClient]bool)
+	}
+	game.Spectators[socketClient] = true
 }
 
-// GetOpponentID returns the other player's id
-func GetOpponentID(game *GameRoom, userID string) string {
-	for id := range game.Players {
-		if id != userID {
-			return id
+// spectatorResponses sends a copy of response to everyone watching the game, forgetting
+// spectators who have disconnected
+func (game *GameRoom) spectatorResponses(response Request) []SocketClientResponse {
+	response.YourTurn = false
+	response.Instructions = ""
+
+	responses := []SocketClientResponse{}
+	for spectator := range game.Spectators {
+		if spectator.isClosed() {
+			delete(game.Spectators, spectator)
+			continue
 		}
+		responses = append(responses, SocketClientResponse{spectator, response})
 	}
-	return ""
+	return responses
+}
+
+// recentMessages is how much chat is kept for players who resume a game
+const recentMessages = 20
+
+// addMessage keeps a chat message for players who resume the game later
+func (game *GameRoom) addMessage(content string, author string) {
+	game.Messages = append(game.Messages, Message{Content: content, Author: author})
+	if len(game.Messages) > recentMessages {
+		game.Messages = game.Messages[len(game.Messages)-recentMessages:]
+	}
+}
+
+// clocks reports the time each player has left, or nil if the game is untimed
+func (game *GameRoom) clocks() map[string]gomoku.PlayerClock {
+	if game.Clock == nil {
+		return nil
+	}
+	return game.Clock.State(time.Now())
+}
+
+// botToMove returns the id of the bot whose turn it is, if any
+func (game *GameRoom) botToMove() string {
+	id := game.PlayerToMove()
+	if id == "" || game.Players[id].Bot == nil {
+		return ""
+	}
+	return id
+}
+
+// newSessionToken creates the secret a player needs to resume their game
+func newSessionToken() string {
+	return uuid.New().String()
 }
 
 // OtherClient returns the other player's client connection
@@ -49,14 +117,6 @@ func OtherClient(game *GameRoom, userID string) *SocketClient {
 	return player.SocketClient
 }
 
-// IsTurn turns if it's a user's turn or not
-func IsTurn(game *GameRoom, userID string) bool {
-	if userID == game.FirstPlayerID {
-		return game.Turn%2 == 1
-	}
-	return game.Turn%2 == 0
-}
-
 // Server handles all requests and game states
 type Server struct {
 	M      		sync.Mutex
@@ -65,6 +125,11 @@ type Server struct {
 	quit 		chan interface{}
 	listener 	net.Listener
 	wg 			sync.WaitGroup
+	store		GameStore
+	names		*NameRegistry
+	ratings		RatingStore
+	queue		*MatchQueue
+	tournaments	*TournamentRegistry
 }
 
 // NewServer creates a server instances
@@ -73,74 +138,147 @@ func NewServer() Server {
 		games:  make(map[int]*GameRoom),
 		gameID: 0,
 		quit: make(chan interface {}),
+		store: nullStore{},
+		names: NewNameRegistry(nil),
+		ratings: NewMemoryRatingStore(),
+		queue: &MatchQueue{},
+		tournaments: NewTournamentRegistry(nil),
 	}
 }
 
-func (server *Server) createGame(req Request, socketClient *SocketClient) int {
+// UseRatingStore makes the server keep players' ratings in store
+func (server *Server) UseRatingStore(store RatingStore) {
+	server.ratings = store
+}
+
+// UseStore makes the server save its games to store, and restores any games already in it
+func (server *Server) UseStore(store GameStore) error {
 	server.M.Lock()
 	defer server.M.Unlock()
-	defer func() { server.gameID++ }()
 
-	player := Player{
-		UserID:       req.UserID,
-		SocketClient: socketClient,
+	server.store = store
+	saved, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	accounts, err := store.LoadAccounts()
+	if err != nil {
+		return err
 	}
+	server.names = NewNameRegistry(accounts)
 
-	players := make(map[string]*Player)
+	tournaments, err := store.LoadTournaments()
+	if err != nil {
+		return err
+	}
+	server.tournaments = NewTournamentRegistry(tournaments)
 
-	players[req.UserID] = &player
+	restored := 0
+	for _, savedGame := range saved {
+		// one corrupt game shouldn't keep the others from coming back
+		game, err := restoreGame(savedGame)
+		if err != nil {
+			log.Println("Could not restore game:", err)
+			continue
+		}
+		restored++
+
+		// games that ended before the restart were rated then
+		game.onFinish = server.finishGame
+		if link := savedGame.Snapshot.Tournament; link != nil {
+			game.tournament = server.tournaments.tournaments[link.ID]
+			game.round = link.Round
+			game.pairing = link.Pairing
+		}
+		server.games[game.ID] = game
+		if game.ID >= server.gameID {
+			server.gameID = game.ID + 1
+		}
+	}
 
-	server.games[server.gameID] = &GameRoom{
-		ID:      server.gameID,
-		Players: players,
-		Turn:    0,
-		Board:   NewBoard(),
+	for _, game := range server.games {
+		server.watchClock(game)
+		go server.playBotTurn(game)
 	}
+	server.resumeTournaments()
 
-	return server.gameID
+	log.Println("Restored", restored, "games,", len(accounts), "names and", len(tournaments), "tournaments")
+	return nil
 }
 
-func (server *Server) parseMove(req Request, moveStr string) (bool, Coord, Request) {
-	coordinates := strings.Split(moveStr, " ")
+// saveSnapshot saves the whole game; the caller must hold the game's lock
+func (server *Server) saveSnapshot(game *GameRoom) {
+	err := server.store.SaveSnapshot(game.snapshot())
+	if err != nil {
+		log.Println("Could not save game:", err)
+	}
+}
 
-	if len(coordinates) != 2 {
-		errorResponse := Request{
-			GameID:  req.GameID,
-			UserID:  req.UserID,
-			Action:  MOVE,
-			Data:    "The syntax for a move is mv <x> <y>",
-			Success: false,
-		}
+// saveTurn logs the game's latest turn, taking a new snapshot every few turns
+func (server *Server) saveTurn(game *GameRoom) {
+	err := server.store.AppendTurn(game.ID, game.turnRecord(game.History[len(game.History)-1]))
+	if err != nil {
+		log.Println("Could not save turn:", err)
+	}
 
-		return false, Coord{}, errorResponse
+	if game.IsOver || len(game.History)%snapshotInterval == 0 {
+		server.saveSnapshot(game)
 	}
+}
+
+func (server *Server) createGame(req Request, socketClient *SocketClient, settings GameSettings, access RoomAccess) *GameRoom {
+	server.M.Lock()
+	defer server.M.Unlock()
+	defer func() { server.gameID++ }()
+
+	player := Player{
+		UserID:       req.UserID,
+		SocketClient: socketClient,
+		Token:        newSessionToken(),
+	}
+
+	game := newGameRoom(server.gameID, settings)
+	game.Access = access
+	game.onFinish = server.finishGame
+	game.addPlayer(&player)
+	server.games[server.gameID] = game
+
+	return game
+}
+
+// game returns the room with the given ID, or nil if there is none
+func (server *Server) game(gameID int) *GameRoom {
+	server.M.Lock()
+	defer server.M.Unlock()
+	return server.games[gameID]
+}
 
-	x, xErr := strconv.Atoi(coordinates[0])
-	y, yErr := strconv.Atoi(coordinates[1])
+// listGames returns every room, so that they can be looked through without holding the
+// server's lock
+func (server *Server) listGames() []*GameRoom {
+	server.M.Lock()
+	defer server.M.Unlock()
 
-	isNil := xErr != nil || yErr != nil
-	notInRange := x < 1 || x > 15 || y < 1 || y > 15
+	games := []*GameRoom{}
+	for _, game := range server.games {
+		games = append(games, game)
+	}
+	return games
+}
 
-	if isNil || notInRange {
+func (server *Server) parseMove(req Request, moveStr string) (bool, gomoku.Coord, Request) {
+	move, err := server.game(req.GameID).ParseMove(moveStr)
+	if err != nil {
 		errorResponse := Request{
 			GameID:  req.GameID,
 			UserID:  req.UserID,
 			Action:  MOVE,
-			Data:    "Both x and y must be integers from 1 to 15",
+			Data:    err.Error(),
 			Success: false,
 		}
 
-		return false, Coord{}, errorResponse
-	}
-
-	move := Coord{
-		X: x,
-		Y: y,
-	}
-
-	ok, errorResponse := server.games[req.GameID].Board.checkOwnership(req.GameID, req.UserID, move)
-	if !ok {
-		return false, Coord{}, errorResponse
+		return false, gomoku.Coord{}, errorResponse
 	}
 
 	return true, move, Request{}
@@ -156,25 +294,34 @@ func (socketClientResponse *SocketClientResponse) sendBackoff(data []byte, i int
 	if i > 1 {
 		log.Println("Retrying message send! Attempt:", i)
 	}
-	if socketClientResponse.socketClient.Closed {
-		return
+	socketClient := socketClientResponse.socketClient
+
+	// Data is closed along with the socket, so only send while holding the lock
+	socketClient.M.Lock()
+	done := socketClient.Closed
+	if !done {
+		select {
+		case socketClient.Data <- data:
+			done = true
+		default:
+		}
 	}
+	socketClient.M.Unlock()
 
-	select {
-	case socketClientResponse.socketClient.Data <- data:
+	if done || i > 5 {
 		return
-	default:
-		time.Sleep(500 * time.Millisecond)
-
-		if i > 5 {
-			return
-		}
-		socketClientResponse.sendBackoff(data, i+1)
 	}
+	time.Sleep(500 * time.Millisecond)
+	socketClientResponse.sendBackoff(data, i+1)
 }
 
 // SendToClient tries to send a request to socketClient, with backoff
 func (socketClientResponse *SocketClientResponse) send() {
+	// bots have no connection
+	if socketClientResponse.socketClient == nil {
+		return
+	}
+
 	data, err := gobToBytes(socketClientResponse.response)
 
 	if err != nil {
@@ -186,24 +333,160 @@ func (socketClientResponse *SocketClientResponse) send() {
 }
 
 func (server *Server) handleCreate(req Request, socketClient *SocketClient) []SocketClientResponse {
-	gameID := server.createGame(req, socketClient)
+	access, options, err := parseRoomAccess(req.Data)
+	settings := GameSettings{}
+	if err == nil {
+		settings, err = parseGameSettings(options)
+	}
+	if err != nil {
+		response := Request{
+			Action:  CREATE,
+			Success: false,
+			Data:    err.Error(),
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	if settings.Rated && server.names.Name(req.UserID) == "" {
+		response := Request{
+			Action:  CREATE,
+			Success: false,
+			Data:    "Choose a name with nm before playing rated games",
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	game := server.createGame(req, socketClient, settings, access)
+	gameID := game.ID
+	game.M.Lock()
+	defer game.M.Unlock()
+
 	response := Request{
-		GameID:  gameID,
-		Action:  CREATE,
-		Success: true,
+		GameID:     gameID,
+		Action:     CREATE,
+		Success:    true,
+		Settings:   settings,
+		Token:      game.Players[req.UserID].Token,
+		InviteCode: access.InviteCode,
+	}
+
+	if settings.Bot == "" {
+		server.saveSnapshot(game)
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	botID := "bot-" + strconv.Itoa(gameID)
+	game.addPlayer(&Player{
+		UserID: botID,
+		Bot:    NewBot(settings.Bot),
+	})
+	game.begin(req.UserID)
+	server.saveSnapshot(game)
+	server.watchClock(game)
+
+	joinedResponse := Request{
+		GameID:   gameID,
+		UserID:   botID,
+		Action:   OTHERJOINED,
+		YourTurn: game.FirstPlayerID == req.UserID,
+		Success:  true,
+		Turn:     game.Turn,
+		Settings: settings,
+		Clocks:   game.clocks(),
+	}
+	if joinedResponse.YourTurn {
+		joinedResponse.Instructions = game.Opening.Instructions()
 	}
+
 	return []SocketClientResponse{
 		SocketClientResponse{
 			socketClient,
 			response,
 		},
+		SocketClientResponse{
+			socketClient,
+			joinedResponse,
+		},
+	}
+}
+
+// playBotTurn lets a bot move if it is a bot's turn in the game
+func (server *Server) playBotTurn(game *GameRoom) {
+	game.M.Lock()
+	botID := game.botToMove()
+	if botID == "" {
+		game.M.Unlock()
+		return
+	}
+	turn := game.Players[botID].Bot.prepareTurn(game, botID)
+	turnNumber := game.Turn
+	game.M.Unlock()
+
+	data := turn.choose()
+	if server.playBotMove(game, botID, turnNumber, data) {
+		return
+	}
+
+	// a rejected move would leave the game waiting on the bot for good, so try once more
+	log.Println("Bot move", data, "was rejected in game", game.ID)
+	data = turn.fallback()
+	if !server.playBotMove(game, botID, turnNumber, data) {
+		log.Println("Bot fallback move", data, "was rejected in game", game.ID)
+	}
+}
+
+// playBotMove sends a bot's move for turnNumber, reporting whether the game moved on
+func (server *Server) playBotMove(game *GameRoom, botID string, turnNumber int, data string) bool {
+	req := Request{
+		GameID: game.ID,
+		UserID: botID,
+		Action: MOVE,
+		Data:   data,
 	}
+	server.handleRequest(req, nil)
+
+	game.M.Lock()
+	defer game.M.Unlock()
+	return game.IsOver || game.Turn != turnNumber
 }
 
 func (server *Server) handleJoin(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	if activeGame == nil {
+		response := Request{
+			GameID:  req.GameID,
+			Action:  JOIN,
+			Success: false,
+			Data:    "That game doesn't exist",
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
 	otherClient := OtherClient(activeGame, req.UserID)
 
-	if otherClient == nil {
+	if activeGame.Players[req.UserID] != nil {
 		response := Request{
 			GameID:  req.GameID,
 			Action:  JOIN,
@@ -235,7 +518,41 @@ func (server *Server) handleJoin(req Request, socketClient *SocketClient, active
 		}
 	}
 
-	if otherClient.Closed {
+	if err := activeGame.Access.admitsPlayer(server.names.Name(req.UserID), req.Data); err != nil {
+		response := Request{
+			GameID:  req.GameID,
+			Action:  JOIN,
+			Success: false,
+			Data:    err.Error(),
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	if activeGame.Settings.Rated && server.names.Name(req.UserID) == "" {
+		response := Request{
+			GameID:  req.GameID,
+			Action:  JOIN,
+			Success: false,
+			Data:    "Choose a name with nm before playing rated games",
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				response,
+			},
+		}
+	}
+
+	// the other player of a restored game has no connection until they come back
+	otherLeft := activeGame.Players[GetOpponentID(activeGame, req.UserID)].Left
+	if otherLeft || otherClient != nil && otherClient.isClosed() {
 		response := Request{
 			GameID:  req.GameID,
 			Action:  JOIN,
@@ -254,15 +571,14 @@ func (server *Server) handleJoin(req Request, socketClient *SocketClient, active
 	player := Player{
 		UserID:       req.UserID,
 		SocketClient: socketClient,
+		Token:        newSessionToken(),
 	}
 
-	activeGame.Players[req.UserID] = &player
-	activeGame.Turn = 1
-	activeGame.FirstPlayerID = req.UserID
+	activeGame.addPlayer(&player)
+	activeGame.begin(req.UserID)
+	server.saveSnapshot(activeGame)
+	server.watchClock(activeGame)
 	opponentID := GetOpponentID(activeGame, req.UserID)
-	if rand.Intn(2) == 0 {
-		activeGame.FirstPlayerID = opponentID
-	}
 
 	// OpponentID is used to alert player to opponent's ID -- should be in Data
 	response1 := Request{
@@ -272,6 +588,10 @@ func (server *Server) handleJoin(req Request, socketClient *SocketClient, active
 		YourTurn: activeGame.FirstPlayerID == req.UserID,
 		Success:  true,
 		Turn:     activeGame.Turn,
+		Settings: activeGame.Settings,
+		Token:    player.Token,
+		Clocks:   activeGame.clocks(),
+		Names:    server.gameNames(activeGame),
 	}
 
 	// Req.UserID is used to alert player to new player ID -- should be in Data
@@ -282,6 +602,15 @@ func (server *Server) handleJoin(req Request, socketClient *SocketClient, active
 		YourTurn: activeGame.FirstPlayerID != req.UserID,
 		Success:  true,
 		Turn:     activeGame.Turn,
+		Settings: activeGame.Settings,
+		Clocks:   activeGame.clocks(),
+		Names:    server.gameNames(activeGame),
+	}
+
+	if response1.YourTurn {
+		response1.Instructions = activeGame.Opening.Instructions()
+	} else {
+		response2.Instructions = activeGame.Opening.Instructions()
 	}
 
 	return []SocketClientResponse{
@@ -296,15 +625,119 @@ func (server *Server) handleJoin(req Request, socketClient *SocketClient, active
 	}
 }
 
+// handleResume binds a returning player to their new connection and sends them the whole game
+func (server *Server) handleResume(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	errorResponse := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  RESUME,
+		Success: false,
+	}
+
+	if activeGame == nil {
+		errorResponse.Data = "That game no longer exists"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	player := activeGame.Players[req.UserID]
+	if player == nil || player.Token == "" || player.Token != req.Token {
+		errorResponse.Data = "Could not resume that game"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	player.SocketClient = socketClient
+	if socketClient != nil {
+		socketClient.UserID = req.UserID
+	}
+	opponentID := GetOpponentID(activeGame, req.UserID)
+
+	// like JOIN, UserID is the opponent's ID
+	response := Request{
+		GameID:      req.GameID,
+		UserID:      opponentID,
+		Action:      RESUME,
+		Success:     true,
+		GameOver:    activeGame.IsOver,
+		Board:       activeGame.Board.Spaces(),
+		Colors:      activeGame.PlayerColors(),
+		LastMove:    activeGame.LastMove(),
+		WinningLine: activeGame.WinningLine,
+		Settings:    activeGame.Settings,
+		Token:       player.Token,
+		InviteCode:  activeGame.Access.InviteCode,
+		Messages:    activeGame.Messages,
+		Clocks:      activeGame.clocks(),
+		Result:      activeGame.Result,
+		Names:       server.gameNames(activeGame),
+	}
+
+	if !activeGame.IsOver {
+		response.Turn = activeGame.Turn
+		response.YourTurn = activeGame.Turn > 0 && IsTurn(activeGame, req.UserID)
+	}
+
+	if response.YourTurn {
+		response.Instructions = activeGame.Opening.Instructions()
+	}
+
+	otherResponse := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  OTHERRESUMED,
+		Success: true,
+	}
+
+	return []SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+		SocketClientResponse{
+			OtherClient(activeGame, req.UserID),
+			otherResponse,
+		},
+	}
+}
+
 func (server *Server) handleMessage(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	if activeGame == nil || activeGame.Players[req.UserID] == nil {
+		errorResponse := Request{
+			GameID:  req.GameID,
+			UserID:  req.UserID,
+			Action:  MESSAGE,
+			Data:    "You're not playing in that game!",
+			Success: false,
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
 	response := Request{
 		GameID:  req.GameID,
 		UserID:  req.UserID,
 		Action:  MESSAGE,
 		Data:    req.Data,
 		Success: true,
+		Names:   server.gameNames(activeGame),
 	}
 	otherClient := OtherClient(activeGame, req.UserID)
+	activeGame.addMessage(req.Data, req.UserID)
+	server.saveSnapshot(activeGame)
 
 	return []SocketClientResponse{
 		SocketClientResponse{
@@ -321,16 +754,13 @@ func (server *Server) handleMove(req Request, socketClient *SocketClient, active
 		Action: MOVE,
 	}
 
-	valid := IsTurn(activeGame, req.UserID)
-	gameOver := false
-	var message string
-
-	if !valid {
+	// spectators and anyone else outside the game can't move in it
+	if reason := playingError(req, activeGame); reason != "" {
 		errorResponse := Request{
 			GameID:  req.GameID,
 			UserID:  req.UserID,
 			Action:  MOVE,
-			Data:    "It's not your turn!",
+			Data:    reason,
 			Success: false,
 		}
 
@@ -342,109 +772,46 @@ func (server *Server) handleMove(req Request, socketClient *SocketClient, active
 		}
 	}
 
-	switch turn := activeGame.Turn; turn {
-	case 1:
-		if activeGame.Turn == 1 {
-			moveStrs := strings.Split(req.Data, ", ")
-			if len(moveStrs) != 3 {
-				errorResponse := Request{
-					GameID:  req.GameID,
-					UserID:  req.UserID,
-					Action:  MOVE,
-					Data:    "Please choose exectly three sets of two values",
-					Success: false,
-				}
-
-				return []SocketClientResponse{
-					SocketClientResponse{
-						socketClient,
-						errorResponse,
-					},
-				}
-			}
-
-			moves := [3]Coord{}
-			for i, moveStr := range moveStrs {
-				ok, move, errorResponse := server.parseMove(req, moveStr)
+	// the timer may not have fired yet for a player whose time has run out
+	timeoutResponses := server.checkClock(activeGame, time.Now())
+	if timeoutResponses != nil {
+		return timeoutResponses
+	}
 
-				if !ok {
-					return []SocketClientResponse{
-						SocketClientResponse{
-							socketClient,
-							errorResponse,
-						},
-					}
-				}
+	message, err := activeGame.playTurn(req.UserID, req.Data, time.Now())
 
-				moves[i] = move
-			}
-
-			activeGame.PlayMove(moves[0], "black")
-			activeGame.PlayMove(moves[1], "black")
-			activeGame.PlayMove(moves[2], "white")
-			message = "(played black on " + moves[0].String() + ", black on " + moves[1].String() + ", and white on " + moves[2].String() + " )"
-		}
-	case 2:
-		response.Colors = make(map[string]string)
-		opponentID := GetOpponentID(activeGame, req.UserID)
-
-		if req.Data == "pass" {
-			message = "(passed and is now black -- back to player 1)"
-
-			activeGame.Players[req.UserID].Color = "black"
-			activeGame.Players[opponentID].Color = "white"
-			response.Colors[req.UserID] = "black"
-			response.Colors[opponentID] = "white"
-		} else {
-			ok, move, errorResponse := server.parseMove(req, req.Data)
-
-			if !ok {
-				return []SocketClientResponse{
-					SocketClientResponse{
-						socketClient,
-						errorResponse,
-					},
-				}
-			}
-
-			activeGame.Players[req.UserID].Color = "white"
-			activeGame.Players[opponentID].Color = "black"
-			response.Colors[req.UserID] = "white"
-			response.Colors[opponentID] = "black"
-
-			activeGame.PlayMove(move, "white")
-			message = "(played on " + req.Data + " )"
-		}
-	default:
-		ok, move, errorResponse := server.parseMove(req, req.Data)
-
-		if !ok {
-			return []SocketClientResponse{
-				SocketClientResponse{
-					socketClient,
-					errorResponse,
-				},
-			}
+	if err != nil {
+		errorResponse := Request{
+			GameID:  req.GameID,
+			UserID:  req.UserID,
+			Action:  MOVE,
+			Data:    err.Error(),
+			Success: false,
 		}
 
-		activeGame.PlayMove(move, activeGame.Players[req.UserID].Color)
-
-		gameOver = activeGame.Board.checkForWin(move, activeGame.Players[req.UserID].Color)
-		if gameOver {
-			activeGame.IsOver = true
-			response.GameOver = true
-			message = "won!!!! (" + req.Data + " )"
-		} else {
-			message = "(played on " + req.Data + " )"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
 		}
 	}
 
+	server.saveTurn(activeGame)
+	server.watchClock(activeGame)
+	server.watchNoShow(activeGame)
+
 	response.Success = true
-	response.Board = activeGame.Board.Spaces
+	response.GameOver = activeGame.IsOver
+	response.Board = activeGame.Board.Spaces()
+	response.LastMove = activeGame.LastMove()
+	response.WinningLine = activeGame.WinningLine
+	response.Colors = activeGame.PlayerColors()
+	response.Clocks = activeGame.clocks()
+	response.Result = activeGame.Result
 	response.Data = message
 
-	if !gameOver {
-		activeGame.Turn++
+	if !activeGame.IsOver {
 		response.YourTurn = false
 		response.Turn = activeGame.Turn
 	}
@@ -461,10 +828,15 @@ func (server *Server) handleMove(req Request, socketClient *SocketClient, active
 		Turn: response.Turn,
 		Colors: response.Colors,
 		Board: response.Board,
+		LastMove: response.LastMove,
+		WinningLine: response.WinningLine,
 		Home: response.Home,
+		Clocks: response.Clocks,
+		Result: response.Result,
+		Instructions: activeGame.Opening.Instructions(),
 	}
 
-	return []SocketClientResponse{
+	responses := []SocketClientResponse{
 		SocketClientResponse{
 			socketClient,
 			response,
@@ -474,17 +846,515 @@ func (server *Server) handleMove(req Request, socketClient *SocketClient, active
 			otherClientResponse,
 		},
 	}
+
+	return append(responses, activeGame.spectatorResponses(response)...)
+}
+
+// playingError explains why a player can't resign or offer a draw, or returns "" if they can
+func playingError(req Request, activeGame *GameRoom) string {
+	if activeGame == nil || activeGame.Players[req.UserID] == nil {
+		return "You're not playing in that game!"
+	}
+
+	if activeGame.IsOver {
+		return "The game is over!"
+	}
+
+	if activeGame.Turn == 0 {
+		return "The game hasn't started yet!"
+	}
+
+	return ""
+}
+
+// gameOverResponse tells everyone in the game how it ended
+func (game *GameRoom) gameOverResponse(req Request, data string) Request {
+	return Request{
+		GameID:      req.GameID,
+		UserID:      req.UserID,
+		Action:      req.Action,
+		Success:     true,
+		GameOver:    true,
+		Data:        data,
+		Board:       game.Board.Spaces(),
+		Colors:      game.PlayerColors(),
+		LastMove:    game.LastMove(),
+		WinningLine: game.WinningLine,
+		Clocks:      game.clocks(),
+		Result:      game.Result,
+	}
+}
+
+func (server *Server) handleResign(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	if reason := playingError(req, activeGame); reason != "" {
+		errorResponse := Request{
+			GameID:  req.GameID,
+			UserID:  req.UserID,
+			Action:  RESIGN,
+			Data:    reason,
+			Success: false,
+		}
+
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	activeGame.end(gomoku.Result{Kind: gomoku.RESIGNED, WinnerID: GetOpponentID(activeGame, req.UserID)}, time.Now())
+	server.saveSnapshot(activeGame)
+	server.watchClock(activeGame)
+
+	return activeGame.broadcast(activeGame.gameOverResponse(req, "resigned"))
+}
+
+// handleDraw offers a draw, or accepts the opponent's offer
+func (server *Server) handleDraw(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	errorResponse := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  DRAW,
+		Success: false,
+	}
+
+	if reason := playingError(req, activeGame); reason != "" {
+		errorResponse.Data = reason
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	opponentID := GetOpponentID(activeGame, req.UserID)
+
+	if activeGame.DrawOfferedBy == opponentID {
+		activeGame.end(gomoku.Result{Kind: gomoku.DRAWN}, time.Now())
+		server.saveSnapshot(activeGame)
+		server.watchClock(activeGame)
+
+		return activeGame.broadcast(activeGame.gameOverResponse(req, "accepted the draw offer"))
+	}
+
+	if activeGame.DrawOfferedBy == req.UserID {
+		errorResponse.Data = "You already offered a draw"
+	} else if activeGame.Players[opponentID].Bot != nil {
+		errorResponse.Data = "The bot declines your draw offer"
+	}
+
+	if errorResponse.Data != "" {
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	activeGame.DrawOfferedBy = req.UserID
+	server.saveSnapshot(activeGame)
+
+	response := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  DRAW,
+		Success: true,
+		Data:    "offered a draw",
+	}
+
+	return activeGame.broadcast(response)
+}
+
+// handleRematch asks for a rematch, and starts it once both players have asked. Bots always
+// accept.
+func (server *Server) handleRematch(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	errorResponse := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  REMATCH,
+		Success: false,
+	}
+
+	opponentID := ""
+	if activeGame != nil {
+		opponentID = GetOpponentID(activeGame, req.UserID)
+	}
+
+	switch {
+	case activeGame == nil || activeGame.Players[req.UserID] == nil:
+		errorResponse.Data = "You're not playing in that game!"
+	case !activeGame.IsOver:
+		errorResponse.Data = "The game isn't over yet!"
+	case opponentID == "" || activeGame.Players[opponentID].Left:
+		errorResponse.Data = "Your opponent has left the room"
+	case activeGame.RematchOfferedBy == req.UserID:
+		errorResponse.Data = "You already asked for a rematch"
+	case activeGame.tournament != nil:
+		errorResponse.Data = "Tournament games can't be rematched"
+	}
+
+	if errorResponse.Data != "" {
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	if activeGame.RematchOfferedBy != opponentID && activeGame.Players[opponentID].Bot == nil {
+		activeGame.RematchOfferedBy = req.UserID
+		server.saveSnapshot(activeGame)
+
+		response := Request{
+			GameID:   req.GameID,
+			UserID:   req.UserID,
+			Action:   REMATCH,
+			Success:  true,
+			GameOver: true,
+			Data:     "wants a rematch",
+		}
+
+		return activeGame.broadcast(response)
+	}
+
+	activeGame.rematch(time.Now())
+	server.saveSnapshot(activeGame)
+	server.watchClock(activeGame)
+
+	response := Request{
+		GameID:   req.GameID,
+		UserID:   req.UserID,
+		Action:   REMATCH,
+		Success:  true,
+		Turn:     activeGame.Turn,
+		Board:    activeGame.Board.Spaces(),
+		Settings: activeGame.Settings,
+		Clocks:   activeGame.clocks(),
+		Score:    activeGame.Score,
+	}
+
+	responses := []SocketClientResponse{}
+	for id, player := range activeGame.Players {
+		playerResponse := response
+		playerResponse.YourTurn = activeGame.FirstPlayerID == id
+		if playerResponse.YourTurn {
+			playerResponse.Instructions = activeGame.Opening.Instructions()
+		}
+		responses = append(responses, SocketClientResponse{player.SocketClient, playerResponse})
+	}
+
+	return append(responses, activeGame.spectatorResponses(response)...)
+}
+
+// handleTakeback asks to take back the player's last move, or accepts or declines ("no") the
+// opponent's request. Bots always accept.
+func (server *Server) handleTakeback(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	errorResponse := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  TAKEBACK,
+		Success: false,
+	}
+
+	if reason := playingError(req, activeGame); reason != "" {
+		errorResponse.Data = reason
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	opponentID := GetOpponentID(activeGame, req.UserID)
+	requestedBy := activeGame.TakebackRequestedBy
+
+	response := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  TAKEBACK,
+		Success: true,
+	}
+
+	switch {
+	case requestedBy == opponentID && req.Data == "no":
+		activeGame.TakebackRequestedBy = ""
+		server.saveSnapshot(activeGame)
+		response.Data = "declined the takeback"
+		return activeGame.broadcast(response)
+	case req.Data == "no":
+		errorResponse.Data = "There's no takeback to decline"
+	case requestedBy == req.UserID:
+		errorResponse.Data = "You already asked to take back your move"
+	case requestedBy != opponentID && activeGame.LastTurnBy(req.UserID) == -1:
+		errorResponse.Data = "You have no moves to take back"
+	case requestedBy != opponentID && activeGame.Players[opponentID].Bot == nil:
+		activeGame.TakebackRequestedBy = req.UserID
+		server.saveSnapshot(activeGame)
+		response.Data = "asked to take back their last move"
+		return activeGame.broadcast(response)
+	}
+
+	if errorResponse.Data != "" {
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	// the player who asked gets their turn back
+	takerID := req.UserID
+	response.Data = "took back their last move"
+	if requestedBy == opponentID {
+		takerID = opponentID
+		response.Data = "accepted the takeback"
+	}
+
+	turn, err := activeGame.TakeBack(takerID, time.Now())
+	if err != nil {
+		log.Println("Could not take back move:", err)
+		errorResponse.Data = "Could not take back the move"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	err = server.store.AppendTurn(activeGame.ID, TurnRecord{Rematch: activeGame.Rematches, Turn: turn, Time: time.Now(), Undo: true})
+	if err != nil {
+		log.Println("Could not save takeback:", err)
+	}
+	server.saveSnapshot(activeGame)
+	server.watchClock(activeGame)
+	server.watchNoShow(activeGame)
+
+	response.Turn = activeGame.Turn
+	response.Board = activeGame.Board.Spaces()
+	response.LastMove = activeGame.LastMove()
+	response.Colors = activeGame.PlayerColors()
+	response.Clocks = activeGame.clocks()
+
+	responses := []SocketClientResponse{}
+	for id, player := range activeGame.Players {
+		playerResponse := response
+		playerResponse.YourTurn = IsTurn(activeGame, id)
+		if playerResponse.YourTurn {
+			playerResponse.Instructions = activeGame.Opening.Instructions()
+		}
+		responses = append(responses, SocketClientResponse{player.SocketClient, playerResponse})
+	}
+
+	return append(responses, activeGame.spectatorResponses(response)...)
+}
+
+// watchClock ends the game when the current player's time runs out
+func (server *Server) watchClock(game *GameRoom) {
+	if game.timer != nil {
+		game.timer.Stop()
+	}
+
+	if game.Clock == nil || game.Clock.Running == "" {
+		return
+	}
+
+	game.timer = time.AfterFunc(time.Until(game.Clock.Deadline()), func() {
+		game.M.Lock()
+		defer game.M.Unlock()
+
+		for _, socketClientResponse := range server.checkClock(game, time.Now()) {
+			socketClientResponse.send()
+		}
+	})
+}
+
+// checkClock ends the game if the current player's time has run out, returning the
+// responses that tell everyone, or nil if there is still time
+func (server *Server) checkClock(game *GameRoom, now time.Time) []SocketClientResponse {
+	if game.Clock == nil || game.IsOver {
+		return nil
+	}
+
+	loserID := game.Clock.Flagged(now)
+	if loserID == "" {
+		return nil
+	}
+
+	game.end(gomoku.Result{Kind: gomoku.TIMEDOUT, WinnerID: GetOpponentID(game, loserID)}, now)
+	server.saveSnapshot(game)
+
+	response := Request{
+		GameID:      game.ID,
+		UserID:      loserID,
+		Action:      TIMEOUT,
+		Success:     true,
+		GameOver:    true,
+		Data:        "ran out of time!",
+		Board:       game.Board.Spaces(),
+		Colors:      game.PlayerColors(),
+		LastMove:    game.LastMove(),
+		WinningLine: game.WinningLine,
+		Clocks:      game.clocks(),
+		Result:      game.Result,
+	}
+
+	return game.broadcast(response)
+}
+
+// handleWatch lets anyone follow the moves of a game in progress
+func (server *Server) handleWatch(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	errorResponse := Request{
+		GameID:  req.GameID,
+		Action:  WATCH,
+		Success: false,
+	}
+
+	if activeGame == nil {
+		errorResponse.Data = "That game doesn't exist"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	if err := activeGame.Access.admitsSpectator(req.Data); err != nil {
+		errorResponse.Data = err.Error()
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	if activeGame.Turn == 0 && !activeGame.IsOver {
+		errorResponse.Data = "That game hasn't started yet"
+		return []SocketClientResponse{
+			SocketClientResponse{
+				socketClient,
+				errorResponse,
+			},
+		}
+	}
+
+	activeGame.watch(socketClient)
+
+	response := Request{
+		GameID:      req.GameID,
+		Action:      WATCH,
+		Success:     true,
+		GameOver:    activeGame.IsOver,
+		Board:       activeGame.Board.Spaces(),
+		Colors:      activeGame.PlayerColors(),
+		LastMove:    activeGame.LastMove(),
+		WinningLine: activeGame.WinningLine,
+		Settings:    activeGame.Settings,
+		Clocks:      activeGame.clocks(),
+		Result:      activeGame.Result,
+		Names:       server.gameNames(activeGame),
+	}
+
+	if !activeGame.IsOver {
+		response.Turn = activeGame.Turn
+	}
+
+	return []SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+	}
+}
+
+// handleExport sends the moves of a game so far, written in the notation asked for
+func (server *Server) handleExport(req Request, socketClient *SocketClient, activeGame *GameRoom) []SocketClientResponse {
+	response := Request{
+		GameID:  req.GameID,
+		UserID:  req.UserID,
+		Action:  EXPORT,
+		Success: false,
+	}
+
+	if activeGame == nil {
+		response.Data = "That game doesn't exist"
+	} else if activeGame.Access.Private && activeGame.Players[req.UserID] == nil && !activeGame.Spectators[socketClient] {
+		// like watching, exporting a private game needs its invite code or password, which
+		// spectators have already given
+		response.Data = "That game is private: watch it with its invite code or password to export it"
+	} else if activeGame.Turn == 0 && !activeGame.IsOver {
+		response.Data = "That game hasn't started yet"
+	} else {
+		exported, err := exportRecord(activeGame.record(), req.Data)
+		if err != nil {
+			response.Data = err.Error()
+		} else {
+			response.Data = exported
+			response.Success = true
+		}
+	}
+
+	return []SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+	}
 }
 
 func (server *Server) handleRequest(req Request, socketClient *SocketClient) {
-	log.Println("Request:", socketClient, req)
+	log.Printf("Request: %p %v", socketClient, req)
+
+	// a connection acts for the user it signed in as, so that knowing someone's ID isn't
+	// enough to play for them. Bots and tests have no connection.
+	if socketClient != nil && req.Action != REGISTER && req.Action != RESUME {
+		if socketClient.UserID == "" {
+			response := SocketClientResponse{socketClient, Request{
+				GameID:  req.GameID,
+				Action:  req.Action,
+				Success: false,
+				Data:    "Please sign in first",
+			}}
+			response.send()
+			return
+		}
+		req.UserID = socketClient.UserID
+	}
+
+	activeGame := server.game(req.GameID)
+
+	// private rooms can be joined and watched by their invite code instead of their ID
+	if (req.Action == JOIN || req.Action == WATCH) && req.GameID == -1 {
+		activeGame = server.gameByInviteCode(req.Data)
+		if activeGame != nil {
+			req.GameID = activeGame.ID
+		}
+	}
+
+	// the home screen looks through every room, so it's listed without holding any of their locks
+	if req.Action == HOME {
+		server.handleHome(req, socketClient, activeGame)
+		return
+	}
 
-	activeGame := server.games[req.GameID]
 	if activeGame != nil {
 		activeGame.M.Lock()
 		defer activeGame.M.Unlock()
 	}
 
+	// players who make or join a game themselves stop waiting for one
+	if req.Action == CREATE || req.Action == JOIN {
+		server.queue.remove(req.UserID)
+	}
+
 	socketClientResponses := []SocketClientResponse{}
 	switch action := req.Action; action {
 	case CREATE:
@@ -495,8 +1365,28 @@ func (server *Server) handleRequest(req Request, socketClient *SocketClient) {
 		socketClientResponses = server.handleMessage(req, socketClient, activeGame)
 	case MOVE:
 		socketClientResponses = server.handleMove(req, socketClient, activeGame)
-	case HOME:
-		socketClientResponses = server.handleSendToHome(socketClient)
+	case WATCH:
+		socketClientResponses = server.handleWatch(req, socketClient, activeGame)
+	case RESIGN:
+		socketClientResponses = server.handleResign(req, socketClient, activeGame)
+	case DRAW:
+		socketClientResponses = server.handleDraw(req, socketClient, activeGame)
+	case REMATCH:
+		socketClientResponses = server.handleRematch(req, socketClient, activeGame)
+	case TAKEBACK:
+		socketClientResponses = server.handleTakeback(req, socketClient, activeGame)
+	case RESUME:
+		socketClientResponses = server.handleResume(req, socketClient, activeGame)
+	case EXPORT:
+		socketClientResponses = server.handleExport(req, socketClient, activeGame)
+	case REGISTER:
+		socketClientResponses = server.handleRegister(req, socketClient)
+	case LEADERBOARD:
+		socketClientResponses = server.handleLeaderboard(req, socketClient)
+	case QUEUE:
+		socketClientResponses = server.handleQueue(req, socketClient)
+	case TOURNAMENT:
+		socketClientResponses = server.handleTournament(req, socketClient)
 	default:
 		log.Println("Unrecognized action:", req.Action)
 	}
@@ -504,30 +1394,104 @@ func (server *Server) handleRequest(req Request, socketClient *SocketClient) {
 	for _, socketClientResponse := range socketClientResponses {
 		socketClientResponse.send()
 	}
+
+	// tournament games start on the connection each player used last, once they have signed in
+	if socketClient == nil || socketClient.UserID == req.UserID {
+		server.tournaments.seen(req.UserID, socketClient)
+	}
+
+	// once everyone has heard about the new game or move, a bot may reply
+	if (req.Action == CREATE || req.Action == MOVE || req.Action == REMATCH) && len(socketClientResponses) > 0 && socketClientResponses[0].response.Success {
+		game := server.game(socketClientResponses[0].response.GameID)
+		if game != nil {
+			go server.playBotTurn(game)
+		}
+	}
+}
+
+// handleRegister gives the player a name, or signs them back in to theirs
+func (server *Server) handleRegister(req Request, socketClient *SocketClient) []SocketClientResponse {
+	response := Request{
+		UserID: req.UserID,
+		Action: REGISTER,
+	}
+
+	// guests sign in without a name
+	var credential string
+	var err error
+	if req.Data == "" {
+		credential, err = server.names.SignIn(req.UserID, req.Token)
+	} else {
+		credential, err = server.names.Register(req.UserID, req.Data, req.Token)
+	}
+
+	if err != nil {
+		response.Data = err.Error()
+	} else {
+		response.Success = true
+		response.Data = req.Data
+		response.Token = credential
+		if socketClient != nil {
+			socketClient.UserID = req.UserID
+		}
+
+		err = server.store.SaveAccounts(server.names.Accounts())
+		if err != nil {
+			log.Println("Could not save names:", err)
+		}
+	}
+
+	return []SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+	}
+}
+
+// handleHome sends the player to the home screen. Players and spectators say which game
+// they are leaving; a room that is still open stays open for its host to be joined.
+func (server *Server) handleHome(req Request, socketClient *SocketClient, activeGame *GameRoom) {
+	if activeGame != nil {
+		activeGame.M.Lock()
+		delete(activeGame.Spectators, socketClient)
+		if player := activeGame.Players[req.UserID]; player != nil && len(activeGame.Players) == 2 {
+			player.Left = true
+		}
+		activeGame.M.Unlock()
+	}
+
+	for _, socketClientResponse := range server.handleSendToHome(socketClient) {
+		socketClientResponse.send()
+	}
+
+	if socketClient == nil || socketClient.UserID == req.UserID {
+		server.tournaments.seen(req.UserID, socketClient)
+	}
 }
 
+// handleSendToHome lists the open and live rooms; the caller must not hold any game's lock
 func (server *Server) handleSendToHome(socketClient *SocketClient) []SocketClientResponse {
 	home := []OpenRoom{}
+	live := []OpenRoom{}
 
-	for _, game := range server.games {
-		if !game.IsOver && len(game.Players) == 1 {
-			var userID string
-			for id := range game.Players {
-				userID = id
-			}
-
-			openRoom := OpenRoom{
-				ID:     game.ID,
-				UserID: userID,
-			}
+	for _, game := range server.listGames() {
+		room, listed, isLive := server.homeListing(game)
+		if !listed {
+			continue
+		}
 
-			home = append(home, openRoom)
+		if isLive {
+			live = append(live, room)
+		} else {
+			home = append(home, room)
 		}
 	}
 
 	response := Request{
 		Action: HOME,
 		Home:   home,
+		Live:   live,
 	}
 
 	return []SocketClientResponse{
@@ -538,6 +1502,49 @@ func (server *Server) handleSendToHome(socketClient *SocketClient) []SocketClien
 	}
 }
 
+// homeListing describes a room for the home screen, and whether it's listed there as a
+// room to join or as a live game to watch
+func (server *Server) homeListing(game *GameRoom) (OpenRoom, bool, bool) {
+	game.M.Lock()
+	defer game.M.Unlock()
+
+	// private rooms are only found by their invite code
+	if game.Access.Private || game.IsOver {
+		return OpenRoom{}, false, false
+	}
+
+	if len(game.Players) == 1 {
+		var userID string
+		for id := range game.Players {
+			userID = id
+		}
+
+		if game.Players[userID].Left {
+			return OpenRoom{}, false, false
+		}
+
+		openRoom := OpenRoom{
+			ID:       game.ID,
+			UserID:   userID,
+			Settings: game.Settings,
+			Name:     server.displayName(game, userID),
+		}
+
+		return openRoom, true, false
+	}
+
+	liveRoom := OpenRoom{
+		ID:         game.ID,
+		UserID:     game.FirstPlayerID,
+		OpponentID: GetOpponentID(game, game.FirstPlayerID),
+		Settings:   game.Settings,
+	}
+	liveRoom.Name = server.displayName(game, liveRoom.UserID)
+	liveRoom.OpponentName = server.displayName(game, liveRoom.OpponentID)
+
+	return liveRoom, len(game.Players) == 2, true
+}
+
 func (server *Server) Stop() {
 	close(server.quit)
 	server.listener.Close()
@@ -564,6 +1571,12 @@ func (server *Server) Listen(port string) {
 
 	go socketClientManager.Start()
 
+	server.wg.Add(1)
+	go func() {
+		server.runMatchmaking()
+		server.wg.Done()
+	}()
+
 	log.Println("Server listening on port " + port + "!")
 
 	for {
@@ -608,25 +1621,30 @@ func CloseSocket(socketClient *SocketClient) {
 	if !socketClient.Closed {
 		socketClient.Socket.Close()
 		socketClient.Closed = true
+		close(socketClient.Data)
 	}
 }
 
 func (manager *SocketClientManager) receive(socketClient *SocketClient, server *Server) {
+	reader := bufio.NewReader(socketClient.Socket)
 	for {
-		message := make([]byte, 4096)
-		length, err := socketClient.Socket.Read(message)
-
-		if length > 0 {
-			request := decodeGob(message)
-			server.handleRequest(request, socketClient)
+		message, err := readFrame(reader)
+
+		if err == nil {
+			var request Request
+			request, err = decodeGob(message)
+			if err == nil {
+				server.handleRequest(request, socketClient)
+				continue
+			}
 		}
 
-		if err != nil {
-			manager.unregister <- socketClient
-			CloseSocket(socketClient)
-			break
+		if err != io.EOF {
+			log.Println("Dropping socketClient:", err)
 		}
-
+		manager.unregister <- socketClient
+		CloseSocket(socketClient)
+		break
 	}
 }
 
@@ -637,7 +1655,9 @@ func (manager *SocketClientManager) send(socketClient *SocketClient) {
 			if !ok {
 				return
 			}
-			socketClient.Socket.Write(message)
+			if err := writeFrame(socketClient.Socket, message); err != nil {
+				log.Println("Could not write to socketClient:", err)
+			}
 		}
 	}
 }
@@ -652,7 +1672,6 @@ func (manager *SocketClientManager) Start() {
 		case socketClient := <-manager.unregister:
 			if _, ok := manager.clients[socketClient]; ok {
 				log.Println("A socketClient has left!")
-				close(socketClient.Data)
 				delete(manager.clients, socketClient)
 			}
 		}
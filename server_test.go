@@ -2,38 +2,41 @@ package main
 
 import (
 	"testing"
+	"time"
+
+	"go_gomoku/gomoku"
 )
 
 type ParseCoordsTestCase struct {
 	input         string
-	expectedMove Coord
+	expectedMove gomoku.Coord
 }
 
 func getValidParseCoordsTestCases() []ParseCoordsTestCase {
 	testcases := []ParseCoordsTestCase{
 		ParseCoordsTestCase{
 			"3 3",
-			Coord{X: 3, Y: 3},
+			gomoku.Coord{X: 3, Y: 3},
 		},
 		ParseCoordsTestCase{
 			"4 4",
-			Coord{X: 4, Y: 4},
+			gomoku.Coord{X: 4, Y: 4},
 		},
 		ParseCoordsTestCase{
 			"1 1",
-			Coord{X: 1, Y: 1},
+			gomoku.Coord{X: 1, Y: 1},
 		},
 		ParseCoordsTestCase{
 			"15 15",
-			Coord{X: 15, Y: 15},
+			gomoku.Coord{X: 15, Y: 15},
 		},
 		ParseCoordsTestCase{
 			"1 15",
-			Coord{X: 1, Y: 15},
+			gomoku.Coord{X: 1, Y: 15},
 		},
 		ParseCoordsTestCase{
 			"15 1",
-			Coord{X: 15, Y: 1},
+			gomoku.Coord{X: 15, Y: 1},
 		},
 	}
 	return testcases
@@ -73,22 +76,18 @@ func TestServerParseCoordsValid(t *testing.T) {
 	gameID := 3
 	player1 := Player{
 		UserID:       "mock_user1",
-		Color: "white",
-
 	}
 	player2 := Player{
 		UserID:       "mock_user2",
-		Color: "black",
 	}
 	players := make(map[string]*Player)
 	players[player1.UserID] = &player1
 	players[player2.UserID] = &player2
 
 	server.games[gameID] = &GameRoom{
+		Game:    &gomoku.Game{Colors: map[string]string{player1.UserID: "white", player2.UserID: "black"}, Board: gomoku.NewBoard()},
 		ID:      gameID,
 		Players: players,
-		Turn:    0,
-		Board:   NewBoard(),
 	}
 
 	testcases := getValidParseCoordsTestCases()
@@ -115,27 +114,24 @@ func TestServerParseCoordsInvalidTaken(t *testing.T) {
 	gameID := 3
 	player1 := Player{
 		UserID:       "mock_user1",
-		Color: "white",
 	}
 	player2 := Player{
 		UserID:       "mock_user2",
-		Color: "black",
 	}
 	players := make(map[string]*Player)
 	players[player1.UserID] = &player1
 	players[player2.UserID] = &player2
 
 	game := GameRoom{
+		Game:    &gomoku.Game{Colors: map[string]string{player1.UserID: "white", player2.UserID: "black"}, Board: gomoku.NewBoard()},
 		ID:      gameID,
 		Players: players,
-		Turn:    0,
-		Board:   NewBoard(),
 	}
 	server.games[gameID] = &game
 
 	testcases := getValidParseCoordsTestCases()
 	for _, testcase := range testcases {
-		game.PlayMove(testcase.expectedMove, game.Players[player1.UserID].Color)
+		game.PlayMove(testcase.expectedMove, game.Colors[player1.UserID])
 		req := Request{
 			GameID:  gameID,
 			UserID:  player1.UserID,
@@ -156,21 +152,18 @@ func TestServerParseCoordsInvalidOffBoard(t *testing.T) {
 	gameID := 3
 	player1 := Player{
 		UserID:       "mock_user1",
-		Color: "white",
 	}
 	player2 := Player{
 		UserID:       "mock_user2",
-		Color: "black",
 	}
 	players := make(map[string]*Player)
 	players[player1.UserID] = &player1
 	players[player2.UserID] = &player2
 
 	game := GameRoom{
+		Game:    &gomoku.Game{Colors: map[string]string{player1.UserID: "white", player2.UserID: "black"}, Board: gomoku.NewBoard()},
 		ID:      gameID,
 		Players: players,
-		Turn:    0,
-		Board:   NewBoard(),
 	}
 	server.games[gameID] = &game
 
@@ -196,21 +189,18 @@ func TestServerParseCoordsInvalidSyntax(t *testing.T) {
 	gameID := 3
 	player1 := Player{
 		UserID:       "mock_user1",
-		Color: "white",
 	}
 	player2 := Player{
 		UserID:       "mock_user2",
-		Color: "black",
 	}
 	players := make(map[string]*Player)
 	players[player1.UserID] = &player1
 	players[player2.UserID] = &player2
 
 	game := GameRoom{
+		Game:    &gomoku.Game{Colors: map[string]string{player1.UserID: "white", player2.UserID: "black"}, Board: gomoku.NewBoard()},
 		ID:      gameID,
 		Players: players,
-		Turn:    0,
-		Board:   NewBoard(),
 	}
 	server.games[gameID] = &game
 
@@ -352,3 +342,187 @@ func TestServerHandleJoinAlreadyInRoom(t *testing.T) {
 		t.Errorf("Expected message to be %s, got %s", expectedMessage, response.Data)
 	}
 }
+
+func TestServerParseCoordsInvalidOffSmallBoard(t *testing.T) {
+	server := NewServer()
+	gameID := 3
+	players := make(map[string]*Player)
+	players["mock_user1"] = &Player{UserID: "mock_user1"}
+
+	server.games[gameID] = &GameRoom{
+		Game:     &gomoku.Game{Colors: map[string]string{"mock_user1": "white"}, Board: gomoku.NewSizedBoard(9, 5)},
+		ID:       gameID,
+		Players:  players,
+		Settings: GameSettings{Size: 9, WinLength: 5},
+	}
+
+	req := Request{
+		GameID: gameID,
+		UserID: "mock_user1",
+	}
+	is_valid, _, errorResponse := server.parseMove(req, "10 3")
+	if is_valid {
+		t.Errorf("Expected move to not be valid")
+	}
+	expectedMessage := "Both x and y must be integers from 1 to 9"
+	if errorResponse.Data != expectedMessage {
+		t.Errorf("Expected message to be %s, got %s", expectedMessage, errorResponse.Data)
+	}
+}
+
+func TestServerHandleCreateWithSettings(t *testing.T) {
+	server := NewServer()
+	req := Request{
+		UserID: "mock_player_1",
+		Data:   "19x19 connect-6",
+	}
+	socketClient := SocketClient{}
+	response := server.handleCreate(req, &socketClient)[0].response
+
+	if !response.Success {
+		t.Fatalf("Expected create to succeed, got: %s", response.Data)
+	}
+
+	game := server.games[response.GameID]
+	if game.Board.Size != 19 || game.Board.WinLength != 6 {
+		t.Errorf("Expected board to be 19x19 with win length 6, got %dx%d with win length %d", game.Board.Size, game.Board.Size, game.Board.WinLength)
+	}
+
+	if response.Settings != game.Settings {
+		t.Errorf("Expected response settings %s to match game settings %s", response.Settings, game.Settings)
+	}
+}
+
+func TestServerHandleCreateInvalidSettings(t *testing.T) {
+	server := NewServer()
+	req := Request{
+		UserID: "mock_player_1",
+		Data:   "3x3",
+	}
+	socketClient := SocketClient{}
+	response := server.handleCreate(req, &socketClient)[0].response
+
+	if response.Success {
+		t.Error("Expected create to fail")
+	}
+
+	if len(server.games) != 0 {
+		t.Errorf("Expected no games to be created, found %d", len(server.games))
+	}
+}
+
+func TestServerHandleMoveRenjuForbidden(t *testing.T) {
+	server := NewServer()
+	gameID := 3
+	game := newTestGame(GameSettings{Size: 15, WinLength: 5, RuleSet: gomoku.RENJU, Opening: gomoku.SWAP})
+	game.Players["mock_user1"].SocketClient = &SocketClient{}
+	game.AssignColors("mock_user1")
+	game.Turn = 5
+	game.Opening.SetStage(gomoku.SwapDone)
+	placeStones(game, []string{"7 8", "7 9", "8 7", "9 7"}, []string{"1 1", "1 3", "15 15"})
+	server.games[gameID] = game
+
+	req := Request{
+		GameID: gameID,
+		UserID: "mock_user1",
+		Action: MOVE,
+		Data:   "7 7",
+	}
+	socketClientResponses := server.handleMove(req, game.Players["mock_user1"].SocketClient, game)
+	if len(socketClientResponses) != 1 {
+		t.Fatalf("Expected 1 response, got %d", len(socketClientResponses))
+	}
+
+	response := socketClientResponses[0].response
+	if response.Success {
+		t.Error("Expected move to be rejected")
+	}
+
+	expectedMessage := "That move is forbidden for black under renju rules: double three"
+	if response.Data != expectedMessage {
+		t.Errorf("Expected message to be %s, got %s", expectedMessage, response.Data)
+	}
+
+	if game.Turn != 5 {
+		t.Errorf("Expected turn to still be 5, got %d", game.Turn)
+	}
+}
+
+func TestServerHandleMoveSpectator(t *testing.T) {
+	server := NewServer()
+	game := newTestGame(DefaultGameSettings())
+	server.games[game.ID] = game
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1"})
+
+	spectator := &SocketClient{}
+	game.watch(spectator)
+
+	req := Request{
+		GameID: game.ID,
+		UserID: "spectator",
+		Action: MOVE,
+		Data:   "pass",
+	}
+	socketClientResponses := server.handleMove(req, spectator, game)
+	if len(socketClientResponses) != 1 || socketClientResponses[0].response.Success {
+		t.Fatalf("Expected the spectator's move to be refused, got %v", socketClientResponses)
+	}
+
+	if game.Turn != 2 || game.PlayerColors() != nil {
+		t.Errorf("Expected the game to be untouched on turn 2, got turn %d with colors %v", game.Turn, game.Colors)
+	}
+}
+
+// newTestGame creates room #3 with settings, in which mock_user1 and mock_user2 have just
+// started playing with mock_user1 to move
+func newTestGame(settings GameSettings) *GameRoom {
+	game := newGameRoom(3, settings)
+	game.addPlayer(&Player{UserID: "mock_user1"})
+	game.addPlayer(&Player{UserID: "mock_user2"})
+	game.Start("mock_user1", time.Now())
+	return game
+}
+
+func playTestTurns(t *testing.T, game *GameRoom, turns []string) {
+	for i, data := range turns {
+		userID := "mock_user1"
+		if i%2 == 1 {
+			userID = "mock_user2"
+		}
+
+		_, err := game.playTurn(userID, data, time.Now())
+		if err != nil {
+			t.Fatalf("Could not play '%s': %s", data, err)
+		}
+	}
+}
+
+func TestTakebackRequestAndAccept(t *testing.T) {
+	server := NewServer()
+	game := newTestGame(DefaultGameSettings())
+	server.games[game.ID] = game
+	playTestTurns(t, game, []string{"8 8, 8 9, 1 1", "2 2"})
+
+	server.handleTakeback(Request{GameID: game.ID, UserID: "mock_user2", Action: TAKEBACK}, nil, game)
+	if game.TakebackRequestedBy != "mock_user2" || game.Turn != 3 {
+		t.Fatal("Expected the takeback to wait for the opponent")
+	}
+
+	server.handleTakeback(Request{GameID: game.ID, UserID: "mock_user1", Action: TAKEBACK, Data: "no"}, nil, game)
+	if game.TakebackRequestedBy != "" || game.Turn != 3 {
+		t.Fatal("Expected the takeback to be declined")
+	}
+
+	server.handleTakeback(Request{GameID: game.ID, UserID: "mock_user2", Action: TAKEBACK}, nil, game)
+	responses := server.handleTakeback(Request{GameID: game.ID, UserID: "mock_user1", Action: TAKEBACK}, nil, game)
+
+	if game.Turn != 2 || game.Board.IsTakenBy(gomoku.Coord{X: 2, Y: 2}) != gomoku.FREE {
+		t.Errorf("Expected mock_user2's move to be taken back, got turn %d", game.Turn)
+	}
+
+	for _, response := range responses {
+		if response.response.Board == nil || response.response.Turn != 2 {
+			t.Errorf("Expected everyone to get the new board, got %v", response.response)
+		}
+	}
+}
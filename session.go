@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Session is what the client remembers about its current game, so that it can resume
+// the game after losing its connection or being restarted
+type Session struct {
+	Host   string
+	Port   string
+	UserID string
+	GameID int
+	Token  string
+}
+
+// defaultSessionPath is where the client keeps its session unless SESSION_FILE is set
+func defaultSessionPath() string {
+	path := os.Getenv("SESSION_FILE")
+	if path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go_gomoku", "session.json")
+}
+
+// loadSession reads a saved session, returning an empty one if there is none
+func loadSession(path string) (Session, error) {
+	var session Session
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+
+	err = json.Unmarshal(data, &session)
+	return session, err
+}
+
+// saveSession writes the session so that only the current user can read its token
+func saveSession(path string, session Session) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// clearSession forgets the saved session
+func clearSession(path string) error {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Identity is who the client is on a server: the user ID it plays as, and the name it
+// registered along with the credential that proves the name is its own
+type Identity struct {
+	UserID     string
+	Name       string
+	Credential string
+}
+
+// defaultIdentityPath is where the client keeps its identities unless IDENTITY_FILE is set
+func defaultIdentityPath() string {
+	path := os.Getenv("IDENTITY_FILE")
+	if path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go_gomoku", "identity.json")
+}
+
+// identityKey identifies a server in the identity file
+func identityKey(host string, port string) string {
+	return host + ":" + port
+}
+
+// loadIdentities reads the client's identity on each server, returning none if there are none
+func loadIdentities(path string) (map[string]Identity, error) {
+	identities := make(map[string]Identity)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return identities, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &identities)
+	return identities, err
+}
+
+// saveIdentity keeps the client's identity on a server, alongside those on other servers,
+// so that only the current user can read its credentials
+func saveIdentity(path string, key string, identity Identity) error {
+	identities, err := loadIdentities(path)
+	if err != nil {
+		return err
+	}
+	identities[key] = identity
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(identities)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go_gomoku", "session.json")
+
+	session, err := loadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token != "" {
+		t.Errorf("Expected no saved session, got %v", session)
+	}
+
+	saved := Session{Host: "localhost", Port: "5000", UserID: "mock_user1", GameID: 3, Token: "secret"}
+	err = saveSession(path, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err = loadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session != saved {
+		t.Errorf("Expected %v, got %v", saved, session)
+	}
+
+	err = clearSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err = loadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token != "" {
+		t.Errorf("Expected session to be cleared, got %v", session)
+	}
+}
+
+func TestIdentitySaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go_gomoku", "identity.json")
+
+	identities, err := loadIdentities(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(identities) != 0 {
+		t.Errorf("Expected no saved identities, got %v", identities)
+	}
+
+	local := Identity{UserID: "mock_user1", Name: "alice", Credential: "secret"}
+	remote := Identity{UserID: "mock_user2"}
+	for key, identity := range map[string]Identity{identityKey("localhost", "5000"): local, identityKey("example.com", "5000"): remote} {
+		err = saveIdentity(path, key, identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	identities, err = loadIdentities(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identities["localhost:5000"] != local || identities["example.com:5000"] != remote {
+		t.Errorf("Expected an identity for each server, got %v", identities)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"go_gomoku/gomoku"
+)
+
+// GameSettings contains the options a room was created with
+type GameSettings struct {
+	Size      int
+	WinLength int
+	RuleSet   string
+	Opening   string
+	// Bot is the difficulty of the computer opponent, or empty for a game between people
+	Bot         string
+	TimeControl gomoku.TimeControl
+	// Rated games change the players' ratings; games are casual unless created as rated
+	Rated bool
+}
+
+// DefaultGameSettings returns the settings used when mk is given no options
+func DefaultGameSettings() GameSettings {
+	return GameSettings{
+		Size:      gomoku.DefaultBoardSize,
+		WinLength: gomoku.DefaultWinLength,
+		RuleSet:   gomoku.STANDARD,
+		Opening:   gomoku.SWAP,
+	}
+}
+
+// parseGameSettings parses the options given to mk, e.g. "19x19 connect-6 freestyle swap2 5m+3s"
+func parseGameSettings(options string) (GameSettings, error) {
+	settings := DefaultGameSettings()
+
+	for _, option := range strings.Fields(strings.ToLower(options)) {
+		switch {
+		case gomoku.RuleSetByName(option) != nil:
+			settings.RuleSet = option
+		case gomoku.IsOpeningName(option):
+			settings.Opening = option
+		case option == "bot":
+			if settings.Bot == "" {
+				settings.Bot = MEDIUM
+			}
+		case option == "rated":
+			settings.Rated = true
+		case option == "casual":
+			settings.Rated = false
+		case isBotLevel(option):
+			settings.Bot = option
+		case strings.HasPrefix(option, "connect-"):
+			winLength, err := strconv.Atoi(strings.TrimPrefix(option, "connect-"))
+			if err != nil {
+				return settings, errors.New("The syntax for win length is connect-<n>")
+			}
+			settings.WinLength = winLength
+		case gomoku.IsTimeControl(option):
+			control, err := gomoku.ParseTimeControl(option)
+			if err != nil {
+				return settings, err
+			}
+			settings.TimeControl = control
+		case strings.Contains(option, "x"):
+			dimensions := strings.Split(option, "x")
+			width, widthErr := strconv.Atoi(dimensions[0])
+			height, heightErr := strconv.Atoi(dimensions[1])
+			if len(dimensions) != 2 || widthErr != nil || heightErr != nil {
+				return settings, errors.New("The syntax for board size is <n>x<n>")
+			}
+			if width != height {
+				return settings, errors.New("The board must be square")
+			}
+			settings.Size = width
+		default:
+			return settings, errors.New("Unrecognized option: " + option)
+		}
+	}
+
+	return settings, settings.validate()
+}
+
+// engineSettings returns the settings the game itself is played with
+func (settings GameSettings) engineSettings() gomoku.Settings {
+	return gomoku.Settings{
+		Size:        settings.Size,
+		WinLength:   settings.WinLength,
+		RuleSet:     settings.RuleSet,
+		Opening:     settings.Opening,
+		TimeControl: settings.TimeControl,
+	}
+}
+
+func (settings GameSettings) validate() error {
+	err := settings.engineSettings().Validate()
+	if err != nil {
+		return err
+	}
+
+	if settings.Bot != "" && settings.Opening != gomoku.SWAP {
+		return errors.New("Bots can only play the swap opening")
+	}
+
+	if settings.Bot != "" && settings.Rated {
+		return errors.New("Games against bots can't be rated")
+	}
+
+	return nil
+}
+
+func (settings GameSettings) String() string {
+	size := strconv.Itoa(settings.Size)
+	description := size + "x" + size + ", " + strconv.Itoa(settings.WinLength) + " in a row, " + settings.RuleSet + " rules, " + settings.Opening + " opening"
+	if settings.TimeControl.Kind != "" {
+		description += ", " + settings.TimeControl.String()
+	}
+	if settings.Bot != "" {
+		description += ", against a " + settings.Bot + " bot"
+	}
+	if settings.Rated {
+		description += ", rated"
+	}
+	return description
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+type ParseGameSettingsTestCase struct {
+	options          string
+	expectedSettings GameSettings
+}
+
+func TestParseGameSettingsValid(t *testing.T) {
+	testcases := []ParseGameSettingsTestCase{
+		ParseGameSettingsTestCase{"", GameSettings{Size: 15, WinLength: 5, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"19x19", GameSettings{Size: 19, WinLength: 5, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"9x9", GameSettings{Size: 9, WinLength: 5, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"connect-6", GameSettings{Size: 15, WinLength: 6, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"19x19 connect-6", GameSettings{Size: 19, WinLength: 6, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"CONNECT-4 7X7", GameSettings{Size: 7, WinLength: 4, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"renju", GameSettings{Size: 15, WinLength: 5, RuleSet: gomoku.RENJU, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"9x9 caro", GameSettings{Size: 9, WinLength: 5, RuleSet: gomoku.CARO, Opening: gomoku.SWAP}},
+		ParseGameSettingsTestCase{"swap2", GameSettings{Size: 15, WinLength: 5, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP2}},
+		ParseGameSettingsTestCase{"renju longpro", GameSettings{Size: 15, WinLength: 5, RuleSet: gomoku.RENJU, Opening: gomoku.LONGPRO}},
+		ParseGameSettingsTestCase{"rated 9x9", GameSettings{Size: 9, WinLength: 5, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP, Rated: true}},
+		ParseGameSettingsTestCase{"19x19 10m/30sx3", GameSettings{Size: 19, WinLength: 5, RuleSet: gomoku.STANDARD, Opening: gomoku.SWAP, TimeControl: gomoku.TimeControl{Kind: gomoku.BYOYOMI, Main: 10 * time.Minute, Period: 30 * time.Second, Periods: 3}}},
+	}
+
+	for _, testcase := range testcases {
+		settings, err := parseGameSettings(testcase.options)
+		if err != nil {
+			t.Errorf("Expected options '%s' to be valid, got error: %s", testcase.options, err)
+		}
+		if settings != testcase.expectedSettings {
+			t.Errorf("Expected options '%s' to give %s, got %s", testcase.options, testcase.expectedSettings, settings)
+		}
+	}
+}
+
+func TestParseGameSettingsInvalid(t *testing.T) {
+	testcases := map[string]string{
+		"4x4":             "Board size must be between 5 and 25",
+		"26x26":           "Board size must be between 5 and 25",
+		"9x11":            "The board must be square",
+		"axb":             "The syntax for board size is <n>x<n>",
+		"connect-x":       "The syntax for win length is connect-<n>",
+		"connect-2":       "Win length must be between 3 and the board size",
+		"5x5 connect-6":   "Win length must be between 3 and the board size",
+		"huge":            "Unrecognized option: huge",
+		"7x7 longpro":     "The long pro opening needs a board of at least 9x9",
+		"5x5 pro":         "The pro opening needs a board of at least 7x7",
+		"renju connect-6": "Renju rules can only be played with five in a row",
+		"bot rated":       "Games against bots can't be rated",
+	}
+
+	for options, expectedMessage := range testcases {
+		_, err := parseGameSettings(options)
+		if err == nil {
+			t.Errorf("Expected options '%s' to be invalid", options)
+			continue
+		}
+		if err.Error() != expectedMessage {
+			t.Errorf("Expected message for '%s' to be %s, got %s", options, expectedMessage, err.Error())
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// snapshotInterval is how many turns are played between snapshots of a game
+const snapshotInterval = 10
+
+// TurnRecord is a single turn, exactly as the player submitted it
+type TurnRecord struct {
+	// Rematch is which game in the room the turn belongs to, counting from 0
+	Rematch int
+	Turn    int
+	UserID  string
+	Data    string
+	Time    time.Time
+	// Undo marks that the turns from Turn onwards were taken back
+	Undo bool
+}
+
+// GameSnapshot is everything needed to rebuild a GameRoom
+type GameSnapshot struct {
+	ID            int
+	Settings      GameSettings
+	Access        RoomAccess
+	Turn          int
+	FirstPlayerID string
+	IsOver        bool
+	Result        gomoku.Result
+	Score         MatchScore
+	Rematches     int
+	OpeningStage  int
+	Players       []string
+	Board         map[string]map[string]bool
+	Colors        map[string]string
+	Bots          map[string]string
+	Tokens        map[string]string
+	Clock         *gomoku.GameClock
+	History       []gomoku.TurnRecord
+	Moves         []gomoku.MoveRecord
+	WinningLine   []gomoku.Coord
+	Messages      []Message
+	// the offers and requests still waiting for an answer
+	DrawOfferedBy       string
+	TakebackRequestedBy string
+	RematchOfferedBy    string
+	// Tournament is nil unless the game was paired in a tournament
+	Tournament *TournamentLink
+}
+
+// TournamentLink locates a game among a tournament's pairings
+type TournamentLink struct {
+	ID      int
+	Round   int
+	Pairing int
+}
+
+// SavedGame is a game's latest snapshot and the turns played since it was taken
+type SavedGame struct {
+	Snapshot GameSnapshot
+	Turns    []TurnRecord
+}
+
+// GameStore saves game rooms, registered names and tournaments so that they survive a server
+// restart
+type GameStore interface {
+	SaveSnapshot(GameSnapshot) error
+	AppendTurn(int, TurnRecord) error
+	Load() ([]SavedGame, error)
+	SaveAccounts([]Account) error
+	LoadAccounts() ([]Account, error)
+	SaveTournaments([]Tournament) error
+	LoadTournaments() ([]Tournament, error)
+}
+
+// assert that stores implement GameStore
+var _ GameStore = (*nullStore)(nil)
+var _ GameStore = (*FileStore)(nil)
+
+// nullStore keeps nothing, for servers that don't persist games
+type nullStore struct{}
+
+func (store nullStore) SaveSnapshot(snapshot GameSnapshot) error {
+	return nil
+}
+
+func (store nullStore) AppendTurn(gameID int, turn TurnRecord) error {
+	return nil
+}
+
+func (store nullStore) Load() ([]SavedGame, error) {
+	return []SavedGame{}, nil
+}
+
+func (store nullStore) SaveAccounts(accounts []Account) error {
+	return nil
+}
+
+func (store nullStore) LoadAccounts() ([]Account, error) {
+	return []Account{}, nil
+}
+
+func (store nullStore) SaveTournaments(tournaments []Tournament) error {
+	return nil
+}
+
+func (store nullStore) LoadTournaments() ([]Tournament, error) {
+	return []Tournament{}, nil
+}
+
+// FileStore keeps an append-only log of turns for each game, plus a snapshot of the
+// game that is rewritten every few turns
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store that keeps its files in dir
+func NewFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (store *FileStore) snapshotPath(gameID int) string {
+	return filepath.Join(store.dir, "game-"+strconv.Itoa(gameID)+".snapshot")
+}
+
+func (store *FileStore) logPath(gameID int) string {
+	return filepath.Join(store.dir, "game-"+strconv.Itoa(gameID)+".log")
+}
+
+// SaveSnapshot atomically replaces a game's snapshot
+func (store *FileStore) SaveSnapshot(snapshot GameSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	path := store.snapshotPath(snapshot.ID)
+	err = os.WriteFile(path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// AppendTurn adds a turn to the end of a game's log
+func (store *FileStore) AppendTurn(gameID int, turn TurnRecord) error {
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(store.logPath(gameID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Load reads every snapshot, along with any turns logged after the snapshot was taken
+func (store *FileStore) Load() ([]SavedGame, error) {
+	paths, err := filepath.Glob(filepath.Join(store.dir, "game-*.snapshot"))
+	if err != nil {
+		return nil, err
+	}
+
+	games := []SavedGame{}
+	for _, path := range paths {
+		// a game that can't be read is left behind, rather than keeping the others from loading
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Println("Could not read", path+":", err)
+			continue
+		}
+
+		var snapshot GameSnapshot
+		err = json.Unmarshal(data, &snapshot)
+		if err != nil {
+			log.Println("Could not read", path+":", err)
+			continue
+		}
+
+		turns, err := store.loadTurns(snapshot.ID)
+		if err != nil {
+			log.Println("Could not read the turns of game #"+strconv.Itoa(snapshot.ID)+":", err)
+			continue
+		}
+
+		// the log also has the turns of earlier games in the room, and the first turns of
+		// the current game are already part of the snapshot
+		current := []TurnRecord{}
+		for _, turn := range turns {
+			if turn.Rematch != snapshot.Rematches {
+				continue
+			}
+
+			if turn.Undo {
+				for len(current) > 0 && current[len(current)-1].Turn >= turn.Turn {
+					current = current[:len(current)-1]
+				}
+				continue
+			}
+
+			current = append(current, turn)
+		}
+		if len(current) > len(snapshot.History) {
+			turns = current[len(snapshot.History):]
+		} else {
+			turns = []TurnRecord{}
+		}
+
+		games = append(games, SavedGame{snapshot, turns})
+	}
+
+	return games, nil
+}
+
+func (store *FileStore) accountsPath() string {
+	return filepath.Join(store.dir, "accounts.json")
+}
+
+// SaveAccounts atomically replaces the registered names
+func (store *FileStore) SaveAccounts(accounts []Account) error {
+	data, err := json.Marshal(accounts)
+	if err != nil {
+		return err
+	}
+
+	path := store.accountsPath()
+	err = os.WriteFile(path+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// LoadAccounts reads the registered names, if any have been saved
+func (store *FileStore) LoadAccounts() ([]Account, error) {
+	accounts := []Account{}
+
+	data, err := os.ReadFile(store.accountsPath())
+	if os.IsNotExist(err) {
+		return accounts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &accounts)
+	return accounts, err
+}
+
+func (store *FileStore) tournamentsPath() string {
+	return filepath.Join(store.dir, "tournaments.json")
+}
+
+// SaveTournaments atomically replaces the tournaments
+func (store *FileStore) SaveTournaments(tournaments []Tournament) error {
+	data, err := json.Marshal(tournaments)
+	if err != nil {
+		return err
+	}
+
+	path := store.tournamentsPath()
+	err = os.WriteFile(path+".tmp", data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// LoadTournaments reads the tournaments, if any have been saved
+func (store *FileStore) LoadTournaments() ([]Tournament, error) {
+	tournaments := []Tournament{}
+
+	data, err := os.ReadFile(store.tournamentsPath())
+	if os.IsNotExist(err) {
+		return tournaments, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &tournaments)
+	return tournaments, err
+}
+
+func (store *FileStore) loadTurns(gameID int) ([]TurnRecord, error) {
+	turns := []TurnRecord{}
+
+	file, err := os.Open(store.logPath(gameID))
+	if os.IsNotExist(err) {
+		return turns, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var turn TurnRecord
+		err = json.Unmarshal([]byte(line), &turn)
+		if err != nil {
+			// a crash may have cut off the last line
+			break
+		}
+		turns = append(turns, turn)
+	}
+
+	return turns, scanner.Err()
+}
+
+// turnRecord logs one of the game's turns along with which game in the room it belongs to
+func (game *GameRoom) turnRecord(turn gomoku.TurnRecord) TurnRecord {
+	return TurnRecord{
+		Rematch: game.Rematches,
+		Turn:    turn.Turn,
+		UserID:  turn.UserID,
+		Data:    turn.Data,
+		Time:    turn.Time,
+	}
+}
+
+// snapshot captures the game's current state
+func (game *GameRoom) snapshot() GameSnapshot {
+	snapshot := GameSnapshot{
+		ID:            game.ID,
+		Settings:      game.Settings,
+		Access:        game.Access,
+		Turn:          game.Turn,
+		FirstPlayerID: game.FirstPlayerID,
+		IsOver:        game.IsOver,
+		Result:        game.Result,
+		Score:         game.Score,
+		Rematches:     game.Rematches,
+		OpeningStage:  game.Opening.Stage(),
+		Players:       []string{},
+		Board:         game.Board.Spaces(),
+		Colors:        game.PlayerColors(),
+		Bots:          make(map[string]string),
+		Tokens:        make(map[string]string),
+		Clock:         game.Clock,
+		History:       append([]gomoku.TurnRecord{}, game.History...),
+		Moves:         append([]gomoku.MoveRecord{}, game.Moves...),
+		WinningLine:   game.WinningLine,
+		Messages:      append([]Message{}, game.Messages...),
+
+		DrawOfferedBy:       game.DrawOfferedBy,
+		TakebackRequestedBy: game.TakebackRequestedBy,
+		RematchOfferedBy:    game.RematchOfferedBy,
+	}
+
+	if game.tournament != nil {
+		snapshot.Tournament = &TournamentLink{
+			ID:      game.tournament.ID,
+			Round:   game.round,
+			Pairing: game.pairing,
+		}
+	}
+
+	for id, player := range game.Players {
+		snapshot.Players = append(snapshot.Players, id)
+		if player.Bot != nil {
+			snapshot.Bots[id] = player.Bot.Level
+		}
+		if player.Token != "" {
+			snapshot.Tokens[id] = player.Token
+		}
+	}
+
+	return snapshot
+}
+
+// restoreGame rebuilds a game from a snapshot and replays the turns played since. Players
+// other than bots have no connection until they come back.
+func restoreGame(saved SavedGame) (*GameRoom, error) {
+	snapshot := saved.Snapshot
+	settings := snapshot.Settings
+
+	game := newGameRoom(snapshot.ID, settings)
+	game.Access = snapshot.Access
+	game.Turn = snapshot.Turn
+	game.Board = gomoku.NewBoardFromSpaces(settings.Size, settings.WinLength, snapshot.Board)
+	game.FirstPlayerID = snapshot.FirstPlayerID
+	game.IsOver = snapshot.IsOver
+	game.Result = snapshot.Result
+	game.Score = snapshot.Score
+	game.Rematches = snapshot.Rematches
+	game.Clock = snapshot.Clock
+	game.History = snapshot.History
+	game.Moves = snapshot.Moves
+	game.WinningLine = snapshot.WinningLine
+	game.Messages = snapshot.Messages
+	game.DrawOfferedBy = snapshot.DrawOfferedBy
+	game.TakebackRequestedBy = snapshot.TakebackRequestedBy
+	game.RematchOfferedBy = snapshot.RematchOfferedBy
+	game.Opening.SetStage(snapshot.OpeningStage)
+
+	for _, id := range snapshot.Players {
+		player := &Player{
+			UserID: id,
+			Token:  snapshot.Tokens[id],
+		}
+		if level, ok := snapshot.Bots[id]; ok {
+			player.Bot = NewBot(level)
+		}
+		game.addPlayer(player)
+		game.Colors[id] = snapshot.Colors[id]
+	}
+
+	for _, turn := range saved.Turns {
+		_, err := game.playTurn(turn.UserID, turn.Data, turn.Time)
+		if err != nil {
+			return nil, errors.New("Could not replay turn " + strconv.Itoa(turn.Turn) + " of game #" + strconv.Itoa(game.ID) + ": " + err.Error())
+		}
+	}
+
+	// clocks don't run while the server is down
+	if game.Clock != nil {
+		game.Clock.Resume(time.Now())
+	}
+
+	return game, nil
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+func TestFileStoreRestoresGame(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.handleRequest(Request{UserID: "mock_user1", Action: CREATE}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: "mock_user2", Action: JOIN}, nil)
+
+	game := server.games[0]
+	first := game.FirstPlayerID
+	second := GetOpponentID(game, first)
+
+	// twelve turns, so that the last two are only in the log
+	turns := []string{"8 8, 8 9, 1 1", "1 2"}
+	for row := 1; row <= 10; row++ {
+		turns = append(turns, gomoku.Coord{X: 15, Y: row}.String())
+	}
+
+	for i, data := range turns {
+		userID := first
+		if i%2 == 1 {
+			userID = second
+		}
+		server.handleRequest(Request{GameID: 0, UserID: userID, Action: MOVE, Data: data}, nil)
+	}
+
+	if len(game.History) != len(turns) {
+		t.Fatalf("Expected %d turns in history, got %d", len(turns), len(game.History))
+	}
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restoredGame := restored.games[0]
+	if restoredGame == nil {
+		t.Fatal("Expected game #0 to be restored")
+	}
+
+	if restoredGame.Turn != game.Turn {
+		t.Errorf("Expected turn %d, got %d", game.Turn, restoredGame.Turn)
+	}
+
+	if restoredGame.FirstPlayerID != first {
+		t.Errorf("Expected first player %s, got %s", first, restoredGame.FirstPlayerID)
+	}
+
+	if len(restoredGame.History) != len(turns) {
+		t.Errorf("Expected %d turns in history, got %d", len(turns), len(restoredGame.History))
+	}
+
+	if !restoredGame.Opening.Done() {
+		t.Errorf("Expected opening to be over")
+	}
+
+	restoredSpaces := restoredGame.Board.Spaces()
+	for color, spaces := range game.Board.Spaces() {
+		if len(restoredSpaces[color]) != len(spaces) {
+			t.Errorf("Expected %d %s pieces, got %d", len(spaces), color, len(restoredSpaces[color]))
+		}
+		for space := range spaces {
+			if !restoredSpaces[color][space] {
+				t.Errorf("Expected %s piece on %s", color, space)
+			}
+		}
+	}
+
+	for id, color := range game.Colors {
+		if restoredGame.Players[id] == nil || restoredGame.Colors[id] != color {
+			t.Errorf("Expected %s to play %s", id, color)
+		}
+	}
+
+	if restored.gameID != 1 {
+		t.Errorf("Expected next game ID to be 1, got %d", restored.gameID)
+	}
+}
+
+func TestFileStoreRestoresRematch(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.handleRequest(Request{UserID: "mock_user1", Action: CREATE}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: "mock_user2", Action: JOIN}, nil)
+	game := server.games[0]
+
+	server.handleRequest(Request{GameID: 0, UserID: game.FirstPlayerID, Action: MOVE, Data: "8 8, 8 9, 1 1"}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: game.FirstPlayerID, Action: RESIGN}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: "mock_user1", Action: REMATCH}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: "mock_user2", Action: REMATCH}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: game.FirstPlayerID, Action: MOVE, Data: "2 2, 2 3, 3 3"}, nil)
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restoredGame := restored.games[0]
+	if restoredGame.Rematches != 1 || len(restoredGame.History) != 1 {
+		t.Fatalf("Expected only the rematch's turn to be restored, got %d rematches and %v", restoredGame.Rematches, restoredGame.History)
+	}
+
+	if restoredGame.Board.IsTakenBy(gomoku.Coord{X: 2, Y: 2}) != "black" || restoredGame.Board.IsTakenBy(gomoku.Coord{X: 8, Y: 8}) != gomoku.FREE {
+		t.Errorf("Expected the rematch's board, got %v", restoredGame.Board.Spaces())
+	}
+}
+
+func TestFileStoreRestoresTakeback(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.handleRequest(Request{UserID: "mock_user1", Action: CREATE}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: "mock_user2", Action: JOIN}, nil)
+	game := server.games[0]
+	first := game.FirstPlayerID
+	second := GetOpponentID(game, first)
+
+	server.handleRequest(Request{GameID: 0, UserID: first, Action: MOVE, Data: "8 8, 8 9, 1 1"}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: second, Action: MOVE, Data: "2 2"}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: second, Action: TAKEBACK}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: first, Action: TAKEBACK}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: second, Action: MOVE, Data: "3 3"}, nil)
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restoredGame := restored.games[0]
+	if restoredGame.Turn != 3 || len(restoredGame.History) != 2 {
+		t.Fatalf("Expected 2 turns to be restored, got %v", restoredGame.History)
+	}
+
+	if restoredGame.Board.IsTakenBy(gomoku.Coord{X: 2, Y: 2}) != gomoku.FREE || restoredGame.Board.IsTakenBy(gomoku.Coord{X: 3, Y: 3}) != "white" {
+		t.Errorf("Expected the move taken back to stay off the board, got %v", restoredGame.Board.Spaces())
+	}
+}
+
+func TestFileStoreIgnoresTruncatedTurn(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.SaveSnapshot(GameSnapshot{ID: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.AppendTurn(4, TurnRecord{Turn: 1, UserID: "mock_user1", Data: "8 8, 8 9, 1 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.OpenFile(store.logPath(4), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"Turn":2,"UserID":"mock_us`)
+	file.Close()
+
+	saved, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saved) != 1 {
+		t.Fatalf("Expected 1 saved game, got %d", len(saved))
+	}
+
+	if len(saved[0].Turns) != 1 || saved[0].Turns[0].Data != "8 8, 8 9, 1 1" {
+		t.Errorf("Expected only the complete turn, got %v", saved[0].Turns)
+	}
+}
+
+func TestFileStoreRestoresNames(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses := server.handleRegister(Request{UserID: "mock_user1", Data: "alice"}, nil)
+	credential := responses[0].response.Token
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if restored.names.Name("mock_user1") != "alice" {
+		t.Fatal("Expected alice's name to be restored")
+	}
+
+	responses = restored.handleRegister(Request{UserID: "mock_user1", Data: "alice", Token: credential}, nil)
+	if !responses[0].response.Success {
+		t.Errorf("Expected alice to sign back in, got: %s", responses[0].response.Data)
+	}
+}
+
+func TestFileStoreSkipsCorruptGames(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.handleRequest(Request{UserID: "mock_user1", Action: CREATE}, nil)
+
+	// a turn that can't be replayed, and a snapshot that can't be read
+	err = store.SaveSnapshot(GameSnapshot{ID: 5, Settings: DefaultGameSettings(), Turn: 1, FirstPlayerID: "mock_user1", Players: []string{"mock_user1", "mock_user2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AppendTurn(5, TurnRecord{Turn: 1, UserID: "mock_user2", Data: "8 8, 8 9, 1 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(store.snapshotPath(6), []byte(`{"ID":`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatalf("Expected corrupt games to be skipped, got error: %s", err)
+	}
+
+	if len(restored.games) != 1 || restored.games[0] == nil {
+		t.Errorf("Expected only game #0 to be restored, got %d games", len(restored.games))
+	}
+}
+
+func TestFileStoreRestoresChatAndOffers(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.handleRequest(Request{UserID: "mock_user1", Action: CREATE}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: "mock_user2", Action: JOIN}, nil)
+
+	game := server.games[0]
+	first := game.FirstPlayerID
+	second := GetOpponentID(game, first)
+	server.handleRequest(Request{GameID: 0, UserID: first, Action: MOVE, Data: "8 8, 8 9, 1 1"}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: second, Action: MOVE, Data: "1 2"}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: second, Action: MESSAGE, Data: "good luck"}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: second, Action: DRAW}, nil)
+	server.handleRequest(Request{GameID: 0, UserID: first, Action: TAKEBACK}, nil)
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restoredGame := restored.games[0]
+	if len(restoredGame.Messages) != 1 || restoredGame.Messages[0].Content != "good luck" {
+		t.Errorf("Expected the chat to be restored, got %v", restoredGame.Messages)
+	}
+	if restoredGame.DrawOfferedBy != second {
+		t.Errorf("Expected %s's draw offer to be restored, got '%s'", second, restoredGame.DrawOfferedBy)
+	}
+	if restoredGame.TakebackRequestedBy != first {
+		t.Errorf("Expected %s's takeback request to be restored, got '%s'", first, restoredGame.TakebackRequestedBy)
+	}
+}
+
+func TestFileStoreRestoresTournament(t *testing.T) {
+	roundDelay = time.Hour
+	defer func() { roundDelay = 10 * time.Second }()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer()
+	err = server.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.handleTournament(Request{UserID: "mock_user1", Action: TOURNAMENT, Data: "mk swiss rounds 2"}, nil)
+	server.handleTournament(Request{UserID: "mock_user1", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user2", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user1", Data: "go 0"}, nil)
+
+	gameID := server.tournaments.tournaments[0].Pairings[0][0].GameID
+	first := server.games[gameID].FirstPlayerID
+	server.handleRequest(Request{GameID: gameID, UserID: first, Action: MOVE, Data: "8 8, 8 9, 1 1"}, nil)
+
+	restored := NewServer()
+	err = restored.UseStore(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tournament := restored.tournaments.tournaments[0]
+	if tournament == nil || len(tournament.Players) != 2 || tournament.round() != 1 || tournament.Rounds != 2 {
+		t.Fatalf("Expected the tournament to be restored in its first round, got %v", tournament)
+	}
+
+	game := restored.games[gameID]
+	if game == nil || game.tournament != tournament || game.noShow == nil {
+		t.Fatal("Expected the game to be linked to its tournament, with the no-show timer running again")
+	}
+
+	restored.handleRequest(Request{GameID: gameID, UserID: first, Action: RESIGN}, nil)
+	if !tournament.roundComplete() || tournament.Pairings[0][0].Result.Kind != gomoku.RESIGNED {
+		t.Errorf("Expected the restored game's result to complete the round, got %v", tournament.Pairings[0])
+	}
+
+	responses := restored.handleRematch(Request{GameID: gameID, UserID: first, Action: REMATCH}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected the restored tournament game not to be rematched")
+	}
+
+	restored.handleTournament(Request{UserID: "mock_user3", Action: TOURNAMENT, Data: "mk roundrobin"}, nil)
+	if restored.tournaments.tournaments[1] == nil {
+		t.Error("Expected new tournaments to carry on from the restored IDs")
+	}
+}
@@ -1 +1 @@
-go test . -coverprofile=coverage.out
\ No newline at end of file
+go test ./... -coverprofile=coverage.out
\ No newline at end of file
@@ -0,0 +1,862 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+// tournament formats
+const (
+	// ROUNDROBIN pairs every player with every other player once
+	ROUNDROBIN = "roundrobin"
+	// SWISS pairs players with similar scores who haven't met yet, for a fixed number of rounds
+	SWISS = "swiss"
+)
+
+// roundDelay is how long players see the result of a round before the next one starts, and
+// noShowTimeout is how long a player has to play their first turn before forfeiting
+var (
+	roundDelay    = 10 * time.Second
+	noShowTimeout = 2 * time.Minute
+)
+
+// Pairing is a game between two tournament players, or a bye if Second is empty
+type Pairing struct {
+	First  string
+	Second string
+	// GameID is the room the game is played in, or -1 if it was never played
+	GameID int
+	// Result is empty until the game is over
+	Result gomoku.Result
+}
+
+// Tournament is a series of rounds between the players who registered for it
+type Tournament struct {
+	ID        int
+	Format    string
+	Settings  GameSettings
+	Rounds    int
+	Organizer string
+	// Players are listed in the order they registered
+	Players []string
+	// Pairings holds the pairings of each round started so far
+	Pairings [][]Pairing
+	Finished bool
+	// clients are the latest connection of each player, which their games are started on
+	clients map[string]*SocketClient
+}
+
+// TournamentRegistry keeps the tournaments hosted on the server. Its lock may be taken while
+// holding a game's lock, but never the other way round.
+type TournamentRegistry struct {
+	M           sync.Mutex
+	tournaments map[int]*Tournament
+	nextID      int
+}
+
+// NewTournamentRegistry creates a registry with the tournaments already saved, if any. Their
+// players have no connection until they next send a request.
+func NewTournamentRegistry(saved []Tournament) *TournamentRegistry {
+	registry := &TournamentRegistry{tournaments: make(map[int]*Tournament)}
+
+	for i := range saved {
+		tournament := saved[i]
+		tournament.clients = make(map[string]*SocketClient)
+		for _, id := range tournament.Players {
+			tournament.clients[id] = nil
+		}
+
+		registry.tournaments[tournament.ID] = &tournament
+		if tournament.ID >= registry.nextID {
+			registry.nextID = tournament.ID + 1
+		}
+	}
+	return registry
+}
+
+// list copies every tournament, to be saved; the caller must hold the registry's lock
+func (registry *TournamentRegistry) list() []Tournament {
+	tournaments := []Tournament{}
+	for _, tournament := range registry.tournaments {
+		saved := *tournament
+		saved.Players = append([]string{}, tournament.Players...)
+		saved.Pairings = [][]Pairing{}
+		for _, round := range tournament.Pairings {
+			saved.Pairings = append(saved.Pairings, append([]Pairing{}, round...))
+		}
+		saved.clients = nil
+		tournaments = append(tournaments, saved)
+	}
+	return tournaments
+}
+
+// seen remembers the connection a player last used, for the tournaments they're playing in
+func (registry *TournamentRegistry) seen(userID string, socketClient *SocketClient) {
+	registry.M.Lock()
+	defer registry.M.Unlock()
+
+	for _, tournament := range registry.tournaments {
+		if _, ok := tournament.clients[userID]; ok && !tournament.Finished {
+			tournament.clients[userID] = socketClient
+		}
+	}
+}
+
+// round is the number of the latest round started, counting from 1, or 0 before the start
+func (tournament *Tournament) round() int {
+	return len(tournament.Pairings)
+}
+
+// roundComplete checks whether every game in the latest round has a result
+func (tournament *Tournament) roundComplete() bool {
+	if tournament.round() == 0 {
+		return false
+	}
+	for _, pairing := range tournament.Pairings[tournament.round()-1] {
+		if pairing.Result.Kind == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// setResult records the result of a pairing, returning true if it completed the round. A
+// pairing's first result is the one that counts.
+func (tournament *Tournament) setResult(round int, index int, result gomoku.Result) bool {
+	pairing := &tournament.Pairings[round][index]
+	if pairing.Result.Kind != "" {
+		return false
+	}
+	pairing.Result = result
+	return round == tournament.round()-1 && tournament.roundComplete()
+}
+
+// status describes how far the tournament has got
+func (tournament *Tournament) status() string {
+	switch {
+	case tournament.Finished:
+		return "finished"
+	case tournament.round() == 0:
+		return "registering"
+	}
+	return "round " + strconv.Itoa(tournament.round()) + " of " + strconv.Itoa(tournament.Rounds)
+}
+
+// pointsFor is what a pairing's result scores for userID: 1 for a win or a bye, 0.5 for a draw
+func (pairing Pairing) pointsFor(userID string) float64 {
+	switch {
+	case pairing.Result.Kind == gomoku.DRAWN:
+		return 0.5
+	case pairing.Result.WinnerID == userID:
+		return 1
+	}
+	return 0
+}
+
+// opponent returns the other player in the pairing, or an empty string for a bye
+func (pairing Pairing) opponent(userID string) string {
+	if pairing.First == userID {
+		return pairing.Second
+	}
+	return pairing.First
+}
+
+// points adds up each player's score in the games finished so far
+func (tournament *Tournament) points() map[string]float64 {
+	points := make(map[string]float64)
+	for _, id := range tournament.Players {
+		points[id] = 0
+	}
+
+	for _, round := range tournament.Pairings {
+		for _, pairing := range round {
+			if pairing.Result.Kind == "" {
+				continue
+			}
+			points[pairing.First] += pairing.pointsFor(pairing.First)
+			if pairing.Second != "" {
+				points[pairing.Second] += pairing.pointsFor(pairing.Second)
+			}
+		}
+	}
+	return points
+}
+
+// roundRobinPairings pairs the players for a round of a round robin, counting from 0, by the
+// circle method: the first player stays put while the others rotate around them. With an odd
+// number of players, whoever is paired with the empty seat has a bye.
+func roundRobinPairings(players []string, round int) []Pairing {
+	seats := append([]string{}, players...)
+	if len(seats)%2 == 1 {
+		seats = append(seats, "")
+	}
+
+	n := len(seats)
+	rotated := []string{seats[0]}
+	for i := 0; i < n-1; i++ {
+		rotated = append(rotated, seats[1+(i+n-1-round%(n-1))%(n-1)])
+	}
+
+	pairings := []Pairing{}
+	for i := 0; i < n/2; i++ {
+		first, second := rotated[i], rotated[n-1-i]
+		// alternate who hosts the first board, so that the fixed player isn't always first
+		if i == 0 && round%2 == 1 {
+			first, second = second, first
+		}
+		if first == "" {
+			first, second = second, first
+		}
+		pairings = append(pairings, Pairing{First: first, Second: second, GameID: -1})
+	}
+	return pairings
+}
+
+// swissPairings pairs players with the same or similar scores who haven't played each other
+// yet, strongest first, and only repeats a game when no pairing of the round avoids it. With
+// an odd number of players, the lowest placed player who hasn't had a bye yet sits the round
+// out.
+func swissPairings(players []string, points map[string]float64, previous [][]Pairing) []Pairing {
+	played := make(map[string]map[string]bool)
+	byes := make(map[string]bool)
+	for _, id := range players {
+		played[id] = make(map[string]bool)
+	}
+	for _, round := range previous {
+		for _, pairing := range round {
+			if pairing.Second == "" {
+				byes[pairing.First] = true
+				continue
+			}
+			played[pairing.First][pairing.Second] = true
+			played[pairing.Second][pairing.First] = true
+		}
+	}
+
+	ranked := append([]string{}, players...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return points[ranked[i]] > points[ranked[j]]
+	})
+
+	// the bye goes to the lowest placed player who hasn't had one, unless that leaves the
+	// others with no way to avoid a rematch. -1 stands for no bye.
+	candidates := []int{-1}
+	if len(ranked)%2 == 1 {
+		candidates = []int{}
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if !byes[ranked[i]] {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			candidates = append(candidates, len(ranked)-1)
+		}
+	}
+
+	withBye := func(bye int) ([]string, []Pairing) {
+		if bye == -1 {
+			return ranked, []Pairing{}
+		}
+		rest := append(append([]string{}, ranked[:bye]...), ranked[bye+1:]...)
+		return rest, []Pairing{Pairing{First: ranked[bye], GameID: -1}}
+	}
+
+	for _, bye := range candidates {
+		rest, byePairings := withBye(bye)
+		if games := pairUnplayed(rest, played); games != nil {
+			return append(games, byePairings...)
+		}
+	}
+
+	// every way of pairing the players repeats a game, so each takes the closest placed
+	// opponent they haven't met, or the closest if they've met everyone left
+	rest, byePairings := withBye(candidates[0])
+
+	games := []Pairing{}
+	for len(rest) > 0 {
+		first := rest[0]
+		rest = rest[1:]
+
+		opponent := 0
+		for i, id := range rest {
+			if !played[first][id] {
+				opponent = i
+				break
+			}
+		}
+		games = append(games, Pairing{First: first, Second: rest[opponent], GameID: -1})
+		rest = append(rest[:opponent], rest[opponent+1:]...)
+	}
+
+	return append(games, byePairings...)
+}
+
+// pairUnplayed pairs the ranked players so that nobody meets someone they've played before,
+// each with the closest placed opponent that still lets everyone below them be paired. It
+// returns nil if there is no such pairing.
+func pairUnplayed(ranked []string, played map[string]map[string]bool) []Pairing {
+	if len(ranked) == 0 {
+		return []Pairing{}
+	}
+
+	first := ranked[0]
+	for i := 1; i < len(ranked); i++ {
+		if played[first][ranked[i]] {
+			continue
+		}
+
+		rest := append(append([]string{}, ranked[1:i]...), ranked[i+1:]...)
+		if games := pairUnplayed(rest, played); games != nil {
+			return append([]Pairing{Pairing{First: first, Second: ranked[i], GameID: -1}}, games...)
+		}
+	}
+	return nil
+}
+
+// standings ranks the players by points, breaking ties in Swiss tournaments by Buchholz (the
+// points of everyone they played) and then Sonneborn-Berger (the points of everyone they beat,
+// and half those of everyone they drew), and in round robins by Sonneborn-Berger and then wins
+func (tournament *Tournament) standings(nameOf func(string) string) []Standing {
+	points := tournament.points()
+
+	byID := make(map[string]*Standing)
+	for _, id := range tournament.Players {
+		byID[id] = &Standing{Name: nameOf(id), Points: points[id]}
+	}
+
+	for _, round := range tournament.Pairings {
+		for _, pairing := range round {
+			if pairing.Result.Kind == "" || pairing.Second == "" {
+				continue
+			}
+			for _, id := range []string{pairing.First, pairing.Second} {
+				standing := byID[id]
+				opponentPoints := points[pairing.opponent(id)]
+				standing.Buchholz += opponentPoints
+
+				switch pairing.pointsFor(id) {
+				case 1:
+					standing.Wins++
+					standing.SonnebornBerger += opponentPoints
+				case 0.5:
+					standing.Draws++
+					standing.SonnebornBerger += opponentPoints / 2
+				default:
+					standing.Losses++
+				}
+			}
+		}
+	}
+
+	standings := []Standing{}
+	for _, id := range tournament.Players {
+		standings = append(standings, *byID[id])
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if tournament.Format == SWISS && a.Buchholz != b.Buchholz {
+			return a.Buchholz > b.Buchholz
+		}
+		if a.SonnebornBerger != b.SonnebornBerger {
+			return a.SonnebornBerger > b.SonnebornBerger
+		}
+		return a.Wins > b.Wins
+	})
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
+
+// String describes a standing as e.g. "1. alice 2.5 (2 wins, 1 draw, 0 losses; Buchholz 3, SB 2.25)"
+func (standing Standing) String() string {
+	return strconv.Itoa(standing.Rank) + ". " + standing.Name + " " + formatPoints(standing.Points) + " (" +
+		plural(standing.Wins, "win", "wins") + ", " + plural(standing.Draws, "draw", "draws") + ", " +
+		plural(standing.Losses, "loss", "losses") + "; Buchholz " + formatPoints(standing.Buchholz) +
+		", SB " + formatPoints(standing.SonnebornBerger) + ")"
+}
+
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+// summary describes the tournament for the tournament list
+func (tournament *Tournament) summary() TournamentSummary {
+	return TournamentSummary{
+		ID:       tournament.ID,
+		Format:   tournament.Format,
+		Settings: tournament.Settings,
+		Players:  len(tournament.Players),
+		Rounds:   tournament.Rounds,
+		Status:   tournament.status(),
+	}
+}
+
+// parseTournament parses the options given to tn mk, e.g. "swiss rounds 4 9x9 5m": a format,
+// optionally a number of rounds for Swiss, and the options for each game as for mk
+func parseTournament(options string) (*Tournament, error) {
+	fields := strings.Fields(strings.ToLower(options))
+	if len(fields) == 0 || fields[0] != ROUNDROBIN && fields[0] != SWISS {
+		return nil, errors.New("Choose a format for the tournament: roundrobin or swiss")
+	}
+
+	tournament := &Tournament{Format: fields[0], clients: make(map[string]*SocketClient)}
+	fields = fields[1:]
+
+	if len(fields) >= 2 && fields[0] == "rounds" {
+		if tournament.Format != SWISS {
+			return nil, errors.New("Only Swiss tournaments have a chosen number of rounds")
+		}
+		rounds, err := strconv.Atoi(fields[1])
+		if err != nil || rounds < 1 {
+			return nil, errors.New("The syntax for the number of rounds is rounds <n>")
+		}
+		tournament.Rounds = rounds
+		fields = fields[2:]
+	}
+
+	settings, err := parseGameSettings(strings.Join(fields, " "))
+	if err != nil {
+		return nil, err
+	}
+	if settings.Bot != "" {
+		return nil, errors.New("Bots can't play in tournaments")
+	}
+	tournament.Settings = settings
+	return tournament, nil
+}
+
+// tournamentName is how a player appears in standings and pairings
+func (server *Server) tournamentName(userID string) string {
+	if name := server.names.Name(userID); name != "" {
+		return name
+	}
+	return guestName(userID)
+}
+
+// handleTournament lists, creates, joins, starts and shows the standings of tournaments,
+// following the subcommand in req.Data
+func (server *Server) handleTournament(req Request, socketClient *SocketClient) []SocketClientResponse {
+	response := Request{
+		UserID: req.UserID,
+		Action: TOURNAMENT,
+		GameID: -1,
+	}
+
+	fields := strings.Fields(req.Data)
+	command := "ls"
+	if len(fields) > 0 {
+		command = fields[0]
+	}
+
+	var tournament *Tournament
+	if command == "jn" || command == "go" || command == "st" {
+		id := -1
+		if len(fields) > 1 {
+			id, _ = strconv.Atoi(fields[1])
+		}
+
+		server.tournaments.M.Lock()
+		tournament = server.tournaments.tournaments[id]
+		server.tournaments.M.Unlock()
+
+		if tournament == nil {
+			response.Data = "That tournament doesn't exist"
+			return []SocketClientResponse{
+				SocketClientResponse{
+					socketClient,
+					response,
+				},
+			}
+		}
+	}
+
+	var err error
+	responses := []SocketClientResponse{}
+	switch command {
+	case "ls":
+		response.Tournaments = server.listTournaments()
+		response.Data = "TOURNAMENTS"
+		if len(response.Tournaments) == 0 {
+			response.Data = "There are no tournaments yet. Host one with 'tn mk roundrobin' or 'tn mk swiss'."
+		}
+	case "mk":
+		err = server.createTournament(req, &response)
+	case "jn":
+		err = server.joinTournament(req, socketClient, tournament, &response)
+	case "go":
+		err = server.beginTournament(req, tournament, &response)
+		if err == nil {
+			responses = server.startRound(tournament, 1)
+		}
+	case "st":
+		server.tournaments.M.Lock()
+		response.Standings = tournament.standings(server.tournamentName)
+		response.Data = "Tournament #" + strconv.Itoa(tournament.ID) + " (" + tournament.Format + ", " + tournament.status() + ")"
+		server.tournaments.M.Unlock()
+	default:
+		err = errors.New("Unrecognized tournament command: " + command)
+	}
+
+	if err != nil {
+		response.Data = err.Error()
+	} else {
+		response.Success = true
+		if command == "mk" || command == "jn" || command == "go" {
+			server.saveTournaments()
+		}
+	}
+
+	return append([]SocketClientResponse{
+		SocketClientResponse{
+			socketClient,
+			response,
+		},
+	}, responses...)
+}
+
+// listTournaments describes every tournament, newest first
+func (server *Server) listTournaments() []TournamentSummary {
+	server.tournaments.M.Lock()
+	defer server.tournaments.M.Unlock()
+
+	summaries := []TournamentSummary{}
+	for _, tournament := range server.tournaments.tournaments {
+		summaries = append(summaries, tournament.summary())
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].ID > summaries[j].ID
+	})
+	return summaries
+}
+
+func (server *Server) createTournament(req Request, response *Request) error {
+	tournament, err := parseTournament(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(req.Data), "mk")))
+	if err != nil {
+		return err
+	}
+
+	server.tournaments.M.Lock()
+	defer server.tournaments.M.Unlock()
+
+	tournament.ID = server.tournaments.nextID
+	tournament.Organizer = req.UserID
+	server.tournaments.tournaments[tournament.ID] = tournament
+	server.tournaments.nextID++
+
+	response.Data = "Created tournament #" + strconv.Itoa(tournament.ID) + ": " + tournament.Format + ", " + tournament.Settings.String() + ". Players join with 'tn jn " + strconv.Itoa(tournament.ID) + "', and you start it with 'tn go " + strconv.Itoa(tournament.ID) + "'."
+	return nil
+}
+
+func (server *Server) joinTournament(req Request, socketClient *SocketClient, tournament *Tournament, response *Request) error {
+	if tournament.Settings.Rated && server.names.Name(req.UserID) == "" {
+		return errors.New("Choose a name with nm before playing rated games")
+	}
+
+	server.tournaments.M.Lock()
+	defer server.tournaments.M.Unlock()
+
+	if tournament.round() > 0 || tournament.Finished {
+		return errors.New("That tournament has already started")
+	}
+	if _, ok := tournament.clients[req.UserID]; ok {
+		return errors.New("You're already playing in that tournament")
+	}
+
+	tournament.Players = append(tournament.Players, req.UserID)
+	tournament.clients[req.UserID] = socketClient
+	response.Data = "You joined tournament #" + strconv.Itoa(tournament.ID) + ", with " + strconv.Itoa(len(tournament.Players)) + " players so far. Your games start as soon as each round begins."
+	return nil
+}
+
+// beginTournament closes registration, so that the first round can be paired
+func (server *Server) beginTournament(req Request, tournament *Tournament, response *Request) error {
+	server.tournaments.M.Lock()
+	defer server.tournaments.M.Unlock()
+
+	switch {
+	case req.UserID != tournament.Organizer:
+		return errors.New("Only the player who made the tournament can start it")
+	case tournament.round() > 0 || tournament.Finished:
+		return errors.New("That tournament has already started")
+	case len(tournament.Players) < 2:
+		return errors.New("A tournament needs at least two players")
+	}
+
+	switch {
+	case tournament.Format == ROUNDROBIN:
+		tournament.Rounds = len(tournament.Players) - 1 + len(tournament.Players)%2
+	case tournament.Rounds == 0:
+		// enough rounds for a single player to win every game
+		tournament.Rounds = int(math.Ceil(math.Log2(float64(len(tournament.Players)))))
+	}
+
+	response.Data = "Tournament #" + strconv.Itoa(tournament.ID) + " has begun, with " + strconv.Itoa(len(tournament.Players)) + " players over " + strconv.Itoa(tournament.Rounds) + " rounds"
+	return nil
+}
+
+// startRound pairs the players for round, counting from 1, and starts their games, or ends
+// the tournament if every round has been played. It does nothing unless the previous round
+// has just finished, so that it's safe to call more than once.
+func (server *Server) startRound(tournament *Tournament, round int) []SocketClientResponse {
+	server.tournaments.M.Lock()
+	if tournament.Finished || tournament.round() != round-1 || round > 1 && !tournament.roundComplete() {
+		server.tournaments.M.Unlock()
+		return nil
+	}
+
+	if round > tournament.Rounds {
+		tournament.Finished = true
+		responses := server.announceStandings(tournament)
+		server.tournaments.M.Unlock()
+		server.saveTournaments()
+		return responses
+	}
+
+	pairings := roundRobinPairings(tournament.Players, round-1)
+	if tournament.Format == SWISS {
+		pairings = swissPairings(tournament.Players, tournament.points(), tournament.Pairings)
+	}
+	tournament.Pairings = append(tournament.Pairings, pairings)
+
+	clients := make(map[string]*SocketClient)
+	for id, socketClient := range tournament.clients {
+		clients[id] = socketClient
+	}
+	server.tournaments.M.Unlock()
+
+	log.Println("Starting round", round, "of tournament", tournament.ID)
+
+	// games are created without the registry's lock, since each game is locked as it starts
+	responses := []SocketClientResponse{}
+	completed := false
+	for i, pairing := range pairings {
+		notice := Request{
+			GameID:  -1,
+			Action:  TOURNAMENT,
+			Success: true,
+			Round:   round,
+		}
+		prefix := "Round " + strconv.Itoa(round) + " of tournament #" + strconv.Itoa(tournament.ID) + ": "
+
+		if pairing.Second == "" {
+			notice.UserID = pairing.First
+			notice.Data = prefix + "you have a bye, which scores a point"
+			responses = append(responses, SocketClientResponse{clients[pairing.First], notice})
+			completed = server.recordTournamentResult(tournament, round-1, i, gomoku.Result{Kind: gomoku.WON, WinnerID: pairing.First}) || completed
+			continue
+		}
+
+		// players whose connection has gone forfeit without a game
+		absent := ""
+		for _, id := range []string{pairing.First, pairing.Second} {
+			if socketClient, ok := clients[id]; !ok || socketClient != nil && socketClient.isClosed() {
+				absent = id
+			}
+		}
+		if absent != "" {
+			present := pairing.opponent(absent)
+			notice.UserID = present
+			notice.Data = prefix + server.tournamentName(absent) + " isn't connected, so you win by forfeit"
+			responses = append(responses, SocketClientResponse{clients[present], notice})
+			completed = server.recordTournamentResult(tournament, round-1, i, gomoku.Result{Kind: gomoku.FORFEITED, WinnerID: present}) || completed
+			continue
+		}
+
+		for _, id := range []string{pairing.First, pairing.Second} {
+			notice.UserID = id
+			notice.Data = prefix + "you play " + server.tournamentName(pairing.opponent(id))
+			responses = append(responses, SocketClientResponse{clients[id], notice})
+		}
+
+		game, gameResponses := server.startPairedGame(pairing.First, clients[pairing.First], pairing.Second, clients[pairing.Second], tournament.Settings)
+		game.M.Lock()
+		game.tournament = tournament
+		game.round = round - 1
+		game.pairing = i
+		// the game was first saved before it was linked to the tournament
+		server.saveSnapshot(game)
+		server.watchNoShow(game)
+		game.M.Unlock()
+
+		server.tournaments.M.Lock()
+		tournament.Pairings[round-1][i].GameID = game.ID
+		server.tournaments.M.Unlock()
+
+		responses = append(responses, gameResponses...)
+	}
+
+	server.saveTournaments()
+
+	// a round of nothing but byes and forfeits is over already
+	if completed {
+		server.scheduleRound(tournament, round+1)
+	}
+	return responses
+}
+
+// recordTournamentResult records a pairing's result, returning true if it completed the round
+func (server *Server) recordTournamentResult(tournament *Tournament, round int, index int, result gomoku.Result) bool {
+	server.tournaments.M.Lock()
+	completed := tournament.setResult(round, index, result)
+	server.tournaments.M.Unlock()
+
+	server.saveTournaments()
+	return completed
+}
+
+// saveTournaments saves every tournament; the caller must not hold the registry's lock
+func (server *Server) saveTournaments() {
+	server.tournaments.M.Lock()
+	defer server.tournaments.M.Unlock()
+
+	err := server.store.SaveTournaments(server.tournaments.list())
+	if err != nil {
+		log.Println("Could not save tournaments:", err)
+	}
+}
+
+// resumeTournaments picks restored tournaments up where they left off: no-show timers run
+// again, games that ended just before the restart have their results recorded, and rounds
+// that were already over are followed by the next
+func (server *Server) resumeTournaments() {
+	for _, game := range server.games {
+		if game.tournament == nil {
+			continue
+		}
+
+		game.M.Lock()
+		if game.IsOver && game.Rematches == 0 {
+			server.recordTournamentGame(game)
+		}
+		server.watchNoShow(game)
+		game.M.Unlock()
+	}
+
+	server.tournaments.M.Lock()
+	complete := []*Tournament{}
+	for _, tournament := range server.tournaments.tournaments {
+		if !tournament.Finished && tournament.roundComplete() {
+			complete = append(complete, tournament)
+		}
+	}
+	server.tournaments.M.Unlock()
+
+	// startRound does nothing for a round that has already started
+	for _, tournament := range complete {
+		server.scheduleRound(tournament, tournament.round()+1)
+	}
+}
+
+// recordTournamentGame records the result of a tournament game once it's over, and starts the
+// next round shortly after the last game of a round ends; the caller must hold the game's lock
+func (server *Server) recordTournamentGame(game *GameRoom) {
+	if server.recordTournamentResult(game.tournament, game.round, game.pairing, game.Result) {
+		server.scheduleRound(game.tournament, game.round+2)
+	}
+}
+
+// scheduleRound starts a round once players have had a moment to see the last one's results
+func (server *Server) scheduleRound(tournament *Tournament, round int) {
+	time.AfterFunc(roundDelay, func() {
+		for _, socketClientResponse := range server.startRound(tournament, round) {
+			socketClientResponse.send()
+		}
+	})
+}
+
+// announceStandings sends everyone in a finished tournament the final standings; the caller
+// must hold the registry's lock
+func (server *Server) announceStandings(tournament *Tournament) []SocketClientResponse {
+	standings := tournament.standings(server.tournamentName)
+	log.Println("Tournament", tournament.ID, "finished, won by", standings[0].Name)
+
+	responses := []SocketClientResponse{}
+	for _, id := range tournament.Players {
+		response := Request{
+			GameID:    -1,
+			UserID:    id,
+			Action:    TOURNAMENT,
+			Success:   true,
+			Data:      "Tournament #" + strconv.Itoa(tournament.ID) + " is over. Congratulations to " + standings[0].Name + "!",
+			Standings: standings,
+		}
+		responses = append(responses, SocketClientResponse{tournament.clients[id], response})
+	}
+	return responses
+}
+
+// watchNoShow forfeits a tournament game for the player to move if they still haven't played
+// their first turn once noShowTimeout has passed since it began. It is called whenever a turn
+// begins, so that each player is timed from the start of their own first turn; the caller
+// must hold the game's lock.
+func (server *Server) watchNoShow(game *GameRoom) {
+	if game.noShow != nil {
+		game.noShow.Stop()
+	}
+
+	if game.tournament == nil || game.IsOver || game.Rematches > 0 {
+		return
+	}
+
+	noShowID := game.PlayerToMove()
+	if noShowID == "" || game.LastTurnBy(noShowID) != -1 {
+		return
+	}
+
+	turn := game.Turn
+	game.noShow = time.AfterFunc(noShowTimeout, func() {
+		game.M.Lock()
+		defer game.M.Unlock()
+
+		for _, socketClientResponse := range server.forfeitNoShow(game, turn, time.Now()) {
+			socketClientResponse.send()
+		}
+	})
+}
+
+// forfeitNoShow ends the game if it is still on turn and the player to move has never played
+// a turn in it, returning the responses that tell everyone, or nil if the game goes on
+func (server *Server) forfeitNoShow(game *GameRoom, turn int, now time.Time) []SocketClientResponse {
+	if game.IsOver || game.Rematches > 0 || game.Turn != turn {
+		return nil
+	}
+
+	noShowID := game.PlayerToMove()
+	if noShowID == "" || game.LastTurnBy(noShowID) != -1 {
+		return nil
+	}
+
+	game.end(gomoku.Result{Kind: gomoku.FORFEITED, WinnerID: GetOpponentID(game, noShowID)}, now)
+	server.saveSnapshot(game)
+
+	response := Request{
+		GameID:      game.ID,
+		UserID:      noShowID,
+		Action:      FORFEIT,
+		Success:     true,
+		GameOver:    true,
+		Data:        "didn't play in time, and forfeits the game",
+		Board:       game.Board.Spaces(),
+		Colors:      game.PlayerColors(),
+		LastMove:    game.LastMove(),
+		WinningLine: game.WinningLine,
+		Clocks:      game.clocks(),
+		Result:      game.Result,
+	}
+
+	return game.broadcast(response)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go_gomoku/gomoku"
+)
+
+func TestRoundRobinPairings(t *testing.T) {
+	for _, count := range []int{4, 5} {
+		players := []string{"a", "b", "c", "d", "e"}[:count]
+		rounds := count - 1 + count%2
+
+		met := make(map[string]int)
+		byes := make(map[string]int)
+		for round := 0; round < rounds; round++ {
+			seen := make(map[string]bool)
+			for _, pairing := range roundRobinPairings(players, round) {
+				if seen[pairing.First] || seen[pairing.Second] {
+					t.Fatalf("Expected each player once in round %d, got %v", round, pairing)
+				}
+				seen[pairing.First] = true
+				seen[pairing.Second] = true
+
+				if pairing.Second == "" {
+					byes[pairing.First]++
+					continue
+				}
+				key := pairing.First + pairing.Second
+				if pairing.First > pairing.Second {
+					key = pairing.Second + pairing.First
+				}
+				met[key]++
+			}
+		}
+
+		if len(met) != count*(count-1)/2 {
+			t.Errorf("Expected every pair of %d players to meet, got %v", count, met)
+		}
+		for key, times := range met {
+			if times != 1 {
+				t.Errorf("Expected %s to meet once, got %d", key, times)
+			}
+		}
+		if count%2 == 1 && len(byes) != count {
+			t.Errorf("Expected each of %d players to have one bye, got %v", count, byes)
+		}
+	}
+}
+
+func TestSwissPairings(t *testing.T) {
+	players := []string{"a", "b", "c", "d", "e"}
+	first := swissPairings(players, map[string]float64{}, nil)
+	if len(first) != 3 || first[2].First != "e" || first[2].Second != "" {
+		t.Fatalf("Expected the last player to have a bye in round 1, got %v", first)
+	}
+
+	// a beat b, c beat d, e had a bye
+	first[0].Result = gomoku.Result{Kind: gomoku.WON, WinnerID: first[0].First}
+	first[1].Result = gomoku.Result{Kind: gomoku.WON, WinnerID: first[1].First}
+	first[2].Result = gomoku.Result{Kind: gomoku.WON, WinnerID: "e"}
+	points := map[string]float64{"a": 1, "c": 1, "e": 1}
+
+	second := swissPairings(players, points, [][]Pairing{first})
+	expected := []Pairing{
+		{First: "a", Second: "c", GameID: -1},
+		{First: "e", Second: "b", GameID: -1},
+		{First: "d", GameID: -1},
+	}
+	for i, pairing := range expected {
+		if second[i] != pairing {
+			t.Errorf("Expected pairing %v, got %v", pairing, second[i])
+		}
+	}
+}
+
+func TestSwissPairingsAvoidRematches(t *testing.T) {
+	// everyone drew, so the players stay in order, and taking the closest new opponent for a
+	// and then c would leave e to play f again
+	players := []string{"a", "b", "c", "d", "e", "f"}
+	points := map[string]float64{"a": 0.5, "b": 0.5, "c": 0.5, "d": 0.5, "e": 0.5, "f": 0.5}
+	previous := [][]Pairing{{
+		{First: "a", Second: "c"},
+		{First: "b", Second: "d"},
+		{First: "e", Second: "f"},
+	}}
+
+	pairings := swissPairings(players, points, previous)
+	expected := []Pairing{
+		{First: "a", Second: "b", GameID: -1},
+		{First: "c", Second: "e", GameID: -1},
+		{First: "d", Second: "f", GameID: -1},
+	}
+	if len(pairings) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, pairings)
+	}
+	for i, pairing := range expected {
+		if pairings[i] != pairing {
+			t.Errorf("Expected pairing %v, got %v", pairing, pairings[i])
+		}
+	}
+}
+
+func TestStandings(t *testing.T) {
+	tournament := &Tournament{Format: SWISS, Players: []string{"a", "b", "c", "d"}}
+	tournament.Pairings = [][]Pairing{
+		{
+			{First: "a", Second: "b", Result: gomoku.Result{Kind: gomoku.WON, WinnerID: "a"}},
+			{First: "c", Second: "d", Result: gomoku.Result{Kind: gomoku.DRAWN}},
+		},
+		{
+			{First: "a", Second: "c", Result: gomoku.Result{Kind: gomoku.RESIGNED, WinnerID: "c"}},
+			{First: "b", Second: "d", Result: gomoku.Result{Kind: gomoku.FORFEITED, WinnerID: "b"}},
+		},
+	}
+
+	standings := tournament.standings(func(id string) string { return id })
+	names := []string{}
+	for _, standing := range standings {
+		names = append(names, standing.Name)
+	}
+	// c has 1.5; a and b have 1 each, but a played stronger opponents
+	if strings.Join(names, " ") != "c a b d" {
+		t.Errorf("Expected c a b d, got %v", names)
+	}
+
+	if standings[0].String() != "1. c 1.5 (1 win, 1 draw, 0 losses; Buchholz 1.5, SB 1.25)" {
+		t.Errorf("Unexpected standing: %s", standings[0])
+	}
+}
+
+func TestTournament(t *testing.T) {
+	roundDelay = time.Hour
+	defer func() { roundDelay = 10 * time.Second }()
+
+	server := NewServer()
+	responses := server.handleTournament(Request{UserID: "mock_user1", Action: TOURNAMENT, Data: "mk swiss rounds 2 9x9"}, nil)
+	if !responses[0].response.Success {
+		t.Fatal(responses[0].response.Data)
+	}
+
+	gone := &SocketClient{Closed: true}
+	server.handleTournament(Request{UserID: "mock_user1", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user2", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user3", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user4", Data: "jn 0"}, gone)
+
+	responses = server.handleTournament(Request{UserID: "mock_user2", Data: "go 0"}, nil)
+	if responses[0].response.Success {
+		t.Error("Expected only the organizer to be able to start the tournament")
+	}
+
+	server.handleTournament(Request{UserID: "mock_user1", Data: "go 0"}, nil)
+	tournament := server.tournaments.tournaments[0]
+	round := tournament.Pairings[0]
+	if len(round) != 2 || round[1].Result.Kind != gomoku.FORFEITED || round[1].Result.WinnerID != "mock_user3" {
+		t.Fatalf("Expected mock_user4 to forfeit to mock_user3 for not being connected, got %v", round)
+	}
+
+	game := server.games[round[0].GameID]
+	if game == nil || len(game.Players) != 2 || game.Settings.Size != 9 {
+		t.Fatalf("Expected a 9x9 game between mock_user1 and mock_user2, got %v", round[0])
+	}
+
+	// nobody has played a turn, so the player to move forfeits
+	game.M.Lock()
+	server.forfeitNoShow(game, game.Turn, time.Now())
+	game.M.Unlock()
+	if !tournament.roundComplete() || tournament.Pairings[0][0].Result.Kind != gomoku.FORFEITED {
+		t.Fatalf("Expected the no-show to complete the round, got %v", tournament.Pairings[0])
+	}
+	winner := tournament.Pairings[0][0].Result.WinnerID
+
+	server.startRound(tournament, 2)
+	server.startRound(tournament, 2)
+	if len(tournament.Pairings) != 2 {
+		t.Fatalf("Expected the second round to start once, got %d rounds", len(tournament.Pairings))
+	}
+	if tournament.Pairings[1][0].First != winner && tournament.Pairings[1][0].Second != winner {
+		t.Errorf("Expected the two winners to meet, got %v", tournament.Pairings[1])
+	}
+
+	game = server.games[tournament.Pairings[1][0].GameID]
+	server.handleResign(Request{GameID: game.ID, UserID: winner, Action: RESIGN}, nil, game)
+	responses = server.handleRematch(Request{GameID: game.ID, UserID: winner, Action: REMATCH}, nil, game)
+	if responses[0].response.Success {
+		t.Error("Expected tournament games not to be rematched")
+	}
+
+	responses = server.startRound(tournament, 3)
+	if !tournament.Finished || len(responses) != 4 || len(responses[0].response.Standings) != 4 {
+		t.Fatalf("Expected the final standings to be sent to every player, got %v", responses)
+	}
+	if responses[0].response.Standings[0].Name != server.tournamentName(GetOpponentID(game, winner)) {
+		t.Errorf("Expected the winner of the final to top the standings, got %v", responses[0].response.Standings)
+	}
+}
+
+func TestTournamentNoShowTimedFromOwnTurn(t *testing.T) {
+	noShowTimeout = 200 * time.Millisecond
+	defer func() { noShowTimeout = 2 * time.Minute }()
+
+	server := NewServer()
+	server.handleTournament(Request{UserID: "mock_user1", Action: TOURNAMENT, Data: "mk swiss rounds 1"}, nil)
+	server.handleTournament(Request{UserID: "mock_user1", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user2", Data: "jn 0"}, nil)
+	server.handleTournament(Request{UserID: "mock_user1", Data: "go 0"}, nil)
+
+	game := server.games[server.tournaments.tournaments[0].Pairings[0][0].GameID]
+	game.M.Lock()
+	first := game.PlayerToMove()
+	second := GetOpponentID(game, first)
+	game.M.Unlock()
+
+	time.Sleep(150 * time.Millisecond)
+	server.handleRequest(Request{GameID: game.ID, UserID: first, Action: MOVE, Data: "8 8, 8 9, 1 1"}, nil)
+
+	// the second player's first turn has only just begun, though the game began long ago
+	time.Sleep(100 * time.Millisecond)
+	game.M.Lock()
+	over := game.IsOver
+	game.M.Unlock()
+	if over {
+		t.Fatal("Expected the second player to have the whole timeout from the start of their turn")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	game.M.Lock()
+	defer game.M.Unlock()
+	if !game.IsOver || game.Result.Kind != gomoku.FORFEITED || game.Result.WinnerID != first {
+		t.Errorf("Expected %s to forfeit to %s, got %v", second, first, game.Result)
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bufio"
+	"io"
+	"log"
 	"net"
-	"strconv"
 	"sync"
+
+	"go_gomoku/gomoku"
 )
 
 // SocketClient contains the connection to a client
@@ -12,46 +16,53 @@ type SocketClient struct {
 	Data   chan []byte
 	Closed bool
 	M      sync.Mutex
+	// UserID is who the client proved to be by signing in or resuming a game, and is
+	// empty until it does. Requests on the connection act for this user, whatever they claim.
+	UserID string
+}
+
+// isClosed checks whether the connection has been closed
+func (socketClient *SocketClient) isClosed() bool {
+	socketClient.M.Lock()
+	defer socketClient.M.Unlock()
+	return socketClient.Closed
 }
 
 // Receive listens for data and handles it
 func (socketClient *SocketClient) Receive(handler func([]byte), connected *chan bool) {
 	firstMessage := true
+	reader := bufio.NewReader(socketClient.Socket)
 	for {
-		message := make([]byte, 4096)
-		length, err := socketClient.Socket.Read(message)
+		message, err := readFrame(reader)
 		if err != nil {
+			if err != io.EOF {
+				log.Println("Closing connection:", err)
+			}
 			socketClient.Socket.Close()
 			break
 		}
-		if length > 0 {
-			// switch
-			if firstMessage {
-				go func() { *connected <- true }()
-				firstMessage = false
-			}
-			handler(message)
+		if firstMessage {
+			go func() { *connected <- true }()
+			firstMessage = false
 		}
+		handler(message)
 	}
 }
 
-type Coord struct {
-	X int
-	Y int
-}
-
-func (coord Coord) String() string {
-	return strconv.Itoa(coord.X) + " " + strconv.Itoa(coord.Y)
-}
-
 type Message struct {
 	Content string
 	Author  string
 }
 
 type OpenRoom struct {
-	ID     int
-	UserID string
+	ID       int
+	UserID   string
+	Settings GameSettings
+	// OpponentID is only set for games in progress
+	OpponentID string
+	// Name and OpponentName are how the players appear to others
+	Name         string
+	OpponentName string
 }
 
 type Request struct {
@@ -65,11 +76,76 @@ type Request struct {
 	Turn     int
 	Colors   map[string]string
 	Board    map[string]map[string]bool
-	Home     []OpenRoom
+	// LastMove is the stones placed on the last turn that placed any
+	LastMove []gomoku.Coord
+	// WinningLine is the line of stones that won the game, if it was won on the board
+	WinningLine []gomoku.Coord
+	Home        []OpenRoom
+	// Live lists games in progress that can be watched
+	Live     []OpenRoom
+	Settings GameSettings
+	// Instructions explain the opening to the player whose turn it is
+	Instructions string
+	// Token lets a player resume their game from a new connection
+	Token    string
+	Messages []Message
+	// Clocks are the time each player has left, in timed games
+	Clocks map[string]gomoku.PlayerClock
+	// Result is how the game ended, once it is over
+	Result gomoku.Result
+	// Score is the running score of the games played in the room
+	Score MatchScore
+	// Names maps the players in the game who have registered a name to that name
+	Names map[string]string
+	// InviteCode lets others join a private room, and is only sent to the room's players
+	InviteCode string
+	// Leaderboard lists the best rated players, and YourRating the player's own place,
+	// with a Rank of 0 if they haven't played a rated game
+	Leaderboard []RatingEntry
+	YourRating  RatingEntry
+	// Round is set when a tournament round starts, on the notice sent to each of its players
+	Round       int
+	Tournaments []TournamentSummary
+	Standings   []Standing
+}
+
+// TournamentSummary describes a tournament in the tournament list
+type TournamentSummary struct {
+	ID       int
+	Format   string
+	Settings GameSettings
+	Players  int
+	Rounds   int
+	Status   string
+}
+
+// Standing is a player's place in a tournament
+type Standing struct {
+	Rank            int
+	Name            string
+	Points          float64
+	Wins            int
+	Draws           int
+	Losses          int
+	Buchholz        float64
+	SonnebornBerger float64
+}
+
+// RatingEntry is a player's place on the leaderboard
+type RatingEntry struct {
+	Rank   int
+	Name   string
+	Rating int
+	Wins   int
+	Losses int
+	Draws  int
 }
 
 type Player struct {
 	UserID       string
 	SocketClient *SocketClient
-	Color        string
+	Bot          *Bot
+	Token        string
+	// Left is set once the player has gone back to the home screen
+	Left bool
 }